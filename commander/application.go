@@ -2,9 +2,16 @@ package commander
 
 import (
 	"github.com/gdamore/tcell/v2"
+	"github.com/mushkevych/9ofm/commander/bookmarks"
 	"github.com/mushkevych/9ofm/commander/controller"
+	"github.com/mushkevych/9ofm/commander/hashcache"
+	"github.com/mushkevych/9ofm/commander/jobs"
+	"github.com/mushkevych/9ofm/commander/journal"
 	"github.com/mushkevych/9ofm/commander/model"
+	"github.com/mushkevych/9ofm/commander/session"
+	"github.com/mushkevych/9ofm/commander/system"
 	tview "gitlab.com/tslocum/cview"
+	"path/filepath"
 
 	log "github.com/sirupsen/logrus"
 )
@@ -14,8 +21,12 @@ type Application struct {
 	AlphaPanel *controller.FilePanelController
 	BetaPanel  *controller.FilePanelController
 	BottomRow  *controller.FxxController
+	StatusRow  *controller.StatusController
 	flexLayout *tview.Flex
 	pages      *tview.Pages
+
+	// file the state of the UI is saved into on exit
+	sessionPath string
 }
 
 func (app *Application) Renderers() []controller.Renderer {
@@ -23,14 +34,24 @@ func (app *Application) Renderers() []controller.Renderer {
 		app.AlphaPanel,
 		app.BetaPanel,
 		app.BottomRow,
+		app.StatusRow,
 	}
 }
 
-func NewApplication(tviewApp *tview.Application) (*Application, error) {
-	application, err := buildControllers(tviewApp)
+// NewApplication builds the UI, with the panels starting in the directories given by the options
+func NewApplication(tviewApp *tview.Application, options Options) (*Application, error) {
+	configDir, err := system.ConfigDir()
+	if err != nil {
+		return nil, err
+	}
+	sessionPath := filepath.Join(configDir, "session.json")
+	state := loadSession(sessionPath, options)
+
+	application, err := buildControllers(tviewApp, options, state)
 	if err != nil {
 		return nil, err
 	}
+	application.sessionPath = sessionPath
 
 	err = application.buildLayout()
 	if err != nil {
@@ -41,11 +62,36 @@ func NewApplication(tviewApp *tview.Application) (*Application, error) {
 	if err != nil {
 		return nil, err
 	}
+
+	if state != nil {
+		if err = application.restoreSession(state); err != nil {
+			return nil, err
+		}
+	}
 	return application, nil
 }
 
-func buildControllers(tviewApp *tview.Application) (*Application, error) {
-	alphaFileTree, err := model.ReadFileTree("/")
+// loadSession returns the state of the previous session, or nil if it is not to be restored or there is none.
+// Failure to load the session is not fatal: the UI starts afresh.
+func loadSession(sessionPath string, options Options) *session.State {
+	if options.NoRestore {
+		return nil
+	}
+	state, err := session.Load(sessionPath)
+	if err != nil {
+		log.WithError(err).Warn("unable to restore the session")
+		return nil
+	}
+	return state
+}
+
+func buildControllers(tviewApp *tview.Application, options Options, state *session.State) (*Application, error) {
+	alphaRoot, betaRoot, err := options.panelRoots(state)
+	if err != nil {
+		return nil, err
+	}
+
+	alphaFileTree, err := model.ReadFileTree(alphaRoot)
 	if err != nil {
 		return nil, err
 	}
@@ -54,7 +100,7 @@ func buildControllers(tviewApp *tview.Application) (*Application, error) {
 		return nil, err
 	}
 
-	betaFileTree, err := model.ReadFileTree("/")
+	betaFileTree, err := model.ReadFileTree(betaRoot)
 	if err != nil {
 		return nil, err
 	}
@@ -63,12 +109,23 @@ func buildControllers(tviewApp *tview.Application) (*Application, error) {
 		return nil, err
 	}
 
+	configDir, err := system.ConfigDir()
+	if err != nil {
+		return nil, err
+	}
+
 	pages := tview.NewPages()
+	jobManager := jobs.NewManager(system.Config.GetInt("jobs.workers"))
+	operationJournal := journal.Open(filepath.Join(configDir, "journal.jsonl"), system.Config.GetInt("journal.size"))
+	hashCache := openHashCache()
+	model.SetHashCache(hashCache)
+	hotlist := openBookmarks(filepath.Join(configDir, "bookmarks.json"))
 	application := &Application{
 		tviewApp:   tviewApp,
 		AlphaPanel: AlphaPanel,
 		BetaPanel:  BetaPanel,
-		BottomRow:  controller.NewFxxController(tviewApp, pages),
+		BottomRow:  controller.NewFxxController(tviewApp, pages, jobManager, operationJournal, hashCache, hotlist),
+		StatusRow:  controller.NewStatusController(tviewApp, jobManager),
 		flexLayout: tview.NewFlex(),
 		pages:      pages,
 	}
@@ -76,20 +133,55 @@ func buildControllers(tviewApp *tview.Application) (*Application, error) {
 	return application, nil
 }
 
+// openHashCache loads the cache of the file hashes; the comparison works without the cache,
+// hence nil is returned if the cache is disabled or can not be loaded
+func openHashCache() *hashcache.Cache {
+	cacheSize := system.Config.GetInt("compare.cache")
+	if cacheSize <= 0 {
+		return nil
+	}
+
+	cacheDir, err := system.CacheDir()
+	if err != nil {
+		log.WithError(err).Warn("hash cache is disabled")
+		return nil
+	}
+	hashCache, err := hashcache.Open(filepath.Join(cacheDir, "hashes"), cacheSize)
+	if err != nil {
+		log.WithError(err).Warn("hash cache is disabled")
+		return nil
+	}
+	return hashCache
+}
+
+// openBookmarks loads the hotlist of the directories; the UI works without it, hence nil is returned
+// if it can not be loaded, leaving the file intact
+func openBookmarks(path string) *bookmarks.List {
+	hotlist, err := bookmarks.Open(path)
+	if err != nil {
+		log.WithError(err).Warn("bookmarks are disabled")
+		return nil
+	}
+	return hotlist
+}
+
 func (app *Application) buildLayout() error {
 	app.flexLayout.SetDirection(tview.FlexRow)
 
-	// header
-	header := tview.NewBox()
-	header.SetBorder(true)
-	header.SetTitle("Header")
-	app.flexLayout.AddItem(header, 1, 1, false)
+	// header with the progress of the background jobs
+	app.flexLayout.AddItem(app.StatusRow.GraphicElement(), 1, 1, false)
 
 	// panels
 	panels := tview.NewFlex()
 	panels.SetDirection(tview.FlexColumn)
-	panels.AddItem(app.AlphaPanel.GraphicElement(), 0, 1, false)
-	panels.AddItem(app.BetaPanel.GraphicElement(), 0, 1, false)
+	for _, filePanel := range []*controller.FilePanelController{app.AlphaPanel, app.BetaPanel} {
+		// file table with the one-line footer underneath
+		column := tview.NewFlex()
+		column.SetDirection(tview.FlexRow)
+		column.AddItem(filePanel.GraphicElement(), 0, 1, false)
+		column.AddItem(filePanel.Footer(), 1, 0, false)
+		panels.AddItem(column, 0, 1, false)
+	}
 	app.flexLayout.AddItem(panels, 0, 8, false)
 
 	// bottom row with F1-F12 buttons
@@ -119,14 +211,19 @@ func (app *Application) Render() error {
 
 func (app *Application) registerGlobalKeymaps() error {
 	app.tviewApp.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if frontPage, _ := app.pages.GetFrontPage(); frontPage != "mainLayout" {
+			// dialogs and full-screen views (such as F3 viewer) handle the keys on their own
+			return event
+		}
+
 		var err error
 		switch event.Key() {
 		case tcell.KeyTab:
 			err = app.ToggleActiveFilePanel()
 		default:
-			// adding F1-F12 key hook to the global keymaps
+			// adding F1-F12 key hook to the global keymaps; the keys it handles are not passed on to the panels
 			fxxEventHandler := app.BottomRow.GraphicElement().GetInputCapture()
-			fxxEventHandler(event)
+			return fxxEventHandler(event)
 		}
 
 		if err != nil {
@@ -137,6 +234,30 @@ func (app *Application) registerGlobalKeymaps() error {
 	return nil
 }
 
+// restoreSession applies the saved state of both panels, and focuses the panel that had the focus
+func (app *Application) restoreSession(state *session.State) error {
+	if err := app.AlphaPanel.RestoreSessionState(state.Alpha); err != nil {
+		return err
+	}
+	if err := app.BetaPanel.RestoreSessionState(state.Beta); err != nil {
+		return err
+	}
+	if state.Active == app.BetaPanel.Name() {
+		return app.ToggleActiveFilePanel()
+	}
+	return nil
+}
+
+// SaveSession writes the state of both panels, and which of them is active, to be restored on the next start
+func (app *Application) SaveSession() error {
+	state := &session.State{
+		Alpha:  app.AlphaPanel.SessionState(),
+		Beta:   app.BetaPanel.SessionState(),
+		Active: app.BottomRow.ActiveFilePanel().Name(),
+	}
+	return state.Save(app.sessionPath)
+}
+
 // ToggleActiveFilePanel switches between the two file panels
 func (app *Application) ToggleActiveFilePanel() (err error) {
 	v := app.tviewApp.GetFocus()
@@ -0,0 +1,168 @@
+package bookmarks
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/mushkevych/9ofm/utils"
+)
+
+// MaxSlot is the highest quick slot; the slots 1-9 are opened with the digits in the bookmarks popup
+const MaxSlot = 9
+
+// ErrNoSuchSlot is returned when no bookmark is assigned the quick slot
+var ErrNoSuchSlot = errors.New("no bookmark in the quick slot")
+
+// Bookmark is a directory in the hotlist
+type Bookmark struct {
+	Label string `json:"label"`
+	Path  string `json:"path"`
+	// name of the group the bookmark is listed under; empty for the ungrouped bookmarks
+	Group string `json:"group,omitempty"`
+	// quick slot 1-9 opening the bookmark; 0 if none
+	Slot int `json:"slot,omitempty"`
+}
+
+// Exists returns true if the bookmark still points at a directory
+func (b Bookmark) Exists() bool {
+	info, err := os.Stat(b.Path)
+	return err == nil && info.IsDir()
+}
+
+// List is the hotlist of the directories, stored in the file as JSON
+type List struct {
+	path      string
+	bookmarks []Bookmark
+}
+
+// content of the file
+type document struct {
+	Bookmarks []Bookmark `json:"bookmarks"`
+}
+
+// Open reads the hotlist from the file, which is created on the first change.
+// Unlike the session, the malformed file is not discarded: it is reported, so that the bookmarks are not lost.
+func Open(path string) (*List, error) {
+	list := &List{path: path}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return list, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var doc document
+	if err = json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("malformed bookmarks %s: %w", path, err)
+	}
+	list.bookmarks = doc.Bookmarks
+	return list, nil
+}
+
+// Bookmarks returns the bookmarks ordered by the group and the label; the ungrouped bookmarks go first
+func (l *List) Bookmarks() []Bookmark {
+	bookmarks := append([]Bookmark(nil), l.bookmarks...)
+	sort.SliceStable(bookmarks, func(i, j int) bool {
+		if bookmarks[i].Group != bookmarks[j].Group {
+			return bookmarks[i].Group < bookmarks[j].Group
+		}
+		return strings.ToLower(bookmarks[i].Label) < strings.ToLower(bookmarks[j].Label)
+	})
+	return bookmarks
+}
+
+// Add saves the bookmark, replacing the bookmark of the same path. The label defaults to the name of the directory,
+// and the quick slot is taken away from the bookmark that had it before.
+func (l *List) Add(bookmark Bookmark) error {
+	if !filepath.IsAbs(bookmark.Path) {
+		return fmt.Errorf("bookmark must be an absolute path: %s", bookmark.Path)
+	}
+	if bookmark.Slot < 0 || bookmark.Slot > MaxSlot {
+		return fmt.Errorf("quick slot must be between 1 and %d: %d", MaxSlot, bookmark.Slot)
+	}
+	bookmark.Path = filepath.Clean(bookmark.Path)
+	bookmark.Label = strings.TrimSpace(bookmark.Label)
+	bookmark.Group = strings.TrimSpace(bookmark.Group)
+	if bookmark.Label == "" {
+		bookmark.Label = filepath.Base(bookmark.Path)
+	}
+
+	bookmarks := make([]Bookmark, 0, len(l.bookmarks)+1)
+	for _, existing := range l.bookmarks {
+		if existing.Path == bookmark.Path {
+			continue
+		}
+		if bookmark.Slot != 0 && existing.Slot == bookmark.Slot {
+			existing.Slot = 0
+		}
+		bookmarks = append(bookmarks, existing)
+	}
+	return l.save(append(bookmarks, bookmark))
+}
+
+// Remove deletes the bookmark of the path; removing the path that is not bookmarked is no error
+func (l *List) Remove(path string) error {
+	bookmarks := make([]Bookmark, 0, len(l.bookmarks))
+	for _, existing := range l.bookmarks {
+		if existing.Path != path {
+			bookmarks = append(bookmarks, existing)
+		}
+	}
+	return l.save(bookmarks)
+}
+
+// BySlot returns the bookmark assigned the quick slot, or ErrNoSuchSlot
+func (l *List) BySlot(slot int) (Bookmark, error) {
+	for _, bookmark := range l.bookmarks {
+		if bookmark.Slot == slot {
+			return bookmark, nil
+		}
+	}
+	return Bookmark{}, fmt.Errorf("%w %d", ErrNoSuchSlot, slot)
+}
+
+// save writes the bookmarks into the file, replacing the previous one at once; the list changes only on success
+func (l *List) save(bookmarks []Bookmark) error {
+	data, err := json.MarshalIndent(document{Bookmarks: bookmarks}, "", "  ")
+	if err != nil {
+		return err
+	}
+	err = utils.WriteFileAtomic(l.path, 0600, func(w io.Writer) error {
+		_, err := w.Write(data)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	l.bookmarks = bookmarks
+	return nil
+}
+
+// Matching returns the bookmarks whose label, group or path contains the query, ignoring the case;
+// an empty query matches all
+func Matching(bookmarks []Bookmark, query string) []Bookmark {
+	query = strings.ToLower(strings.TrimSpace(query))
+	if query == "" {
+		return bookmarks
+	}
+
+	matching := make([]Bookmark, 0, len(bookmarks))
+	for _, bookmark := range bookmarks {
+		for _, text := range []string{bookmark.Label, bookmark.Group, bookmark.Path} {
+			if strings.Contains(strings.ToLower(text), query) {
+				matching = append(matching, bookmark)
+				break
+			}
+		}
+	}
+	return matching
+}
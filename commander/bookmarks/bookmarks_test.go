@@ -0,0 +1,141 @@
+package bookmarks
+
+import (
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestAddAndReopen(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config", "bookmarks.json")
+
+	list, err := Open(path)
+	if err != nil || len(list.Bookmarks()) != 0 {
+		t.Fatalf("expected the empty list before the first change, got %+v, %v", list, err)
+	}
+
+	for _, bookmark := range []Bookmark{
+		{Label: "logs", Path: "/var/log", Group: "system", Slot: 2},
+		{Path: dir + "/", Slot: 1},
+		{Label: "Etc", Path: "/etc", Group: "system"},
+	} {
+		if err = list.Add(bookmark); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	expected := []Bookmark{
+		{Label: filepath.Base(dir), Path: dir, Slot: 1},
+		{Label: "Etc", Path: "/etc", Group: "system"},
+		{Label: "logs", Path: "/var/log", Group: "system", Slot: 2},
+	}
+	if !reflect.DeepEqual(list.Bookmarks(), expected) {
+		t.Errorf("expected %+v, got %+v", expected, list.Bookmarks())
+	}
+
+	reopened, err := Open(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(reopened.Bookmarks(), expected) {
+		t.Errorf("expected %+v after reopening, got %+v", expected, reopened.Bookmarks())
+	}
+}
+
+func TestAddReplacesPathAndSlot(t *testing.T) {
+	list, _ := Open(filepath.Join(t.TempDir(), "bookmarks.json"))
+	_ = list.Add(Bookmark{Label: "logs", Path: "/var/log", Slot: 1})
+	_ = list.Add(Bookmark{Label: "etc", Path: "/etc", Slot: 2})
+	if err := list.Add(Bookmark{Label: "config", Path: "/etc", Group: "system", Slot: 1}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []Bookmark{
+		{Label: "logs", Path: "/var/log"},
+		{Label: "config", Path: "/etc", Group: "system", Slot: 1},
+	}
+	if !reflect.DeepEqual(list.Bookmarks(), expected) {
+		t.Errorf("expected %+v, got %+v", expected, list.Bookmarks())
+	}
+
+	bookmark, err := list.BySlot(1)
+	if err != nil || bookmark.Path != "/etc" {
+		t.Errorf("expected /etc in the slot 1, got %+v, %v", bookmark, err)
+	}
+	if _, err = list.BySlot(2); !errors.Is(err, ErrNoSuchSlot) {
+		t.Errorf("expected ErrNoSuchSlot, got %v", err)
+	}
+}
+
+func TestAddInvalid(t *testing.T) {
+	list, _ := Open(filepath.Join(t.TempDir(), "bookmarks.json"))
+	for _, bookmark := range []Bookmark{
+		{Path: "relative/path"},
+		{Path: "/etc", Slot: MaxSlot + 1},
+		{Path: "/etc", Slot: -1},
+	} {
+		if err := list.Add(bookmark); err == nil {
+			t.Errorf("expected %+v to be rejected", bookmark)
+		}
+	}
+	if len(list.Bookmarks()) != 0 {
+		t.Errorf("expected no bookmarks, got %+v", list.Bookmarks())
+	}
+}
+
+func TestRemove(t *testing.T) {
+	list, _ := Open(filepath.Join(t.TempDir(), "bookmarks.json"))
+	_ = list.Add(Bookmark{Path: "/var/log"})
+	_ = list.Add(Bookmark{Path: "/etc"})
+
+	if err := list.Remove("/var/log"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if bookmarks := list.Bookmarks(); len(bookmarks) != 1 || bookmarks[0].Path != "/etc" {
+		t.Errorf("expected only /etc to stay, got %+v", bookmarks)
+	}
+}
+
+func TestOpenMalformed(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bookmarks.json")
+	if err := ioutil.WriteFile(path, []byte("{not json"), 0600); err != nil {
+		t.Fatalf("could not setup test: %v", err)
+	}
+
+	if _, err := Open(path); err == nil {
+		t.Errorf("expected the malformed bookmarks to be reported")
+	}
+}
+
+func TestExists(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "file")
+	if err := ioutil.WriteFile(file, nil, 0600); err != nil {
+		t.Fatalf("could not setup test: %v", err)
+	}
+
+	if !(Bookmark{Path: dir}).Exists() {
+		t.Errorf("expected %s to exist", dir)
+	}
+	for _, path := range []string{file, filepath.Join(dir, "missing")} {
+		if (Bookmark{Path: path}).Exists() {
+			t.Errorf("expected %s to be reported as missing", path)
+		}
+	}
+}
+
+func TestMatching(t *testing.T) {
+	bookmarks := []Bookmark{
+		{Label: "logs", Path: "/var/log", Group: "system"},
+		{Label: "Project", Path: "/home/user/src"},
+	}
+
+	for query, expected := range map[string]int{"": 2, "SYSTEM": 1, "proj": 1, "/src": 1, "nothing": 0} {
+		if matching := Matching(bookmarks, query); len(matching) != expected {
+			t.Errorf("expected %d bookmarks to match %q, got %+v", expected, query, matching)
+		}
+	}
+}
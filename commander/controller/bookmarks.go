@@ -0,0 +1,322 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/mushkevych/9ofm/commander/bookmarks"
+	"github.com/mushkevych/9ofm/commander/system"
+	log "github.com/sirupsen/logrus"
+	tview "gitlab.com/tslocum/cview"
+)
+
+// errNoBookmarks is reported when the hotlist could not be opened on start
+var errNoBookmarks = errors.New("bookmarks are unavailable, see the log for the reason")
+
+// BookmarksController holds the UI objects for the popup with the hotlist of the directories.
+// Typing narrows the list down to the bookmarks whose label, group or path contains the text.
+type BookmarksController struct {
+	tviewApp       *tview.Application
+	name           string
+	graphicElement GraphicElement
+
+	search *tview.InputField
+	table  *tview.Table
+	list   *bookmarks.List
+
+	openFunc   func(bookmark bookmarks.Bookmark)
+	editFunc   func(bookmark *bookmarks.Bookmark)
+	removeFunc func(bookmark bookmarks.Bookmark)
+	doneFunc   func()
+}
+
+// NewBookmarksController creates a new BookmarksController object attached the the global [tview] screen object.
+func NewBookmarksController(tviewApp *tview.Application, list *bookmarks.List) (controller *BookmarksController) {
+	controller = new(BookmarksController)
+	controller.tviewApp = tviewApp
+	controller.name = "bookmarks"
+	controller.list = list
+
+	controller.table = tview.NewTable()
+	controller.table.SetSelectable(true, false)
+
+	controller.search = tview.NewInputField()
+	controller.search.SetLabel("Search: ")
+	controller.search.SetChangedFunc(func(text string) {
+		if err := controller.Render(); err != nil {
+			log.WithError(err).Error("unable to render the bookmarks")
+		}
+	})
+	controller.search.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		row, _ := controller.table.GetSelection()
+		bookmark, selected := controller.getSelectedBookmark()
+		switch event.Key() {
+		case tcell.KeyEscape:
+			if controller.doneFunc != nil {
+				controller.doneFunc()
+			}
+		case tcell.KeyEnter:
+			if selected && controller.openFunc != nil {
+				controller.openFunc(bookmark)
+			}
+		case tcell.KeyCtrlA:
+			if controller.editFunc != nil {
+				controller.editFunc(nil)
+			}
+		case tcell.KeyCtrlE:
+			if selected && controller.editFunc != nil {
+				controller.editFunc(&bookmark)
+			}
+		case tcell.KeyCtrlD:
+			if selected && controller.removeFunc != nil {
+				controller.removeFunc(bookmark)
+			}
+		case tcell.KeyUp:
+			if row > 1 {
+				controller.table.Select(row-1, 0)
+			}
+		case tcell.KeyDown:
+			if row+1 < controller.table.GetRowCount() {
+				controller.table.Select(row+1, 0)
+			}
+		case tcell.KeyRune:
+			// the digits open the quick slots until the search is typed, and are searched for afterwards
+			if event.Rune() < '1' || event.Rune() > '9' || controller.search.GetText() != "" {
+				return event
+			}
+			controller.openSlot(int(event.Rune() - '0'))
+		default:
+			return event
+		}
+		return nil
+	})
+
+	popup := tview.NewFlex()
+	popup.SetDirection(tview.FlexRow)
+	popup.SetBorder(true)
+	popup.SetTitle("Bookmarks  [Enter: open, 1-9: quick slot, ^A: add, ^E: edit, ^D: remove, Esc: close]")
+	popup.SetBackgroundTransparent(false)
+	popup.AddItem(controller.search, 1, 0, true)
+	popup.AddItem(controller.table, 0, 1, false)
+
+	controller.graphicElement = centered(popup, popupWidth, popupHeight)
+	return controller
+}
+
+// SetOpenFunc sets the handler which is called with the bookmark chosen by the user
+func (c *BookmarksController) SetOpenFunc(handler func(bookmark bookmarks.Bookmark)) {
+	c.openFunc = handler
+}
+
+// SetEditFunc sets the handler which is called to edit the bookmark, or to add a new one if the bookmark is nil
+func (c *BookmarksController) SetEditFunc(handler func(bookmark *bookmarks.Bookmark)) {
+	c.editFunc = handler
+}
+
+// SetRemoveFunc sets the handler which is called with the bookmark to remove
+func (c *BookmarksController) SetRemoveFunc(handler func(bookmark bookmarks.Bookmark)) {
+	c.removeFunc = handler
+}
+
+// SetDoneFunc sets the handler which is called when the user closes the popup
+func (c *BookmarksController) SetDoneFunc(handler func()) {
+	c.doneFunc = handler
+}
+
+// openSlot opens the bookmark assigned the quick slot; the empty slot is reported instead
+func (c *BookmarksController) openSlot(slot int) {
+	bookmark, err := c.list.BySlot(slot)
+	if err != nil {
+		system.MessageBus.Error(err.Error())
+		return
+	}
+	if c.openFunc != nil {
+		c.openFunc(bookmark)
+	}
+}
+
+func (c *BookmarksController) getSelectedBookmark() (bookmarks.Bookmark, bool) {
+	row, _ := c.table.GetSelection()
+	bookmark, ok := c.table.GetCell(row, 0).Reference.(bookmarks.Bookmark)
+	return bookmark, ok
+}
+
+func (c *BookmarksController) Name() string {
+	return c.name
+}
+
+// Render lists the bookmarks matching the search, grouped; the bookmarks of the missing directories are flagged
+func (c *BookmarksController) Render() error {
+	log.Tracef("controller.Render() %s", c.Name())
+
+	c.table.Clear()
+	headerColumns := []string{"Slot", "Group", "Label", "Path", ""}
+	for idx, columnName := range headerColumns {
+		tableCell := tview.NewTableCell(columnName)
+		tableCell.SetTextColor(tcell.ColorYellow)
+		tableCell.SetSelectable(false)
+		c.table.SetCell(0, idx, tableCell)
+	}
+
+	for idx, bookmark := range bookmarks.Matching(c.list.Bookmarks(), c.search.GetText()) {
+		slot, status, color := "", "", tcell.ColorWhite
+		if bookmark.Slot != 0 {
+			slot = strconv.Itoa(bookmark.Slot)
+		}
+		if !bookmark.Exists() {
+			status, color = "missing", tcell.ColorRed
+		}
+
+		for idxCol, text := range []string{slot, bookmark.Group, bookmark.Label, bookmark.Path, status} {
+			tableCell := tview.NewTableCell(text)
+			tableCell.SetTextColor(color)
+			tableCell.SetReference(bookmark)
+			c.table.SetCell(idx+1, idxCol, tableCell)
+		}
+	}
+
+	if row, _ := c.table.GetSelection(); row < 1 || row >= c.table.GetRowCount() {
+		c.table.SetOffset(0, 0)
+		c.table.Select(1, 0)
+	}
+	return nil
+}
+
+// IsVisible indicates if the bookmarks popup is currently initialized
+func (c *BookmarksController) IsVisible() bool {
+	return c != nil
+}
+
+// SetVisible is not used for the bookmarks popup: it is shown and hidden as a page
+func (c *BookmarksController) SetVisible(visible bool) error {
+	return nil
+}
+
+// GraphicElement returns UI graphicElement used by tview framework to render the UI interface
+func (c *BookmarksController) GraphicElement() GraphicElement {
+	return c.graphicElement
+}
+
+// ShowBookmarks opens the hotlist, where the bookmarks are opened, added, edited and removed
+func (c *FxxController) ShowBookmarks() error {
+	if c.sourceFilePanel == nil {
+		return nil
+	}
+	if c.bookmarks == nil {
+		system.MessageBus.Error(errNoBookmarks.Error())
+		return nil
+	}
+
+	formId := "formBookmarks"
+	bookmarksController := NewBookmarksController(c.tviewApp, c.bookmarks)
+	bookmarksController.SetDoneFunc(func() {
+		c.hideModalForm(formId)
+	})
+	bookmarksController.SetOpenFunc(func(bookmark bookmarks.Bookmark) {
+		c.hideModalForm(formId)
+		c.openBookmark(bookmark)
+	})
+	bookmarksController.SetEditFunc(func(bookmark *bookmarks.Bookmark) {
+		if bookmark == nil {
+			bookmark = &bookmarks.Bookmark{Path: c.sourceFilePanel.GetPwd()}
+		}
+		c.editBookmark(*bookmark, bookmarksController)
+	})
+	bookmarksController.SetRemoveFunc(func(bookmark bookmarks.Bookmark) {
+		if err := c.bookmarks.Remove(bookmark.Path); err != nil {
+			system.MessageBus.Error(err.Error())
+		}
+		if err := bookmarksController.Render(); err != nil {
+			system.MessageBus.Error(err.Error())
+		}
+	})
+
+	c.showFullScreenForm(formId, bookmarksController.GraphicElement())
+	return bookmarksController.Render()
+}
+
+// AddBookmark asks for the label, the group and the quick slot, and bookmarks the directory of the active panel
+func (c *FxxController) AddBookmark() error {
+	if c.sourceFilePanel == nil {
+		return nil
+	}
+	if c.bookmarks == nil {
+		system.MessageBus.Error(errNoBookmarks.Error())
+		return nil
+	}
+
+	c.editBookmark(bookmarks.Bookmark{Path: c.sourceFilePanel.GetPwd()}, nil)
+	return nil
+}
+
+// openBookmark enters the bookmarked directory in the active panel; the missing directory is reported instead
+func (c *FxxController) openBookmark(bookmark bookmarks.Bookmark) {
+	if !bookmark.Exists() {
+		system.MessageBus.Error(fmt.Sprintf("bookmark %s points at the missing directory %s", bookmark.Label, bookmark.Path))
+		return
+	}
+	if err := c.sourceFilePanel.ChangeDir(bookmark.Path); err != nil {
+		system.MessageBus.Error(err.Error())
+	}
+}
+
+// editBookmark asks for the label, the group and the quick slot of the bookmark, and saves it.
+// The parent is the hotlist the dialog is shown over, or nil if it is shown over the panels.
+func (c *FxxController) editBookmark(bookmark bookmarks.Bookmark, parent *BookmarksController) {
+	formId := "formBookmarkEdit"
+	labelLabel := "Label:"
+	groupLabel := "Group:"
+	slotLabel := fmt.Sprintf("Quick slot (1-%d):", bookmarks.MaxSlot)
+	slot := ""
+	if bookmark.Slot != 0 {
+		slot = strconv.Itoa(bookmark.Slot)
+	}
+
+	hide := func() {
+		if parent == nil {
+			c.hideModalForm(formId)
+		} else {
+			c.hideDialog(formId, parent)
+		}
+	}
+
+	modalForm := tview.NewModal()
+	modalForm.SetBorder(true)
+	modalForm.SetTitle("Bookmark")
+	modalForm.SetTitleAlign(tview.AlignCenter)
+	modalForm.SetText(bookmark.Path)
+	form := modalForm.GetForm()
+	form.AddInputField(labelLabel, bookmark.Label, 30, nil, nil)
+	form.AddInputField(groupLabel, bookmark.Group, 30, nil, nil)
+	form.AddInputField(slotLabel, slot, 2, tview.InputFieldInteger, nil)
+	modalForm.AddButtons([]string{"OK", "Cancel"})
+	modalForm.SetDoneFunc(func(buttonIndex int, buttonLabel string) {
+		if buttonLabel != "OK" {
+			hide()
+			return
+		}
+
+		bookmark.Label = form.GetFormItemByLabel(labelLabel).(*tview.InputField).GetText()
+		bookmark.Group = form.GetFormItemByLabel(groupLabel).(*tview.InputField).GetText()
+		bookmark.Slot = 0
+		if text := form.GetFormItemByLabel(slotLabel).(*tview.InputField).GetText(); text != "" {
+			bookmark.Slot, _ = strconv.Atoi(text)
+		}
+		if err := c.bookmarks.Add(bookmark); err != nil {
+			// keep the dialog open to correct the input
+			system.MessageBus.Error(err.Error())
+			return
+		}
+
+		hide()
+		if parent != nil {
+			if err := parent.Render(); err != nil {
+				system.MessageBus.Error(err.Error())
+			}
+		}
+	})
+
+	c.showModalForm(formId, modalForm)
+}
@@ -0,0 +1,382 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/mushkevych/9ofm/commander/jobs"
+	"github.com/mushkevych/9ofm/commander/model"
+	"github.com/mushkevych/9ofm/commander/report"
+	"github.com/mushkevych/9ofm/commander/system"
+	"github.com/mushkevych/9ofm/commander/view"
+	log "github.com/sirupsen/logrus"
+	tview "gitlab.com/tslocum/cview"
+)
+
+// ComparePanels compares the directories of both panels, one level deep, and marks the files that differ.
+// See comparePanels for details.
+func (c *FxxController) ComparePanels() error {
+	criteria, err := CompareCriteria()
+	if err != nil {
+		system.MessageBus.Error(err.Error())
+		return err
+	}
+	return c.comparePanels(model.ReadOptions{Depth: 1}, criteria)
+}
+
+// DeepComparePanels compares the whole trees of the directories of both panels, down to the "compare.depth" levels
+// and leaving out the names matching "compare.exclude" patterns. Directories are marked as Modified when anything
+// below them differs; entering them keeps the result of the comparison. See comparePanels for details.
+func (c *FxxController) DeepComparePanels() error {
+	criteria, err := CompareCriteria()
+	if err != nil {
+		system.MessageBus.Error(err.Error())
+		return err
+	}
+	return c.comparePanels(DeepCompareOptions(), criteria)
+}
+
+// DeepCompareOptions returns the options of reading the whole trees, according to "compare.depth" and "compare.exclude"
+func DeepCompareOptions() model.ReadOptions {
+	var excludes []string
+	for _, pattern := range system.Config.GetStringSlice("compare.exclude", ",") {
+		if pattern = strings.TrimSpace(pattern); pattern != "" {
+			excludes = append(excludes, pattern)
+		}
+	}
+	return model.ReadOptions{Depth: system.Config.GetInt("compare.depth"), Excludes: excludes}
+}
+
+// CompareCriteria returns the properties of the files compared by the panels, according to "compare.criteria"
+// and "compare.mtime.tolerance"; the empty "compare.criteria" stands for the model.DefaultCriteria
+func CompareCriteria() (model.Criteria, error) {
+	tolerance, err := time.ParseDuration(system.Config.GetString("compare.mtime.tolerance"))
+	if err != nil {
+		return model.Criteria{}, fmt.Errorf("invalid compare.mtime.tolerance: %w", err)
+	}
+	if strings.TrimSpace(system.Config.GetString("compare.criteria")) == "" {
+		criteria := model.DefaultCriteria()
+		criteria.ModTimeTolerance = tolerance
+		return criteria, nil
+	}
+	return model.ParseCriteria(system.Config.GetStringSlice("compare.criteria", ","), tolerance)
+}
+
+// compareCriteriaLabels are the check boxes of the criteria dialog, in the order of the model.Criteria names
+var compareCriteriaLabels = []struct{ label, name string }{
+	{"Size", "size"},
+	{"Modification time", "mtime"},
+	{"Permissions", "perms"},
+	{"Ownership", "owner"},
+	{"Symlink target", "link"},
+	{"Content hash", "hash"},
+}
+
+// ComparePanelsWithCriteria asks for the criteria of the comparison, preset from the configuration,
+// and compares the panels with them; the configuration is left unchanged. See comparePanels for details.
+func (c *FxxController) ComparePanelsWithCriteria() error {
+	if c.sourceFilePanel == nil || c.targetFilePanel == nil {
+		return nil
+	}
+	defaults, err := CompareCriteria()
+	if err != nil {
+		// the dialog lets the user choose valid criteria instead
+		system.MessageBus.Error(err.Error())
+		defaults = model.DefaultCriteria()
+	}
+	selected := make(map[string]bool)
+	for _, name := range defaults.Names() {
+		selected[name] = true
+	}
+
+	formId := "formCompareCriteria"
+	toleranceLabel, deepLabel := "Time tolerance:", "Deep comparison"
+	modalForm := tview.NewModal()
+	modalForm.SetBorder(true)
+	modalForm.SetTitle("Compare by")
+	modalForm.SetTitleAlign(tview.AlignCenter)
+	form := modalForm.GetForm()
+	for _, criterion := range compareCriteriaLabels {
+		form.AddCheckBox(criterion.label, "", selected[criterion.name], nil)
+	}
+	form.AddInputField(toleranceLabel, defaults.ModTimeTolerance.String(), 10, nil, nil)
+	form.AddCheckBox(deepLabel, "", false, nil)
+	modalForm.AddButtons([]string{"Compare", "Cancel"})
+	modalForm.SetDoneFunc(func(buttonIndex int, buttonLabel string) {
+		c.hideModalForm(formId)
+		if buttonLabel != "Compare" {
+			return
+		}
+
+		tolerance, err := time.ParseDuration(form.GetFormItemByLabel(toleranceLabel).(*tview.InputField).GetText())
+		if err != nil {
+			system.MessageBus.Error(fmt.Sprintf("invalid time tolerance: %s", err))
+			return
+		}
+		var names []string
+		for _, criterion := range compareCriteriaLabels {
+			if form.GetFormItemByLabel(criterion.label).(*tview.CheckBox).IsChecked() {
+				names = append(names, criterion.name)
+			}
+		}
+		criteria, err := model.ParseCriteria(names, tolerance)
+		if err != nil {
+			system.MessageBus.Error(err.Error())
+			return
+		}
+
+		options := model.ReadOptions{Depth: 1}
+		if form.GetFormItemByLabel(deepLabel).(*tview.CheckBox).IsChecked() {
+			options = DeepCompareOptions()
+		}
+		_ = c.comparePanels(options, criteria)
+	})
+
+	c.showModalForm(formId, modalForm)
+	return nil
+}
+
+// comparePanels re-reads the directories of both panels in the background and compares them by the criteria.
+// Shows the number of files of every DiffType; DiffTypes listed in the "diff.hide" setting are hidden from the panels.
+func (c *FxxController) comparePanels(options model.ReadOptions, criteria model.Criteria) error {
+	if c.sourceFilePanel == nil || c.targetFilePanel == nil {
+		return nil
+	}
+
+	left, right := c.sourceFilePanel, c.targetFilePanel
+	leftPwd, rightPwd := left.GetPwd(), right.GetPwd()
+
+	var leftTree, rightTree *model.FileTreeModel
+	title := fmt.Sprintf("Compare %s with %s", leftPwd, rightPwd)
+	c.jobManager.Submit(title, func(job *jobs.Job) (err error) {
+		leftTree, rightTree, err = c.readTrees(job, leftPwd, rightPwd, options, criteria.Content)
+		return err
+	}, func(job *jobs.Job) {
+		c.tviewApp.QueueUpdateDraw(func() {
+			if errors.Is(job.Err(), jobs.ErrCancelled) {
+				return
+			}
+			if err := job.Err(); err != nil {
+				// files that could not be hashed are compared as modified
+				system.MessageBus.Error(err.Error())
+			}
+			if leftTree == nil || rightTree == nil {
+				return
+			}
+			if left.GetPwd() != leftPwd || right.GetPwd() != rightPwd {
+				system.MessageBus.Error("the panels have changed during the comparison")
+				return
+			}
+			_ = c.showComparison(left, leftTree, right, rightTree, options.Depth, criteria)
+		})
+	})
+	return nil
+}
+
+// readTrees reads both trees with the options within the background job.
+// With hashing enabled, the content of the files of equal size is hashed as well.
+// The trees are returned even if some of the files could not be hashed: such files are compared as modified.
+func (c *FxxController) readTrees(job *jobs.Job, leftPwd, rightPwd string, options model.ReadOptions, hashing bool) (
+	leftTree, rightTree *model.FileTreeModel, err error) {
+	if leftTree, err = model.ReadFileTreeWithOptions(leftPwd, options, job); err != nil {
+		return nil, nil, err
+	}
+	if rightTree, err = model.ReadFileTreeWithOptions(rightPwd, options, job); err != nil {
+		return nil, nil, err
+	}
+	if !hashing {
+		return leftTree, rightTree, nil
+	}
+
+	candidates := model.HashCandidates(leftTree, rightTree)
+	var bytes int64
+	for _, fileNode := range candidates {
+		bytes += fileNode.Data.FileInfo.Size
+	}
+	job.Progress.SetTotals(int64(len(candidates)), bytes)
+	err = model.HashFiles(candidates, system.Config.GetInt("compare.workers"), job)
+	c.saveHashCache()
+	return leftTree, rightTree, err
+}
+
+// saveHashCache writes the hashes computed so far to the disk; failure to do so does not fail the comparison
+func (c *FxxController) saveHashCache() {
+	if c.hashCache == nil {
+		return
+	}
+	if err := c.hashCache.Save(); err != nil {
+		log.WithError(err).Error("unable to save the hash cache")
+	}
+}
+
+// InvalidateHashCache asks for confirmation and forgets the hashes of all files,
+// so that the next comparison reads the content of the files again
+func (c *FxxController) InvalidateHashCache() error {
+	if c.hashCache == nil {
+		system.MessageBus.Error("hash cache is disabled")
+		return nil
+	}
+
+	formId := "formInvalidateHashCache"
+	modalWindow := tview.NewModal()
+	modalWindow.SetBorder(true)
+	modalWindow.SetTitle("Hash cache")
+	modalWindow.SetTitleAlign(tview.AlignCenter)
+	modalWindow.SetText(fmt.Sprintf("Forget the hashes of %d files?", c.hashCache.Len()))
+	modalWindow.AddButtons([]string{"OK", "Cancel"})
+	modalWindow.SetDoneFunc(func(buttonIndex int, buttonLabel string) {
+		c.hideModalForm(formId)
+		if buttonLabel != "OK" {
+			return
+		}
+		if err := c.hashCache.Invalidate(); err != nil {
+			system.MessageBus.Error(err.Error())
+		}
+	})
+
+	c.showModalForm(formId, modalWindow)
+	return nil
+}
+
+// showComparison marks the files of both trees by comparing them by the criteria, displays the trees in the panels,
+// and shows the summary. Depth is the number of directory levels read into the trees.
+func (c *FxxController) showComparison(left *FilePanelController, leftTree *model.FileTreeModel,
+	right *FilePanelController, rightTree *model.FileTreeModel, depth int, criteria model.Criteria) error {
+	err := model.CompareAndMarkWith(leftTree, rightTree, criteria)
+	if err != nil {
+		system.MessageBus.Error(err.Error())
+		return err
+	}
+
+	for _, panel := range []struct {
+		fpc  *FilePanelController
+		tree *model.FileTreeModel
+	}{{left, leftTree}, {right, rightTree}} {
+		ftv, err := view.NewFileTreeView(panel.tree)
+		if err != nil {
+			system.MessageBus.Error(err.Error())
+			return err
+		}
+		ftv.CopyMarks(panel.fpc.ftv)
+		ftv.CopyHiddenDiffTypes(panel.fpc.ftv)
+		ftv.SetCompared(depth)
+		panel.fpc.ftv = ftv
+		if err = panel.fpc.Render(); err != nil {
+			system.MessageBus.Error(err.Error())
+		}
+	}
+
+	formId := "formCompare"
+	modalWindow := tview.NewModal()
+	modalWindow.SetBorder(true)
+	modalWindow.SetTitle("Compare")
+	modalWindow.SetTitleAlign(tview.AlignCenter)
+	modalWindow.SetText(describeComparison(left.ftv, right.ftv, criteria))
+	modalWindow.AddButtons([]string{"OK"})
+	modalWindow.SetDoneFunc(func(buttonIndex int, buttonLabel string) {
+		c.hideModalForm(formId)
+	})
+
+	c.showModalForm(formId, modalWindow)
+	return nil
+}
+
+// ExportComparison saves the report of the comparison of both panels into the file, in the chosen format.
+// The report covers the directories compared by ComparePanels or DeepComparePanels, whichever ran last.
+func (c *FxxController) ExportComparison() error {
+	if c.sourceFilePanel == nil || c.targetFilePanel == nil {
+		return nil
+	}
+	left, right := c.sourceFilePanel.ftv, c.targetFilePanel.ftv
+	if !left.Compared || !right.Compared {
+		system.MessageBus.Error("compare the panels first")
+		return nil
+	}
+
+	formats := []string{string(report.JSON), string(report.CSV), string(report.Text)}
+	defaultPath := "compare-report." + formats[0]
+	if home, err := os.UserHomeDir(); err == nil {
+		defaultPath = filepath.Join(home, defaultPath)
+	}
+
+	formId := "formExportComparison"
+	formatLabel, pathLabel := "Format:", "Save as:"
+	modalForm := tview.NewModal()
+	modalForm.SetBorder(true)
+	modalForm.SetTitle("Export comparison")
+	modalForm.SetTitleAlign(tview.AlignCenter)
+	modalForm.GetForm().AddInputField(pathLabel, defaultPath, 40, nil, nil)
+	modalForm.GetForm().AddDropDownSimple(formatLabel, 0, func(index int, option *tview.DropDownOption) {
+		// the extension of the file follows the format
+		pathField := modalForm.GetForm().GetFormItemByLabel(pathLabel).(*tview.InputField)
+		fqfp := pathField.GetText()
+		for _, format := range formats {
+			if strings.HasSuffix(fqfp, "."+format) {
+				pathField.SetText(strings.TrimSuffix(fqfp, format) + formats[index])
+				break
+			}
+		}
+	}, formats...)
+	modalForm.AddButtons([]string{"OK", "Cancel"})
+	modalForm.SetDoneFunc(func(buttonIndex int, buttonLabel string) {
+		c.hideModalForm(formId)
+		if buttonLabel != "OK" {
+			return
+		}
+
+		index, _ := modalForm.GetForm().GetFormItemByLabel(formatLabel).(*tview.DropDown).GetCurrentOption()
+		fqfp := modalForm.GetForm().GetFormItemByLabel(pathLabel).(*tview.InputField).GetText()
+		if err := exportComparison(left, right, report.Format(formats[index]), fqfp); err != nil {
+			system.MessageBus.Error(err.Error())
+		}
+	})
+
+	c.showModalForm(formId, modalForm)
+	return nil
+}
+
+// exportComparison writes the report of the comparison of the views into the file
+func exportComparison(left, right *view.FileTreeView, format report.Format, fqfp string) error {
+	comparison, err := report.BuildFrom(left.ModelTree, left.CompareRoot(), right.ModelTree, right.CompareRoot())
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Create(fqfp)
+	if err != nil {
+		return err
+	}
+	if err = comparison.Write(file, format); err != nil {
+		_ = file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// describeComparison lists the number of files of every DiffType found in both compared views,
+// the criteria of the comparison, and the hidden DiffTypes
+func describeComparison(left, right *view.FileTreeView, criteria model.Criteria) string {
+	leftCounts := left.ModelTree.DiffTypeCounts()
+	rightCounts := right.ModelTree.DiffTypeCounts()
+
+	lines := []string{fmt.Sprintf("%s : %s", left.ModelTree.GetPwd(), right.ModelTree.GetPwd())}
+	var hidden []string
+	for _, diffType := range []model.DiffType{model.Added, model.Removed, model.Modified, model.Unmodified} {
+		if left.HiddenDiffTypes[diffType] || right.HiddenDiffTypes[diffType] {
+			hidden = append(hidden, diffType.String())
+		}
+		if leftCounts[diffType] == 0 && rightCounts[diffType] == 0 {
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("%s: %d : %d", diffType, leftCounts[diffType], rightCounts[diffType]))
+	}
+	lines = append(lines, "Criteria: "+criteria.String())
+	if len(hidden) > 0 {
+		lines = append(lines, "Hidden: "+strings.Join(hidden, ", "))
+	}
+	return strings.Join(lines, "\n")
+}
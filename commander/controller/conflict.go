@@ -0,0 +1,109 @@
+package controller
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mushkevych/9ofm/commander/fileops"
+	"github.com/mushkevych/9ofm/commander/model"
+	"github.com/mushkevych/9ofm/commander/system"
+	"github.com/mushkevych/9ofm/utils"
+	log "github.com/sirupsen/logrus"
+	tview "gitlab.com/tslocum/cview"
+)
+
+const applyToAllLabel = "Apply to all"
+
+// button label -> policy offered by the conflict dialog
+var conflictButtons = []struct {
+	label  string
+	policy fileops.ConflictPolicy
+}{
+	{"Overwrite", fileops.Overwrite},
+	{"Skip", fileops.Skip},
+	{"Rename", fileops.Rename},
+	{"If newer", fileops.OverwriteIfNewer},
+	{"If size differs", fileops.OverwriteIfSizeDiffers},
+	{"Abort", fileops.Abort},
+}
+
+// conflictResolver returns the resolver defined by the "conflict.policy" setting:
+// either a fixed policy, or the dialog asking the user about every conflict
+func (c *FxxController) conflictResolver() fileops.ConflictResolver {
+	name := system.Config.GetString("conflict.policy")
+	if strings.ToLower(name) != "ask" {
+		policy, err := fileops.ParseConflictPolicy(name)
+		if err == nil {
+			return fileops.FixedPolicy(policy)
+		}
+		log.WithError(err).Error("falling back to the conflict dialog")
+	}
+	return fileops.NewPromptResolver(c.promptConflict)
+}
+
+// promptConflict shows the conflict dialog and blocks until the user answers.
+// It must be called outside of the UI goroutine, since the dialog is displayed by the UI goroutine.
+func (c *FxxController) promptConflict(conflict fileops.Conflict) (fileops.ConflictPolicy, bool) {
+	type answer struct {
+		policy     fileops.ConflictPolicy
+		applyToAll bool
+	}
+	answers := make(chan answer, 1)
+
+	c.tviewApp.QueueUpdateDraw(func() {
+		formId := "formConflict"
+		modalForm := tview.NewModal()
+		modalForm.SetBorder(true)
+		modalForm.SetTitle("Target already exists")
+		modalForm.SetTitleAlign(tview.AlignCenter)
+		modalForm.SetText(describeConflict(conflict))
+		modalForm.GetForm().AddCheckBox(applyToAllLabel, "", false, nil)
+
+		labels := make([]string, 0, len(conflictButtons))
+		for _, button := range conflictButtons {
+			labels = append(labels, button.label)
+		}
+		modalForm.AddButtons(labels)
+		modalForm.SetDoneFunc(func(buttonIndex int, buttonLabel string) {
+			// Esc is reported as buttonIndex -1, and aborts the operation
+			result := answer{policy: fileops.Abort}
+			for _, button := range conflictButtons {
+				if button.label == buttonLabel {
+					result.policy = button.policy
+				}
+			}
+			result.applyToAll = modalForm.GetForm().GetFormItemByLabel(applyToAllLabel).(*tview.CheckBox).IsChecked()
+
+			c.hideModalForm(formId)
+			answers <- result
+		})
+
+		c.showModalForm(formId, modalForm)
+	})
+
+	result := <-answers
+	return result.policy, result.applyToAll
+}
+
+// describeConflict presents the source and the target side by side
+func describeConflict(conflict fileops.Conflict) string {
+	source := model.NewFileInfo(conflict.Source, conflict.SourceInfo, nil)
+	target := model.NewFileInfo(conflict.Destination, conflict.DestinationInfo, nil)
+
+	describe := func(title string, info model.FileInfo) string {
+		return fmt.Sprintf("%s: %d bytes  %s  %s:%s  %s",
+			title,
+			info.Size,
+			utils.FileMode(info.Mode).String(),
+			info.Uid,
+			info.Gid,
+			info.ModTime.Format("2006-01-02 15:04:05"),
+		)
+	}
+
+	return fmt.Sprintf("%s\n\n%s\n%s",
+		conflict.Destination,
+		describe("Source", source),
+		describe("Target", target),
+	)
+}
@@ -0,0 +1,319 @@
+package controller
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/mushkevych/9ofm/commander/model"
+	"github.com/mushkevych/9ofm/commander/system"
+	"github.com/mushkevych/9ofm/commander/view"
+	log "github.com/sirupsen/logrus"
+	tview "gitlab.com/tslocum/cview"
+)
+
+// number of screen cells used by the line numbers in front of every line of the diff
+const diffGutterWidth = 6
+
+// DiffController holds the UI objects and data models for the full-screen side-by-side diff of two text files
+type DiffController struct {
+	tviewApp       *tview.Application
+	pages          *tview.Pages
+	name           string
+	graphicElement GraphicElement
+
+	dv      *view.DiffView
+	message string // outcome of the last action, shown in the status line
+
+	// number of rows presented as of the last rendering; used by the scrolling functions
+	height int
+
+	doneFunc func()
+}
+
+// NewDiffController creates a new DiffController object comparing the files at the given fully qualified file paths.
+func NewDiffController(tviewApp *tview.Application, pages *tview.Pages, leftPath, rightPath string) (controller *DiffController, err error) {
+	controller = new(DiffController)
+	controller.tviewApp = tviewApp
+	controller.pages = pages
+	controller.name = "diff"
+
+	controller.dv, err = view.NewDiffView(leftPath, rightPath)
+	if err != nil {
+		return nil, err
+	}
+
+	box := tview.NewBox()
+	box.SetBorder(true)
+	box.SetTitle(fmt.Sprintf("%s : %s", leftPath, rightPath))
+	box.SetTitleAlign(tview.AlignLeft)
+	box.SetDrawFunc(controller.draw)
+
+	box.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		var err error
+		controller.message = ""
+		switch event.Key() {
+		case tcell.KeyEscape, tcell.KeyF10:
+			controller.close()
+			return nil
+		case tcell.KeyUp:
+			controller.dv.ScrollUp(1, controller.height)
+		case tcell.KeyDown, tcell.KeyEnter:
+			controller.dv.ScrollDown(1, controller.height)
+		case tcell.KeyPgUp:
+			controller.dv.ScrollUp(controller.height, controller.height)
+		case tcell.KeyPgDn:
+			controller.dv.ScrollDown(controller.height, controller.height)
+		case tcell.KeyHome:
+			controller.dv.Top = 0
+		case tcell.KeyEnd:
+			controller.dv.ScrollToEnd(controller.height)
+		case tcell.KeyF2:
+			err = controller.save()
+		case tcell.KeyRune:
+			switch event.Rune() {
+			case 'q':
+				controller.close()
+				return nil
+			case 'n', ']':
+				if !controller.dv.NextHunk(controller.height) {
+					controller.message = "no more differences"
+				}
+			case 'p', 'N', '[':
+				if !controller.dv.PreviousHunk(controller.height) {
+					controller.message = "no previous differences"
+				}
+			case '>':
+				err = controller.dv.CopyHunk(true, controller.height)
+			case '<':
+				err = controller.dv.CopyHunk(false, controller.height)
+			case 's':
+				err = controller.save()
+			}
+		}
+
+		if err != nil {
+			controller.message = err.Error()
+		}
+		return nil
+	})
+
+	controller.graphicElement = box
+	return controller, nil
+}
+
+func (c *DiffController) Name() string {
+	return c.name
+}
+
+// SetDoneFunc sets the handler which is called when the user closes the diff
+func (c *DiffController) SetDoneFunc(handler func()) {
+	c.doneFunc = handler
+}
+
+// save writes the modified files and reports the outcome on the status line
+func (c *DiffController) save() error {
+	if !c.dv.IsModified() {
+		return nil
+	}
+	if err := c.dv.Save(); err != nil {
+		return err
+	}
+	c.message = "saved"
+	return nil
+}
+
+// close notifies the done handler; unsaved changes are confirmed first
+func (c *DiffController) close() {
+	if !c.dv.IsModified() {
+		if c.doneFunc != nil {
+			c.doneFunc()
+		}
+		return
+	}
+
+	formId := "formDiffClose"
+	modalWindow := tview.NewModal()
+	modalWindow.SetBorder(true)
+	modalWindow.SetTitle("Diff")
+	modalWindow.SetTitleAlign(tview.AlignCenter)
+	modalWindow.SetText("Save the changes?")
+	modalWindow.AddButtons([]string{"Save", "Discard", "Cancel"})
+	modalWindow.SetDoneFunc(func(buttonIndex int, buttonLabel string) {
+		c.pages.HidePage(formId)
+		c.pages.RemovePage(formId)
+		c.tviewApp.SetFocus(c.graphicElement)
+
+		switch buttonLabel {
+		case "Save":
+			if err := c.dv.Save(); err != nil {
+				c.message = err.Error()
+				return
+			}
+		case "Discard":
+		default:
+			return
+		}
+		if c.doneFunc != nil {
+			c.doneFunc()
+		}
+	})
+
+	c.pages.AddPage(formId, modalWindow, false, true)
+}
+
+// draw renders visible rows of both files next to each other and the status line into the box
+func (c *DiffController) draw(screen tcell.Screen, x, y, width, height int) (int, int, int, int) {
+	// account for the border
+	x, y, width, height = x+1, y+1, width-2, height-2
+	if width <= 2 || height <= 1 {
+		return x, y, width, height
+	}
+
+	// the bottom row is reserved for the status line
+	c.height = height - 1
+	columnWidth := (width - 1) / 2
+
+	separatorStyle := tcell.StyleDefault.Foreground(tcell.ColorTeal)
+	var current view.Hunk
+	if c.dv.Hunk >= 0 {
+		current = c.dv.Hunks[c.dv.Hunk]
+	}
+	for rowIdx := 0; rowIdx < c.height && c.dv.Top+rowIdx < len(c.dv.Rows); rowIdx++ {
+		rowNumber := c.dv.Top + rowIdx
+		row := c.dv.Rows[rowNumber]
+		isCurrent := c.dv.Hunk >= 0 && rowNumber >= current.FirstRow && rowNumber < current.EndRow
+
+		c.drawLine(screen, x, y+rowIdx, columnWidth, c.dv.Left, row.Left, row.Type, isCurrent)
+		screen.SetContent(x+columnWidth, y+rowIdx, tview.Borders.Vertical, nil, separatorStyle)
+		c.drawLine(screen, x+columnWidth+1, y+rowIdx, width-columnWidth-1, c.dv.Right, row.Right, row.Type, isCurrent)
+	}
+
+	c.drawStatusLine(screen, x, y+height-1, width)
+	return x, y, width, height
+}
+
+// drawLine renders the line number and the text of the line at the index; -1 leaves the row empty
+func (c *DiffController) drawLine(screen tcell.Screen, x, y, width int, side *view.DiffSide, idx int,
+	rowType view.DiffRowType, isCurrent bool) {
+	if idx < 0 {
+		return
+	}
+
+	gutterStyle := tcell.StyleDefault.Foreground(tcell.ColorGray)
+	if isCurrent {
+		gutterStyle = tcell.StyleDefault.Foreground(tcell.ColorBlack).Background(tcell.ColorTeal)
+	}
+	style := tcell.StyleDefault.Foreground(tcell.ColorWhite)
+	switch rowType {
+	case view.DiffDeleted:
+		style = tcell.StyleDefault.Foreground(tcell.ColorRed)
+	case view.DiffInserted:
+		style = tcell.StyleDefault.Foreground(tcell.ColorGreen)
+	case view.DiffChanged:
+		style = tcell.StyleDefault.Foreground(tcell.ColorYellow)
+	}
+
+	gutter := fmt.Sprintf("%*d ", diffGutterWidth-1, idx+1)
+	col := 0
+	for _, r := range gutter {
+		if col >= width {
+			return
+		}
+		screen.SetContent(x+col, y, r, nil, gutterStyle)
+		col++
+	}
+	for _, r := range side.Line(idx) {
+		if col >= width {
+			break
+		}
+		if !unicode.IsPrint(r) {
+			r = '.'
+		}
+		screen.SetContent(x+col, y, r, nil, style)
+		col++
+	}
+}
+
+// drawStatusLine renders the position among the differences and the modified sides
+func (c *DiffController) drawStatusLine(screen tcell.Screen, x, y, width int) {
+	position := "equal"
+	if c.dv.Hunk >= 0 {
+		position = fmt.Sprintf("difference %d/%d", c.dv.Hunk+1, len(c.dv.Hunks))
+	}
+
+	var modified []string
+	if c.dv.Left.Modified {
+		modified = append(modified, "left")
+	}
+	if c.dv.Right.Modified {
+		modified = append(modified, "right")
+	}
+	if len(modified) > 0 {
+		position += "  [modified: " + strings.Join(modified, ", ") + "]"
+	}
+
+	hint := "n/p:next/prev >/<:copy to right/left s:save Esc:close"
+	if c.message != "" {
+		hint = c.message
+	}
+	status := fmt.Sprintf(" %s  %s", position, hint)
+	style := tcell.StyleDefault.Foreground(tcell.ColorBlack).Background(tcell.ColorTeal)
+	for col := 0; col < width; col++ {
+		screen.SetContent(x+col, y, ' ', nil, style)
+	}
+	tview.PrintStyle(screen, []byte(tview.Escape(status)), x, y, width, tview.AlignLeft, style)
+}
+
+// Render flushes the state objects to the screen.
+func (c *DiffController) Render() error {
+	log.Tracef("controller.Render() %s", c.Name())
+	return nil
+}
+
+// IsVisible indicates if the diff is currently initialized
+func (c *DiffController) IsVisible() bool {
+	return c != nil
+}
+
+// SetVisible is not used for the diff: it is shown and hidden as a page
+func (c *DiffController) SetVisible(visible bool) error {
+	return nil
+}
+
+// GraphicElement returns UI graphicElement used by tview framework to render the UI interface
+func (c *DiffController) GraphicElement() GraphicElement {
+	return c.graphicElement
+}
+
+// DiffFiles opens the side-by-side diff of the files selected in both panels. The files must have the same name,
+// and must have been marked as Modified by the comparison of the panels.
+func (c *FxxController) DiffFiles() error {
+	if c.sourceFilePanel == nil || c.targetFilePanel == nil {
+		return nil
+	}
+
+	left, right := c.sourceFilePanel.GetSelectedFileNode(), c.targetFilePanel.GetSelectedFileNode()
+	if left == nil || right == nil || left.IsDir() || right.IsDir() || left.Name != right.Name {
+		system.MessageBus.Error("select the files of the same name in both panels")
+		return nil
+	}
+	if left.Data.DiffType != model.Modified || right.Data.DiffType != model.Modified {
+		system.MessageBus.Error(fmt.Sprintf("%s is not marked as modified by the comparison of the panels", left.Name))
+		return nil
+	}
+
+	formId := "formDiff"
+	diffController, err := NewDiffController(c.tviewApp, c.pages, left.AbsPath(), right.AbsPath())
+	if err != nil {
+		system.MessageBus.Error(err.Error())
+		return err
+	}
+	diffController.SetDoneFunc(func() {
+		c.hideModalForm(formId)
+	})
+
+	c.showFullScreenForm(formId, diffController.GraphicElement())
+	return nil
+}
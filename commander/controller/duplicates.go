@@ -0,0 +1,382 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/mushkevych/9ofm/commander/dedup"
+	"github.com/mushkevych/9ofm/commander/jobs"
+	"github.com/mushkevych/9ofm/commander/journal"
+	"github.com/mushkevych/9ofm/commander/model"
+	"github.com/mushkevych/9ofm/commander/system"
+	"github.com/mushkevych/9ofm/utils"
+	log "github.com/sirupsen/logrus"
+	tview "gitlab.com/tslocum/cview"
+)
+
+// dedupActionLabels are the names of the actions in the confirmation dialog
+var dedupActionLabels = map[dedup.Action]string{
+	dedup.Delete:   "Delete",
+	dedup.Hardlink: "Hard link",
+	dedup.Symlink:  "Symlink",
+}
+
+// duplicateRow is the group and the file listed in a row of the table; file is -1 for the heading of the group
+type duplicateRow struct {
+	group, file int
+}
+
+// DuplicatesController holds the UI objects for the full-screen list of the groups of duplicate files
+type DuplicatesController struct {
+	tviewApp       *tview.Application
+	name           string
+	graphicElement GraphicElement
+
+	root   string
+	groups []dedup.Group
+	rows   []duplicateRow // rows of the table below the header
+
+	runFunc  func(groups []dedup.Group, action dedup.Action)
+	openFunc func(fqfp string)
+	doneFunc func()
+}
+
+// NewDuplicatesController creates a new DuplicatesController object listing the duplicates found below the root.
+func NewDuplicatesController(tviewApp *tview.Application, root string, groups []dedup.Group) (controller *DuplicatesController) {
+	controller = new(DuplicatesController)
+	controller.tviewApp = tviewApp
+	controller.name = "duplicates"
+	controller.root = root
+	controller.groups = groups
+
+	table := tview.NewTable()
+	table.SetBorder(true)
+	table.SetFixed(1, 0)
+	table.SetSelectable(true, false)
+
+	table.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		row := controller.getSelectedRow()
+		switch {
+		case event.Key() == tcell.KeyEscape || event.Rune() == 'q':
+			if controller.doneFunc != nil {
+				controller.doneFunc()
+			}
+			return nil
+		case event.Key() == tcell.KeyEnter:
+			if row != nil && row.file >= 0 && controller.openFunc != nil {
+				controller.openFunc(controller.groups[row.group].Files[row.file].Path)
+			}
+			return nil
+		case event.Rune() == 'd' || event.Rune() == 'h' || event.Rune() == 's':
+			actions := map[rune]dedup.Action{'d': dedup.Delete, 'h': dedup.Hardlink, 's': dedup.Symlink}
+			if controller.runFunc != nil {
+				controller.runFunc(controller.groups, actions[event.Rune()])
+			}
+			return nil
+		case event.Rune() == ' ' || event.Rune() == 'k':
+			if row != nil && row.file >= 0 {
+				controller.groups[row.group].Keep = row.file
+			}
+		case event.Rune() == 'x':
+			if row != nil {
+				controller.groups[row.group].Excluded = !controller.groups[row.group].Excluded
+			}
+		case event.Rune() == 'n' || event.Rune() == 'p':
+			if row != nil {
+				controller.selectGroup(row.group, event.Rune() == 'n')
+			}
+			return nil
+		default:
+			return event
+		}
+
+		if err := controller.Render(); err != nil {
+			log.WithError(err).Error("unable to render duplicates")
+		}
+		return nil
+	})
+
+	controller.graphicElement = table
+	return controller
+}
+
+// SetRunFunc sets the handler which is called when the user asks to apply the action to the redundant copies
+func (c *DuplicatesController) SetRunFunc(handler func(groups []dedup.Group, action dedup.Action)) {
+	c.runFunc = handler
+}
+
+// SetOpenFunc sets the handler which is called when the user asks to go to the selected file
+func (c *DuplicatesController) SetOpenFunc(handler func(fqfp string)) {
+	c.openFunc = handler
+}
+
+// SetDoneFunc sets the handler which is called when the user closes the list
+func (c *DuplicatesController) SetDoneFunc(handler func()) {
+	c.doneFunc = handler
+}
+
+func (c *DuplicatesController) getSelectedRow() *duplicateRow {
+	table := c.graphicElement.(*tview.Table)
+	row, _ := table.GetSelection()
+	if row < 1 || row > len(c.rows) {
+		return nil
+	}
+	return &c.rows[row-1]
+}
+
+// selectGroup moves the cursor to the heading of the group next to the given one, or of the previous one
+func (c *DuplicatesController) selectGroup(group int, next bool) {
+	target := group - 1
+	if next {
+		target = group + 1
+	}
+	for idx, row := range c.rows {
+		if row.group == target && row.file < 0 {
+			c.graphicElement.(*tview.Table).Select(idx+1, 0)
+			return
+		}
+	}
+}
+
+func (c *DuplicatesController) Name() string {
+	return c.name
+}
+
+// Render flushes the state objects to the screen.
+func (c *DuplicatesController) Render() error {
+	log.Tracef("controller.Render() %s", c.Name())
+
+	files, bytes := dedup.Reclaimable(c.groups)
+	table := c.graphicElement.(*tview.Table)
+	table.SetTitle(fmt.Sprintf("Duplicates in %s  %d groups, %d copies, %s reclaimable  "+
+		"[Space: keep, x: exclude, Enter: go to, d/h/s: delete/hard link/symlink]",
+		c.root, len(c.groups), files, utils.HumanBytes(bytes)))
+	table.Clear()
+	headerColumns := []string{"Keep", "Size", "Modified", "Path"}
+	for idx, columnName := range headerColumns {
+		tableCell := tview.NewTableCell(columnName)
+		tableCell.SetTextColor(tcell.ColorYellow)
+		tableCell.SetAlign(tview.AlignCenter)
+		tableCell.SetSelectable(false)
+		table.SetCell(0, idx, tableCell)
+	}
+
+	c.rows = c.rows[:0]
+	for groupIdx := range c.groups {
+		group := &c.groups[groupIdx]
+		color := tcell.ColorTeal
+		note := fmt.Sprintf("%d copies, %s reclaimable", len(group.Files), utils.HumanBytes(group.Reclaimable()))
+		if group.Excluded {
+			color = tcell.ColorGray
+			note += ", excluded"
+		}
+		c.addRow(duplicateRow{group: groupIdx, file: -1}, color, "", "", "", note)
+
+		for fileIdx, file := range group.Files {
+			keep, color := "", tcell.ColorRed
+			if fileIdx == group.Keep {
+				keep, color = "*", tcell.ColorGreen
+			}
+			if group.Excluded {
+				color = tcell.ColorGray
+			}
+			c.addRow(duplicateRow{group: groupIdx, file: fileIdx}, color,
+				keep, utils.HumanBytes(group.Size), file.ModTime.Format("2006-01-02 15:04:05"), "  "+c.relative(file.Path))
+		}
+	}
+
+	if row, _ := table.GetSelection(); row == 0 && table.GetRowCount() > 1 {
+		table.Select(1, 0)
+	} else if row >= table.GetRowCount() {
+		table.Select(table.GetRowCount()-1, 0)
+	}
+	return nil
+}
+
+// addRow appends the row to the table
+func (c *DuplicatesController) addRow(row duplicateRow, color tcell.Color, columns ...string) {
+	table := c.graphicElement.(*tview.Table)
+	c.rows = append(c.rows, row)
+	for idxCol, text := range columns {
+		tableCell := tview.NewTableCell(tview.Escape(text))
+		tableCell.SetTextColor(color)
+		if idxCol == 0 {
+			tableCell.SetAlign(tview.AlignCenter)
+		}
+		table.SetCell(len(c.rows), idxCol, tableCell)
+	}
+}
+
+// relative returns the path relative to the searched directory
+func (c *DuplicatesController) relative(fqfp string) string {
+	if relative, err := filepath.Rel(c.root, fqfp); err == nil {
+		return relative
+	}
+	return fqfp
+}
+
+// IsVisible indicates if the list of the duplicates is currently initialized
+func (c *DuplicatesController) IsVisible() bool {
+	return c != nil
+}
+
+// SetVisible is not used for the list of the duplicates: it is shown and hidden as a page
+func (c *DuplicatesController) SetVisible(visible bool) error {
+	return nil
+}
+
+// GraphicElement returns UI graphicElement used by tview framework to render the UI interface
+func (c *DuplicatesController) GraphicElement() GraphicElement {
+	return c.graphicElement
+}
+
+// FindDuplicates searches the directory of the active panel and its subdirectories for the files of identical
+// content in the background: the files of the same size are hashed, and grouped by the hash. Names matching
+// "compare.exclude" patterns are left out. The groups are listed once found, with the space their redundant
+// copies take, which can be reclaimed by deleting the copies or replacing them with links.
+func (c *FxxController) FindDuplicates() error {
+	if c.sourceFilePanel == nil {
+		return nil
+	}
+	root := c.sourceFilePanel.GetPwd()
+	options := model.ReadOptions{Excludes: DeepCompareOptions().Excludes}
+
+	var groups []dedup.Group
+	c.jobManager.Submit("Find duplicates in "+root, func(job *jobs.Job) (err error) {
+		tree, err := model.ReadFileTreeWithOptions(root, options, job)
+		if err != nil {
+			return err
+		}
+
+		candidates := dedup.Candidates(tree)
+		var bytes int64
+		for _, fileNode := range candidates {
+			bytes += fileNode.Data.FileInfo.Size
+		}
+		job.Progress.SetTotals(int64(len(candidates)), bytes)
+		groups, err = dedup.Find(candidates, system.Config.GetInt("compare.workers"), job)
+		c.saveHashCache()
+		return err
+	}, func(job *jobs.Job) {
+		c.tviewApp.QueueUpdateDraw(func() {
+			if errors.Is(job.Err(), jobs.ErrCancelled) {
+				return
+			}
+			if err := job.Err(); err != nil {
+				// files that could not be hashed are left out
+				system.MessageBus.Error(err.Error())
+			}
+			if groups == nil && job.Err() == nil {
+				system.MessageBus.Error("no duplicates found in " + root)
+				return
+			}
+			if groups != nil {
+				c.showDuplicates(root, groups)
+			}
+		})
+	})
+	return nil
+}
+
+// showDuplicates opens the list of the groups of duplicates, where the copies to keep are chosen
+func (c *FxxController) showDuplicates(root string, groups []dedup.Group) {
+	formId := "formDuplicates"
+	duplicatesController := NewDuplicatesController(c.tviewApp, root, groups)
+	duplicatesController.SetDoneFunc(func() {
+		c.hideModalForm(formId)
+	})
+	duplicatesController.SetOpenFunc(func(fqfp string) {
+		c.hideModalForm(formId)
+		if err := c.sourceFilePanel.ChangeDir(filepath.Dir(fqfp)); err != nil {
+			system.MessageBus.Error(err.Error())
+		}
+	})
+	duplicatesController.SetRunFunc(func(groups []dedup.Group, action dedup.Action) {
+		c.confirmDedup(groups, action, duplicatesController, func() {
+			c.hideModalForm(formId)
+		})
+	})
+
+	c.showFullScreenForm(formId, duplicatesController.GraphicElement())
+	if err := duplicatesController.Render(); err != nil {
+		system.MessageBus.Error(err.Error())
+	}
+}
+
+// confirmDedup asks for the confirmation, and applies the action to the redundant copies in the background.
+// Deleted copies are moved to the trash instead if the "delete.mode" setting is "trash".
+// Every processed copy is recorded in the journal; only the trashed ones can be undone.
+func (c *FxxController) confirmDedup(groups []dedup.Group, action dedup.Action, parent Renderer, closeList func()) {
+	files, bytes := dedup.Reclaimable(groups)
+	if files == 0 {
+		system.MessageBus.Error("no duplicates selected")
+		return
+	}
+
+	useTrash := action == dedup.Delete && strings.ToLower(system.Config.GetString("delete.mode")) == "trash"
+	label := dedupActionLabels[action]
+	if useTrash {
+		label = "Trash"
+	}
+
+	formId := "formDuplicatesRun"
+	modalWindow := tview.NewModal()
+	modalWindow.SetBorder(true)
+	modalWindow.SetTitle("Duplicates")
+	modalWindow.SetTitleAlign(tview.AlignCenter)
+	modalWindow.SetText(fmt.Sprintf("%s %d redundant copies?\n\n%s (%d bytes) reclaimable",
+		label, files, utils.HumanBytes(bytes), bytes))
+	modalWindow.AddButtons([]string{"OK", "Cancel"})
+	modalWindow.SetDoneFunc(func(buttonIndex int, buttonLabel string) {
+		c.hideDialog(formId, parent)
+		if buttonLabel != "OK" {
+			return
+		}
+		closeList()
+
+		title := fmt.Sprintf("%s %d duplicates", label, files)
+		c.submitJob(title, func(job *jobs.Job) error {
+			if useTrash {
+				return c.trashFiles(job, redundantCopies(groups))
+			}
+
+			job.Progress.SetTotals(int64(files), bytes)
+			operation := map[dedup.Action]journal.Operation{
+				dedup.Delete:   journal.Delete,
+				dedup.Hardlink: journal.Hardlink,
+				dedup.Symlink:  journal.Symlink,
+			}[action]
+			return dedup.Execute(groups, action, job, func(kept, path string, err error) {
+				if err != nil {
+					return
+				}
+				if action == dedup.Delete {
+					c.record(journal.NewEntry(operation, path, ""))
+				} else {
+					c.record(journal.NewEntry(operation, kept, path))
+				}
+			})
+		}, c.sourceFilePanel, c.targetFilePanel)
+	})
+
+	c.showModalForm(formId, modalWindow)
+}
+
+// redundantCopies returns the paths of all but the kept file of every group that is not excluded
+func redundantCopies(groups []dedup.Group) []string {
+	var paths []string
+	for _, group := range groups {
+		if group.Excluded {
+			continue
+		}
+		for idx, file := range group.Files {
+			if idx != group.Keep {
+				paths = append(paths, file.Path)
+			}
+		}
+	}
+	return paths
+}
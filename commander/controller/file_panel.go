@@ -2,12 +2,19 @@ package controller
 
 import (
 	"errors"
+	"fmt"
 	"github.com/gdamore/tcell/v2"
+	"github.com/mushkevych/9ofm/commander/history"
 	"github.com/mushkevych/9ofm/commander/model"
+	"github.com/mushkevych/9ofm/commander/session"
+	"github.com/mushkevych/9ofm/commander/system"
 	"github.com/mushkevych/9ofm/commander/view"
+	"github.com/mushkevych/9ofm/utils"
 	tview "gitlab.com/tslocum/cview"
 
 	log "github.com/sirupsen/logrus"
+	"os"
+	"path/filepath"
 	"regexp"
 )
 
@@ -20,15 +27,25 @@ var diffTypeColor = map[model.DiffType]tcell.Color{
 	model.Unmodified: tcell.ColorWhite,
 }
 
+// color of the marked files, which takes precedence over the diffTypeColor
+const markedColor = tcell.ColorFuchsia
+
+// index of the column with the file names
+const fileNameColumn = 3
+
 // FilePanelController holds the UI objects and data models for populating the File Tree Panel.
 type FilePanelController struct {
 	tviewApp       *tview.Application
 	name           string
 	graphicElement GraphicElement
+	footer         *tview.TextView
 	ftv            *view.FileTreeView
 
 	filterRegex *regexp.Regexp
 	listeners   []ViewOptionChangeListener
+
+	// directories visited by the panel, with the cursor position in each of them
+	history *history.History
 }
 
 // NewFilePanelController creates a new FilePanelController object attached the the global [tview] screen object.
@@ -38,6 +55,7 @@ func NewFilePanelController(tviewApp *tview.Application, name string, fileTree *
 
 	controller.tviewApp = tviewApp
 	controller.name = name
+	controller.history = history.New(system.Config.GetInt("history.size"))
 	controller.ftv, err = view.NewFileTreeView(fileTree)
 	if err != nil {
 		return nil, err
@@ -55,11 +73,11 @@ func NewFilePanelController(tviewApp *tview.Application, name string, fileTree *
 		fileNode, ok := table.GetCell(row, column).Reference.(*model.FileNode)
 		if !ok {
 			log.Errorf("unable to cast cell.Reference to model.FileNode")
+			return
 		}
 
-		FILE_NAME_COLUMN_INDEX := 3
 		selectedFileName := ".."
-		cellText := string(table.GetCell(row, FILE_NAME_COLUMN_INDEX).Text)
+		cellText := string(table.GetCell(row, fileNameColumn).Text)
 		if cellText == ".." {
 			selectedFileName = fileNode.Name
 			fileNode = fileNode.Parent
@@ -69,17 +87,11 @@ func NewFilePanelController(tviewApp *tview.Application, name string, fileTree *
 			log.Errorf("error in table.SetSelectedFunc->navigateTo(%v)", fileNode)
 		}
 
-		newSelectedRow := 1 // row that should be selected after navigateTo function is complete
-		for rowIdx := 1; rowIdx < table.GetRowCount(); rowIdx++ {
-			cellText = string(table.GetCell(rowIdx, FILE_NAME_COLUMN_INDEX).Text)
-			if cellText == selectedFileName {
-				newSelectedRow = rowIdx
-				break
-			}
+		// row that should be selected after navigateTo function is complete
+		if !controller.selectFileName(selectedFileName) {
+			table.SetOffset(1, 0)
+			table.Select(1, 0)
 		}
-
-		table.SetOffset(newSelectedRow, 0)
-		table.Select(newSelectedRow, 0)
 	})
 
 	table.SetSelectionChangedFunc(func(row, column int) {
@@ -92,23 +104,56 @@ func NewFilePanelController(tviewApp *tview.Application, name string, fileTree *
 	table.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
 		var err error
 		switch event.Key() {
+		// the keys handled here are not passed to the Table, which binds Ctrl+A and Ctrl+E to the first and the last row,
+		// and Space to the selection, which would enter the directory the cursor moved to after marking
 		case tcell.KeyCtrlA:
 			err = controller.toggleShowDiffType(model.Added)
 		case tcell.KeyCtrlR:
 			err = controller.toggleShowDiffType(model.Removed)
-		case tcell.KeyCtrlO:
+		case tcell.KeyCtrlE:
+			// Ctrl+O is reserved for the subshell output, as in other orthodox file managers
 			err = controller.toggleShowDiffType(model.Modified)
 		case tcell.KeyCtrlU:
 			err = controller.toggleShowDiffType(model.Unmodified)
+		case tcell.KeyInsert:
+			err = controller.toggleMark()
+		case tcell.KeyLeft, tcell.KeyRight:
+			if event.Modifiers()&tcell.ModAlt == 0 {
+				return event
+			}
+			if event.Key() == tcell.KeyLeft {
+				err = controller.GoBack()
+			} else {
+				err = controller.GoForward()
+			}
+			if err != nil {
+				system.MessageBus.Error(err.Error())
+			}
+			return nil
+		case tcell.KeyRune:
+			switch event.Rune() {
+			case ' ':
+				err = controller.toggleMark()
+			case '*':
+				controller.ftv.InvertMarks()
+				err = controller.Render()
+			default:
+				return event
+			}
+		default:
+			return event
 		}
 
 		if err != nil {
 			log.WithError(err)
 		}
-		return event
+		return nil
 	})
 
 	controller.graphicElement = table
+
+	controller.footer = tview.NewTextView()
+	controller.footer.SetWrap(false)
 	return controller, err
 }
 
@@ -116,6 +161,59 @@ func (c *FilePanelController) SetFilterRegex(filterRegex *regexp.Regexp) {
 	c.filterRegex = filterRegex
 }
 
+// selectFileName moves the cursor to the file of the given name; returns false if the panel does not list it
+func (c *FilePanelController) selectFileName(name string) bool {
+	table := c.graphicElement.(*tview.Table)
+	for rowIdx := 1; rowIdx < table.GetRowCount(); rowIdx++ {
+		if string(table.GetCell(rowIdx, fileNameColumn).Text) == name {
+			table.SetOffset(rowIdx, 0)
+			table.Select(rowIdx, 0)
+			return true
+		}
+	}
+	return false
+}
+
+// SessionState returns the state of the panel, saved on exit and restored by RestoreSessionState on the next start
+func (c *FilePanelController) SessionState() session.Panel {
+	state := session.Panel{
+		Pwd:             c.GetPwd(),
+		HiddenDiffTypes: c.ftv.HiddenDiffTypeNames(),
+	}
+	table := c.graphicElement.(*tview.Table)
+	if row, _ := table.GetSelection(); row > 0 && row < table.GetRowCount() {
+		state.Selected = string(table.GetCell(row, fileNameColumn).Text)
+	}
+	if c.filterRegex != nil {
+		state.Filter = c.filterRegex.String()
+	}
+	return state
+}
+
+// RestoreSessionState applies the saved filter and hidden DiffTypes, and moves the cursor to the saved file
+// if the panel is still in the saved PWD. Settings that are no longer valid are skipped.
+func (c *FilePanelController) RestoreSessionState(state session.Panel) error {
+	if state.Filter != "" {
+		filterRegex, err := regexp.Compile(state.Filter)
+		if err != nil {
+			log.WithError(err).Warnf("skipping the filter of %s", c.Name())
+		} else {
+			c.SetFilterRegex(filterRegex)
+		}
+	}
+	if err := c.ftv.HideDiffTypes(state.HiddenDiffTypes); err != nil {
+		log.WithError(err).Warnf("skipping the hidden DiffTypes of %s", c.Name())
+	}
+
+	if err := c.Render(); err != nil {
+		return err
+	}
+	if state.Selected != "" && state.Pwd == c.GetPwd() {
+		c.selectFileName(state.Selected)
+	}
+	return nil
+}
+
 func (c *FilePanelController) Name() string {
 	return c.name
 }
@@ -127,18 +225,190 @@ func (c *FilePanelController) GetPwd() string {
 // navigateTo will enter the directory
 func (c *FilePanelController) navigateTo(fileNode *model.FileNode) error {
 	if fileNode.IsDir() || fileNode.AbsPath() == "/" {
-		fqfp := fileNode.AbsPath()
-		fileTree, err := model.ReadFileTree(fqfp)
-		if err != nil {
-			return err
-		}
+		return c.navigateToPath(fileNode.AbsPath())
+	}
 
-		c.ftv, err = view.NewFileTreeView(fileTree)
-		if err != nil {
-			return err
-		}
+	return c.Render()
+}
+
+// navigateToPath enters the directory specified by the fqfp, and records it in the history
+func (c *FilePanelController) navigateToPath(fqfp string) error {
+	current := c.historyEntry()
+	if err := c.changePwd(fqfp); err != nil {
+		return err
+	}
+	c.history.Visit(current, c.GetPwd())
+	return c.Render()
+}
+
+// GoTo enters the directory specified by the fqfp; if the fqfp is a file, GoTo enters its directory
+// and moves the cursor to the file
+func (c *FilePanelController) GoTo(fqfp string) error {
+	info, err := os.Stat(fqfp)
+	if err != nil {
+		return err
+	}
+	dir, name := fqfp, ""
+	if !info.IsDir() {
+		dir, name = filepath.Dir(fqfp), filepath.Base(fqfp)
+	}
+
+	if err = c.navigateToPath(dir); err != nil {
+		return err
+	}
+	if name == "" || !c.selectFileName(name) {
+		table := c.graphicElement.(*tview.Table)
+		table.SetOffset(0, 0)
+		table.Select(1, 0)
+	}
+	return nil
+}
+
+// changePwd enters the directory without recording it in the history
+func (c *FilePanelController) changePwd(fqfp string) error {
+	if c.ftv.IsComparedDir(fqfp) {
+		// stay within the compared tree, which keeps the DiffTypes of the nested files
+		return c.ftv.ChangePwd(fqfp)
+	}
+	return c.readDir(fqfp)
+}
+
+// readDir replaces the file tree of the panel with the directory read afresh
+func (c *FilePanelController) readDir(fqfp string) error {
+	fileTree, err := model.ReadFileTree(fqfp)
+	if err != nil {
+		return err
+	}
+
+	previous := c.ftv
+	c.ftv, err = view.NewFileTreeView(fileTree)
+	if err != nil {
+		return err
 	}
+	c.ftv.CopyHiddenDiffTypes(previous)
+	return nil
+}
+
+// historyEntry returns the current directory, with the file under the cursor, to be recorded in the history
+func (c *FilePanelController) historyEntry() history.Entry {
+	entry := history.Entry{Dir: c.GetPwd()}
+	table := c.graphicElement.(*tview.Table)
+	if row, _ := table.GetSelection(); row > 0 && row < table.GetRowCount() {
+		entry.Selected = string(table.GetCell(row, fileNameColumn).Text)
+	}
+	return entry
+}
+
+// GoBack returns to the previously visited directory, with the cursor on the file selected there
+func (c *FilePanelController) GoBack() error {
+	entry, ok := c.history.Back(c.historyEntry())
+	if !ok {
+		return nil
+	}
+	if err := c.revisit(entry); err != nil {
+		// stay where the panel is in the history as well
+		c.history.Forward(entry)
+		return err
+	}
+	return nil
+}
 
+// GoForward returns to the directory left by GoBack, with the cursor on the file selected there
+func (c *FilePanelController) GoForward() error {
+	entry, ok := c.history.Forward(c.historyEntry())
+	if !ok {
+		return nil
+	}
+	if err := c.revisit(entry); err != nil {
+		// stay where the panel is in the history as well
+		c.history.Back(entry)
+		return err
+	}
+	return nil
+}
+
+// RecentDirs returns the directories visited by the panel, the most recent first
+func (c *FilePanelController) RecentDirs() []history.Entry {
+	return c.history.Recent()
+}
+
+// OpenHistoryEntry enters the directory chosen from the history, with the cursor on the file selected there;
+// unlike GoBack and GoForward, this is a new visit recorded in the history
+func (c *FilePanelController) OpenHistoryEntry(entry history.Entry) error {
+	current := c.historyEntry()
+	if err := c.revisit(entry); err != nil {
+		return err
+	}
+	c.history.Visit(current, c.GetPwd())
+	return nil
+}
+
+// revisit enters the directory from the history and moves the cursor to the file selected there
+func (c *FilePanelController) revisit(entry history.Entry) error {
+	if err := c.changePwd(entry.Dir); err != nil {
+		return err
+	}
+	if err := c.Render(); err != nil {
+		return err
+	}
+	if !c.selectFileName(entry.Selected) {
+		table := c.graphicElement.(*tview.Table)
+		table.SetOffset(0, 0)
+		table.Select(1, 0)
+	}
+	return nil
+}
+
+// toggleMark marks or unmarks the file under the cursor, and moves the cursor to the next row
+func (c *FilePanelController) toggleMark() error {
+	c.ftv.ToggleMark(c.GetSelectedFileNode())
+
+	table := c.graphicElement.(*tview.Table)
+	if row, _ := table.GetSelection(); row+1 < table.GetRowCount() {
+		table.Select(row+1, 0)
+	}
+	return c.Render()
+}
+
+// MarkByGlob marks (or unmarks, if mark is false) files whose names match the shell pattern
+func (c *FilePanelController) MarkByGlob(pattern string, mark bool) error {
+	if _, err := c.ftv.MarkByGlob(pattern, mark); err != nil {
+		return err
+	}
+	return c.Render()
+}
+
+// ClearMarks unmarks all files in the panel
+func (c *FilePanelController) ClearMarks() error {
+	c.ftv.ClearMarks()
+	return c.Render()
+}
+
+// GetMarkedFileNodes returns the marked files, or the file under the cursor if none is marked.
+// The ".." parent reference is never returned.
+func (c *FilePanelController) GetMarkedFileNodes() []*model.FileNode {
+	if marked := c.ftv.MarkedNodes(); len(marked) > 0 {
+		return marked
+	}
+
+	fileNode := c.GetSelectedFileNode()
+	if fileNode == nil || c.ftv.ModelTree.GetNodeByName(fileNode.Name) != fileNode {
+		return nil
+	}
+	return []*model.FileNode{fileNode}
+}
+
+// ChangeDir will enter the directory specified by the fqfp (Fully Qualified File Path)
+func (c *FilePanelController) ChangeDir(fqfp string) error {
+	current := c.historyEntry()
+	if err := c.readDir(fqfp); err != nil {
+		return err
+	}
+	c.history.Visit(current, c.GetPwd())
+
+	table := c.graphicElement.(*tview.Table)
+	table.SetOffset(0, 0)
+	table.Select(1, 0)
 	return c.Render()
 }
 
@@ -186,33 +456,57 @@ func (c *FilePanelController) Render() error {
 
 	rows, fileNodes := c.ftv.ModelTree.StringArrayBetween(0, c.ftv.ModelTree.VisibleSize())
 
+	tableRow := 1
 	for idxRow, row := range rows {
+		fileNode := fileNodes[idxRow]
+		if c.ftv.IsHidden(fileNode) {
+			continue
+		}
+
 		for idxCol, col := range row {
-			fileNode := fileNodes[idxRow]
 			cellTextColor := diffTypeColor[fileNode.Data.DiffType]
+			if c.ftv.IsMarked(fileNode) && fileNode != c.ftv.ModelTree.GetNodeByName("..") {
+				cellTextColor = markedColor
+			}
 
 			tableCell := tview.NewTableCell(col)
 			tableCell.SetTextColor(cellTextColor)
 			tableCell.SetAlign(tview.AlignLeft)
 			tableCell.SetReference(fileNode)
 
-			table.SetCell(idxRow+1, idxCol, tableCell)
+			table.SetCell(tableRow, idxCol, tableCell)
 		}
+		tableRow++
 	}
 
 	if row, _ := table.GetSelection(); row == 0 {
 		// select top-most row, instead of a header
 		table.Select(1, 0)
+	} else if row >= table.GetRowCount() {
+		// the selected file is no longer listed
+		table.Select(table.GetRowCount()-1, 0)
 	}
+
+	c.renderFooter()
 	return nil
 }
 
-// IsVisible indicates if the file tree controller is currently initialized
+// renderFooter shows the number and the size of the marked files, or the PWD if none is marked
+func (c *FilePanelController) renderFooter() {
+	count, size := c.ftv.MarkedSize()
+	if count == 0 {
+		c.footer.SetText(c.GetPwd())
+		return
+	}
+	c.footer.SetText(fmt.Sprintf("Marked: %d, %s (%d bytes)", count, utils.HumanBytes(size), size))
+}
+
+// GetSelectedFileNode returns the file under the cursor, or nil if the panel lists no files
 func (c *FilePanelController) GetSelectedFileNode() *model.FileNode {
 	table := c.graphicElement.(*tview.Table)
 	row, column := table.GetSelection()
-	fileNode := table.GetCell(row, column).Reference
-	return fileNode.(*model.FileNode)
+	fileNode, _ := table.GetCell(row, column).Reference.(*model.FileNode)
+	return fileNode
 }
 
 // IsVisible indicates if the file tree controller is currently initialized
@@ -229,3 +523,8 @@ func (c *FilePanelController) SetVisible(visible bool) error {
 func (c *FilePanelController) GraphicElement() GraphicElement {
 	return c.graphicElement
 }
+
+// Footer returns the one-line summary displayed under the panel
+func (c *FilePanelController) Footer() GraphicElement {
+	return c.footer
+}
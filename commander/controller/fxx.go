@@ -1,15 +1,24 @@
 package controller
 
 import (
+	"errors"
+	"fmt"
 	"github.com/gdamore/tcell/v2"
+	"github.com/mushkevych/9ofm/commander/bookmarks"
+	"github.com/mushkevych/9ofm/commander/fileops"
+	"github.com/mushkevych/9ofm/commander/hashcache"
+	"github.com/mushkevych/9ofm/commander/jobs"
+	"github.com/mushkevych/9ofm/commander/journal"
 	"github.com/mushkevych/9ofm/commander/model"
+	"github.com/mushkevych/9ofm/commander/pathcomplete"
 	"github.com/mushkevych/9ofm/commander/system"
 	"github.com/mushkevych/9ofm/commander/view"
+	"github.com/mushkevych/9ofm/utils"
 	log "github.com/sirupsen/logrus"
 	tview "gitlab.com/tslocum/cview"
-	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 )
 
@@ -22,15 +31,31 @@ type FxxController struct {
 
 	sourceFilePanel *FilePanelController
 	targetFilePanel *FilePanelController
+
+	// runs copy, move and delete in the background
+	jobManager *jobs.Manager
+
+	// history of the file operations, used to undo them
+	journal *journal.Journal
+
+	// hashes of the files computed by the previous comparisons; nil if the cache is disabled
+	hashCache *hashcache.Cache
+
+	// hotlist of the directories; nil if it could not be opened
+	bookmarks *bookmarks.List
 }
 
 // NewFxxController creates a new controller object attached the the global [tview] screen object.
-func NewFxxController(tviewApp *tview.Application, pages *tview.Pages) (controller *FxxController) {
+func NewFxxController(tviewApp *tview.Application, pages *tview.Pages, jobManager *jobs.Manager, journal *journal.Journal, hashCache *hashcache.Cache, bookmarks *bookmarks.List) (controller *FxxController) {
 	controller = new(FxxController)
 
 	// populate main fields
 	controller.tviewApp = tviewApp
 	controller.pages = pages
+	controller.jobManager = jobManager
+	controller.journal = journal
+	controller.hashCache = hashCache
+	controller.bookmarks = bookmarks
 	controller.name = "bottom_row"
 
 	// create tview graphicElement
@@ -46,13 +71,13 @@ func NewFxxController(tviewApp *tview.Application, pages *tview.Pages) (controll
 	}
 
 	buttonF2 := buttonFactory("F2: RENAME", nil)
-	buttonF3 := buttonFactory("F3: VIEW", nil)
-	buttonF4 := buttonFactory("F4: EDIT", nil)
+	buttonF3 := buttonFactory("F3: VIEW", func() { _ = controller.F3() })
+	buttonF4 := buttonFactory("F4: EDIT", func() { _ = controller.F4() })
 	buttonF5 := buttonFactory("F5: COPY", func() { _ = controller.F5 })
 	buttonF6 := buttonFactory("F6: MOVE", func() { _ = controller.F6 })
 	buttonF7 := buttonFactory("F7: MKDIR", func() { _ = controller.F7 })
 	buttonF8 := buttonFactory("F8: RM", func() { _ = controller.F8 })
-	buttonF9 := buttonFactory("F9: TERM", nil)
+	buttonF9 := buttonFactory("F9: TERM", func() { _ = controller.F9() })
 	buttonF10 := buttonFactory("F10: EXIT", func() { _ = controller.F10 })
 
 	flex := tview.NewFlex()
@@ -73,6 +98,10 @@ func NewFxxController(tviewApp *tview.Application, pages *tview.Pages) (controll
 		switch event.Key() {
 		case tcell.KeyF2:
 			err = controller.F2()
+		case tcell.KeyF3:
+			err = controller.F3()
+		case tcell.KeyF4:
+			err = controller.F4()
 		case tcell.KeyF5:
 			err = controller.F5()
 		case tcell.KeyF6:
@@ -81,14 +110,66 @@ func NewFxxController(tviewApp *tview.Application, pages *tview.Pages) (controll
 			err = controller.F7()
 		case tcell.KeyF8:
 			err = controller.F8()
+		case tcell.KeyF9:
+			err = controller.F9()
 		case tcell.KeyF10:
 			err = controller.F10()
+		case tcell.KeyCtrlO:
+			err = controller.ShowTerminal()
+		case tcell.KeyCtrlT:
+			err = controller.ShowJobs()
+		case tcell.KeyCtrlZ:
+			err = controller.Undo()
+		case tcell.KeyCtrlD:
+			err = controller.ComparePanels()
+		case tcell.KeyCtrlK:
+			err = controller.InvalidateHashCache()
+		case tcell.KeyCtrlB:
+			err = controller.AddBookmark()
+		case tcell.KeyCtrlG:
+			err = controller.GoToPath()
+		case tcell.KeyRune:
+			if event.Modifiers()&tcell.ModAlt != 0 {
+				switch event.Rune() {
+				case 'd':
+					err = controller.DeepComparePanels()
+				case 'c':
+					err = controller.ComparePanelsWithCriteria()
+				case 's':
+					err = controller.SyncPanels()
+				case '=':
+					err = controller.DiffFiles()
+				case 'r':
+					err = controller.ExportComparison()
+				case 'f':
+					err = controller.FindDuplicates()
+				case 'h':
+					err = controller.ShowHistory()
+				case 'b':
+					err = controller.ShowBookmarks()
+				default:
+					return event
+				}
+				break
+			}
+
+			switch event.Rune() {
+			case '+':
+				err = controller.MarkByGlob(true)
+			case '-':
+				err = controller.MarkByGlob(false)
+			default:
+				return event
+			}
+		default:
+			return event
 		}
 
 		if err != nil {
 			log.WithError(err)
 		}
-		return event
+		// the handled key is not passed on to the panel, whose Table binds some of the keys, such as Ctrl+B, as well
+		return nil
 	})
 
 	return controller
@@ -104,6 +185,11 @@ func (c *FxxController) SetFilePanels(activeFilePanel, targetFilePanel *FilePane
 	c.targetFilePanel = targetFilePanel
 }
 
+// ActiveFilePanel returns the File Panel the operations apply to
+func (c *FxxController) ActiveFilePanel() *FilePanelController {
+	return c.sourceFilePanel
+}
+
 // Update refreshes the state objects for future rendering (currently does nothing).
 func (c *FxxController) Update() error {
 	return nil
@@ -152,31 +238,98 @@ func (c *FxxController) refreshFilePanel(fpc *FilePanelController) error {
 		return err
 	}
 
+	previous := fpc.ftv
 	fpc.ftv, err = view.NewFileTreeView(fileTree)
 	if err != nil {
 		return err
 	}
+	fpc.ftv.CopyMarks(previous)
+	fpc.ftv.CopyHiddenDiffTypes(previous)
 
 	return fpc.Render()
 }
 
-func copy(source, destination string) error {
-	var err = filepath.Walk(source, func(path string, info os.FileInfo, err error) error {
-		var relPath string = strings.Replace(path, source, "", 1)
-		if relPath == "" {
-			return nil
-		}
-		if info.IsDir() {
-			return os.Mkdir(filepath.Join(destination, relPath), 0755)
-		} else {
-			var data, err1 = ioutil.ReadFile(filepath.Join(source, relPath))
-			if err1 != nil {
-				return err1
+// submitMove moves every source to the destination with the same index in the background.
+// Moves to another file system fall back to copy followed by delete, with the progress and the conflict
+// handling of the copy; the conflict dialog requires the move to run outside of the UI goroutine.
+func (c *FxxController) submitMove(operation journal.Operation, title string, sources, destinations []string) *jobs.Job {
+	conflicts := c.conflictResolver()
+	return c.submitJob(title, func(job *jobs.Job) error {
+		job.Progress.SetTotals(fileops.Measure(sources...))
+
+		options := fileops.DefaultCopyOptions()
+		options.Conflicts = conflicts
+		options.Monitor = job
+		copier := fileops.NewCopier(options)
+
+		errs := new(fileops.OperationErrors)
+		for idx, source := range sources {
+			destination, err := copier.Move(source, destinations[idx])
+			errs.Add(err)
+			if destination != "" {
+				entry := journal.NewEntry(operation, source, destination)
+				// partially moved tree can not be moved back as a whole
+				entry.Undoable = entry.Undoable && err == nil
+				c.record(entry)
+			}
+			if isStopped(err) {
+				break
 			}
-			return ioutil.WriteFile(filepath.Join(destination, relPath), data, 0777)
 		}
+		return errs.ErrorOrNil()
+	}, c.sourceFilePanel, c.targetFilePanel)
+}
+
+// record adds the operations to the journal; failure to do so does not fail the operation itself
+func (c *FxxController) record(entries ...journal.Entry) {
+	if err := c.journal.Record(entries...); err != nil {
+		log.WithError(err).Error("unable to record the operation in the journal")
+	}
+}
+
+// describeFileNodes returns the file name, or the number of files if there are more than one
+func describeFileNodes(fileNodes []*model.FileNode) string {
+	if len(fileNodes) == 1 {
+		return fileNodes[0].Name
+	}
+	return fmt.Sprintf("%d files", len(fileNodes))
+}
+
+// absPaths returns the absolute paths of the files
+func absPaths(fileNodes []*model.FileNode) []string {
+	paths := make([]string, 0, len(fileNodes))
+	for _, fileNode := range fileNodes {
+		paths = append(paths, fileNode.AbsPath())
+	}
+	return paths
+}
+
+// isStopped returns true if the error means that the rest of the files must not be processed
+func isStopped(err error) bool {
+	return errors.Is(err, fileops.ErrAborted) || errors.Is(err, jobs.ErrCancelled)
+}
+
+// expandNamePattern returns the new file name: every "*" in the pattern stands for the original name
+func expandNamePattern(pattern, name string) string {
+	return strings.ReplaceAll(pattern, "*", name)
+}
+
+// submitJob runs the task in the background. Once the task is over, its error is reported
+// and the given file panels are refreshed. Cancellation by the user is not reported as an error.
+func (c *FxxController) submitJob(title string, task jobs.Task, panels ...*FilePanelController) *jobs.Job {
+	return c.jobManager.Submit(title, task, func(job *jobs.Job) {
+		c.tviewApp.QueueUpdateDraw(func() {
+			if err := job.Err(); err != nil && !errors.Is(err, jobs.ErrCancelled) {
+				system.MessageBus.Error(err.Error())
+			}
+
+			for _, fpc := range panels {
+				if err := c.refreshFilePanel(fpc); err != nil {
+					system.MessageBus.Error(err.Error())
+				}
+			}
+		})
 	})
-	return err
 }
 
 // ignoreInput is used as a validator function
@@ -189,37 +342,52 @@ func (c *FxxController) F2() error {
 		return nil
 	}
 
+	sourceFileNodes := c.sourceFilePanel.GetMarkedFileNodes()
+	if len(sourceFileNodes) == 0 {
+		return nil
+	}
+
 	formId := "formRename"
 	label := "Rename :"
-	currentFileName := c.sourceFilePanel.GetSelectedFileNode().Name
+	defaultPattern := sourceFileNodes[0].Name
+	if len(sourceFileNodes) > 1 {
+		// every marked file is renamed according to the pattern, where "*" stands for the original name
+		defaultPattern = "*"
+	}
 	modalForm := tview.NewModal()
 	modalForm.SetBorder(true)
-	modalForm.SetTitle("Rename: " + currentFileName)
+	modalForm.SetTitle("Rename: " + describeFileNodes(sourceFileNodes))
 	modalForm.SetTitleAlign(tview.AlignCenter)
-	modalForm.GetForm().AddInputField(label, currentFileName, 20, nil, nil)
+	modalForm.GetForm().AddInputField(label, defaultPattern, 20, nil, nil)
 	modalForm.AddButtons([]string{"OK", "Cancel"})
 	modalForm.SetDoneFunc(func(buttonIndex int, buttonLabel string) {
 		switch buttonLabel {
 		case "OK":
-			sourceFileNode := c.sourceFilePanel.GetSelectedFileNode()
-			targetFileName := modalForm.GetForm().GetFormItemByLabel(label).(*tview.InputField).GetText()
-			if !strings.HasPrefix(targetFileName, "/") {
-				// assume the target directory as current one
-				pwd := c.sourceFilePanel.ftv.ModelTree.GetPwd()
-				targetFileName = pwd + string(os.PathSeparator) + targetFileName
+			pattern := modalForm.GetForm().GetFormItemByLabel(label).(*tview.InputField).GetText()
+			if len(sourceFileNodes) > 1 && !strings.Contains(pattern, "*") {
+				system.MessageBus.Error("renaming multiple files requires \"*\" in the name pattern")
+				return
 			}
 
-			err := os.Rename(sourceFileNode.AbsPath(), targetFileName)
-			if err != nil {
-				system.MessageBus.Error(err.Error())
+			destinations := make([]string, 0, len(sourceFileNodes))
+			for _, sourceFileNode := range sourceFileNodes {
+				// the single file is given the typed name as is, even if it contains "*"
+				targetFileName := pattern
+				if len(sourceFileNodes) > 1 {
+					targetFileName = expandNamePattern(pattern, sourceFileNode.Name)
+				}
+				if !strings.HasPrefix(targetFileName, "/") {
+					// assume the target directory as current one
+					targetFileName = filepath.Join(c.sourceFilePanel.GetPwd(), targetFileName)
+				}
+				destinations = append(destinations, targetFileName)
 			}
 
-			err = c.refreshFilePanel(c.sourceFilePanel)
-			if err != nil {
+			c.hideModalForm(formId)
+			if err := c.sourceFilePanel.ClearMarks(); err != nil {
 				system.MessageBus.Error(err.Error())
 			}
-
-			c.hideModalForm(formId)
+			c.submitMove(journal.Rename, "Rename "+describeFileNodes(sourceFileNodes), absPaths(sourceFileNodes), destinations)
 		case "Cancel":
 			c.hideModalForm(formId)
 		}
@@ -229,21 +397,81 @@ func (c *FxxController) F2() error {
 	return nil
 }
 
+// F3 opens the selected file in the full-screen viewer
+func (c *FxxController) F3() error {
+	if c.sourceFilePanel == nil || c.targetFilePanel == nil {
+		return nil
+	}
+
+	formId := "formViewer"
+	sourceFileNode := c.sourceFilePanel.GetSelectedFileNode()
+	if sourceFileNode == nil || sourceFileNode.IsDir() {
+		return nil
+	}
+
+	viewer, err := NewViewerController(c.tviewApp, c.pages, sourceFileNode.AbsPath())
+	if err != nil {
+		system.MessageBus.Error(err.Error())
+		return err
+	}
+	viewer.SetDoneFunc(func() {
+		c.hideModalForm(formId)
+	})
+
+	c.showFullScreenForm(formId, viewer.GraphicElement())
+	return nil
+}
+
+// F4 suspends the UI and opens the selected file in the external editor
+func (c *FxxController) F4() error {
+	if c.sourceFilePanel == nil || c.targetFilePanel == nil {
+		return nil
+	}
+
+	sourceFileNode := c.sourceFilePanel.GetSelectedFileNode()
+	if sourceFileNode == nil || sourceFileNode.IsDir() {
+		return nil
+	}
+
+	var err error
+	editor, args := utils.EditorCommand(system.Config.GetString("editor"))
+	c.tviewApp.Suspend(func() {
+		err = utils.RunCmd(editor, append(args, sourceFileNode.AbsPath())...)
+	})
+	if err != nil {
+		system.MessageBus.Error(err.Error())
+	}
+
+	// file size and mode might have changed
+	for _, fpc := range []*FilePanelController{c.sourceFilePanel, c.targetFilePanel} {
+		err = c.refreshFilePanel(fpc)
+		if err != nil {
+			system.MessageBus.Error(err.Error())
+		}
+	}
+	return nil
+}
+
 func (c *FxxController) F5() error {
 	if c.sourceFilePanel == nil || c.targetFilePanel == nil {
 		return nil
 	}
 
+	sourceFileNodes := c.sourceFilePanel.GetMarkedFileNodes()
+	if len(sourceFileNodes) == 0 {
+		return nil
+	}
+
 	formId := "formCopy"
 	label := "Copy :"
 
 	modalForm := tview.NewModal()
 	modalForm.SetBorder(true)
-	modalForm.SetTitle("Copy: " + c.sourceFilePanel.GetSelectedFileNode().Name)
+	modalForm.SetTitle("Copy: " + describeFileNodes(sourceFileNodes))
 	modalForm.SetTitleAlign(tview.AlignCenter)
 
 	defaultTargetFolder := c.targetFilePanel.ftv.ModelTree.GetPwd()
-	if !strings.HasSuffix(defaultTargetFolder, "/"){
+	if !strings.HasSuffix(defaultTargetFolder, "/") {
 		defaultTargetFolder += string(os.PathSeparator)
 	}
 	modalForm.GetForm().AddInputField(label, defaultTargetFolder, 20, ignoreInput, nil)
@@ -252,24 +480,38 @@ func (c *FxxController) F5() error {
 	modalForm.SetDoneFunc(func(buttonIndex int, buttonLabel string) {
 		switch buttonLabel {
 		case "OK":
-			sourceFileNode := c.sourceFilePanel.GetSelectedFileNode()
 			targetFolder := c.targetFilePanel.ftv.ModelTree.GetPwd()
-			if !strings.HasSuffix(targetFolder, "/") {
-				targetFolder += string(os.PathSeparator)
-			}
-
-			targetFileFqfp := targetFolder + string(os.PathSeparator) + sourceFileNode.Name
-			err := copy(sourceFileNode.AbsPath(), targetFileFqfp)
-			if err != nil {
-				system.MessageBus.Error(err.Error())
-			}
-
-			err = c.refreshFilePanel(c.sourceFilePanel)
-			if err != nil {
+			sources := absPaths(sourceFileNodes)
+			c.hideModalForm(formId)
+			if err := c.sourceFilePanel.ClearMarks(); err != nil {
 				system.MessageBus.Error(err.Error())
 			}
 
-			c.hideModalForm(formId)
+			// copy runs outside of the UI goroutine, so that the conflict dialog can be displayed
+			conflicts := c.conflictResolver()
+			c.submitJob("Copy "+describeFileNodes(sourceFileNodes), func(job *jobs.Job) error {
+				job.Progress.SetTotals(fileops.Measure(sources...))
+
+				options := fileops.DefaultCopyOptions()
+				options.Conflicts = conflicts
+				options.Monitor = job
+				copier := fileops.NewCopier(options)
+
+				errs := new(fileops.OperationErrors)
+				for _, source := range sources {
+					destination := filepath.Join(targetFolder, filepath.Base(source))
+					err := copier.Copy(source, destination)
+					errs.Add(err)
+					// the skipped or failed file is not recorded; the renamed one is recorded under its new name
+					if copiedTo := copier.CopiedTo(source); copiedTo != "" {
+						c.record(journal.NewEntry(journal.Copy, source, copiedTo))
+					}
+					if isStopped(err) {
+						break
+					}
+				}
+				return errs.ErrorOrNil()
+			}, c.targetFilePanel)
 		case "Cancel":
 			c.hideModalForm(formId)
 		}
@@ -284,35 +526,46 @@ func (c *FxxController) F6() error {
 		return nil
 	}
 
+	sourceFileNodes := c.sourceFilePanel.GetMarkedFileNodes()
+	if len(sourceFileNodes) == 0 {
+		return nil
+	}
+
 	formId := "formMove"
 	label := "Move :"
 
 	modalForm := tview.NewModal()
 	modalForm.SetBorder(true)
-	modalForm.SetTitle("Move: " + c.sourceFilePanel.GetSelectedFileNode().AbsPath())
+	modalForm.SetTitle("Move: " + describeFileNodes(sourceFileNodes))
 	modalForm.SetTitleAlign(tview.AlignCenter)
 
-	sourceFileNode := c.sourceFilePanel.GetSelectedFileNode()
-	defaultTargetFolder := c.targetFilePanel.ftv.ModelTree.GetPwd()
-	defaultTargetFileFqfp := defaultTargetFolder + string(os.PathSeparator) + sourceFileNode.Name
-	modalForm.GetForm().AddInputField(label, defaultTargetFileFqfp, 20, nil, nil)
+	// a single file may be given a new name, while multiple files are moved into the target folder
+	defaultTarget := c.targetFilePanel.ftv.ModelTree.GetPwd()
+	if len(sourceFileNodes) == 1 {
+		defaultTarget = filepath.Join(defaultTarget, sourceFileNodes[0].Name)
+	}
+	modalForm.GetForm().AddInputField(label, defaultTarget, 20, nil, nil)
 
 	modalForm.AddButtons([]string{"OK", "Cancel"})
 	modalForm.SetDoneFunc(func(buttonIndex int, buttonLabel string) {
 		switch buttonLabel {
 		case "OK":
-			targetFileName := modalForm.GetForm().GetFormItemByLabel(label).(*tview.InputField).GetText()
-			err := os.Rename(sourceFileNode.AbsPath(), targetFileName)
-			if err != nil {
+			target := modalForm.GetForm().GetFormItemByLabel(label).(*tview.InputField).GetText()
+			c.hideModalForm(formId)
+			if err := c.sourceFilePanel.ClearMarks(); err != nil {
 				system.MessageBus.Error(err.Error())
 			}
 
-			err = c.refreshFilePanel(c.sourceFilePanel)
-			if err != nil {
-				system.MessageBus.Error(err.Error())
+			destinations := make([]string, 0, len(sourceFileNodes))
+			for _, sourceFileNode := range sourceFileNodes {
+				if len(sourceFileNodes) > 1 {
+					destinations = append(destinations, filepath.Join(target, sourceFileNode.Name))
+				} else {
+					destinations = append(destinations, target)
+				}
 			}
 
-			c.hideModalForm(formId)
+			c.submitMove(journal.Move, "Move "+describeFileNodes(sourceFileNodes), absPaths(sourceFileNodes), destinations)
 		case "Cancel":
 			c.hideModalForm(formId)
 		}
@@ -343,6 +596,8 @@ func (c *FxxController) F7() error {
 			err := os.Mkdir(fqfp, 0755)
 			if err != nil {
 				system.MessageBus.Error(err.Error())
+			} else {
+				c.record(journal.NewEntry(journal.Mkdir, filepath.Clean(fqfp), ""))
 			}
 
 			err = c.refreshFilePanel(c.sourceFilePanel)
@@ -360,35 +615,324 @@ func (c *FxxController) F7() error {
 	return nil
 }
 
+// F8 deletes the marked files, or moves them to the trash if the "delete.mode" setting is "trash".
+// The confirmation dialog summarizes what is going to be removed.
 func (c *FxxController) F8() error {
 	if c.sourceFilePanel == nil || c.targetFilePanel == nil {
 		return nil
 	}
 
-	formId := "formRmdir"
-	label := "Delete Folder"
+	sourceFileNodes := c.sourceFilePanel.GetMarkedFileNodes()
+	if len(sourceFileNodes) == 0 {
+		return nil
+	}
+
+	useTrash := strings.ToLower(system.Config.GetString("delete.mode")) == "trash"
+	title, action := "Delete", "Delete"
+	if useTrash {
+		title, action = "Move to Trash", "Trash"
+	}
+	sources := absPaths(sourceFileNodes)
+	question := fmt.Sprintf("%s %s?", action, describeFileNodes(sourceFileNodes))
+
+	formId := "formDelete"
 	modalForm := tview.NewModal()
 	modalForm.SetBorder(true)
-	modalForm.SetTitle("Delete Folder")
+	modalForm.SetTitle(title)
 	modalForm.SetTitleAlign(tview.AlignCenter)
-
-	sourceFileNode := c.sourceFilePanel.GetSelectedFileNode()
-	modalForm.GetForm().AddInputField(label, sourceFileNode.AbsPath(), 20, ignoreInput, nil)
+	modalForm.SetText(question + "\n\ncounting...")
 	modalForm.AddButtons([]string{"OK", "Cancel"})
+
+	// walking a large tree takes a while, so the summary is shown once it is ready
+	summaries := make(chan fileops.Summary, 1)
+	go func() {
+		summary := fileops.Summarize(sources...)
+		summaries <- summary
+		c.tviewApp.QueueUpdateDraw(func() {
+			modalForm.SetText(question + "\n\n" + describeSummary(summary))
+		})
+	}()
+
 	modalForm.SetDoneFunc(func(buttonIndex int, buttonLabel string) {
 		switch buttonLabel {
 		case "OK":
-			err := os.Remove(sourceFileNode.AbsPath())
-			if err != nil {
+			c.hideModalForm(formId)
+			if err := c.sourceFilePanel.ClearMarks(); err != nil {
 				system.MessageBus.Error(err.Error())
 			}
 
-			err = c.refreshFilePanel(c.sourceFilePanel)
+			if useTrash {
+				c.submitJob("Trash "+describeFileNodes(sourceFileNodes), func(job *jobs.Job) error {
+					return c.trashFiles(job, sources)
+				}, c.sourceFilePanel)
+				return
+			}
+
+			c.submitJob("Delete "+describeFileNodes(sourceFileNodes), func(job *jobs.Job) error {
+				summary := <-summaries
+				job.Progress.SetTotals(summary.Files, summary.Bytes)
+
+				errs := new(fileops.OperationErrors)
+				for _, source := range sources {
+					err := fileops.Delete(source, job)
+					errs.Add(err)
+					if err == nil {
+						c.record(journal.NewEntry(journal.Delete, source, ""))
+					}
+					if isStopped(err) {
+						break
+					}
+				}
+				return errs.ErrorOrNil()
+			}, c.sourceFilePanel)
+		case "Cancel":
+			c.hideModalForm(formId)
+		}
+	})
+
+	c.showModalForm(formId, modalForm)
+	return nil
+}
+
+// trashFiles moves the files to the home trash one by one
+func (c *FxxController) trashFiles(job *jobs.Job, sources []string) error {
+	trash, err := fileops.NewHomeTrash()
+	if err != nil {
+		return err
+	}
+	job.Progress.SetTotals(int64(len(sources)), 0)
+
+	errs := new(fileops.OperationErrors)
+	for _, source := range sources {
+		if err = job.Checkpoint(); err != nil {
+			errs.Add(err)
+			break
+		}
+
+		name, err := trash.Put(source)
+		errs.Add(err)
+		if name != "" {
+			entry := journal.NewEntry(journal.Trash, source, trash.FilePath(name))
+			// partially trashed tree can not be restored as a whole
+			entry.Undoable = entry.Undoable && err == nil
+			c.record(entry)
+		}
+		job.FileDone()
+	}
+	return errs.ErrorOrNil()
+}
+
+// describeSummary formats the summary for the confirmation dialogs
+func describeSummary(summary fileops.Summary) string {
+	return fmt.Sprintf("%d files, %d directories, %s (%d bytes)",
+		summary.Files, summary.Dirs, utils.HumanBytes(summary.Bytes), summary.Bytes)
+}
+
+// F9 suspends the UI and starts a subshell in the directory of the active panel.
+// Once the subshell exits, the active panel follows the directory the subshell ended in.
+func (c *FxxController) F9() error {
+	if c.sourceFilePanel == nil || c.targetFilePanel == nil {
+		return nil
+	}
+
+	var err error
+	pwd := c.sourceFilePanel.GetPwd()
+	finalDir := pwd
+	shell, args := utils.ShellCommand(system.Config.GetString("shell"))
+	c.tviewApp.Suspend(func() {
+		finalDir, err = utils.RunShell(pwd, shell, args...)
+	})
+	if err != nil {
+		system.MessageBus.Error(err.Error())
+	}
+
+	if finalDir != pwd {
+		err = c.sourceFilePanel.ChangeDir(finalDir)
+	} else {
+		err = c.refreshFilePanel(c.sourceFilePanel)
+	}
+	if err != nil {
+		system.MessageBus.Error(err.Error())
+	}
+
+	// the subshell might have changed the content of the other panel as well
+	err = c.refreshFilePanel(c.targetFilePanel)
+	if err != nil {
+		system.MessageBus.Error(err.Error())
+	}
+	return nil
+}
+
+// ctrlO is Ctrl+O as read from the terminal
+const ctrlO = 0x0f
+
+// ShowTerminal toggles the panels off to show the terminal with the output of the previous subshell sessions;
+// Ctrl+O toggles the panels back on
+func (c *FxxController) ShowTerminal() error {
+	var err error
+	c.tviewApp.Suspend(func() {
+		err = utils.WaitForKey("9ofm: press Ctrl+O to return to the panels", ctrlO)
+	})
+	return err
+}
+
+// MarkByGlob asks for the shell pattern, and marks (or unmarks, if mark is false) the matching files in the active panel
+func (c *FxxController) MarkByGlob(mark bool) error {
+	if c.sourceFilePanel == nil {
+		return nil
+	}
+
+	formId := "formMarkByGlob"
+	label := "Pattern :"
+	title := "Select"
+	if !mark {
+		title = "Deselect"
+	}
+
+	modalForm := tview.NewModal()
+	modalForm.SetBorder(true)
+	modalForm.SetTitle(title)
+	modalForm.SetTitleAlign(tview.AlignCenter)
+	modalForm.GetForm().AddInputField(label, "*", 20, nil, nil)
+	modalForm.AddButtons([]string{"OK", "Cancel"})
+	modalForm.SetDoneFunc(func(buttonIndex int, buttonLabel string) {
+		switch buttonLabel {
+		case "OK":
+			pattern := modalForm.GetForm().GetFormItemByLabel(label).(*tview.InputField).GetText()
+			c.hideModalForm(formId)
+
+			err := c.sourceFilePanel.MarkByGlob(pattern, mark)
 			if err != nil {
 				system.MessageBus.Error(err.Error())
 			}
+		case "Cancel":
+			c.hideModalForm(formId)
+		}
+	})
+
+	c.showModalForm(formId, modalForm)
+	return nil
+}
+
+// GoToPath asks for the path, with Tab completion, and enters it in the active panel; if the path is a file,
+// the panel enters its directory with the cursor on the file. Errors are shown in the dialog, which stays open.
+func (c *FxxController) GoToPath() error {
+	if c.sourceFilePanel == nil {
+		return nil
+	}
+
+	// number of the matching names listed on ambiguous completion
+	const maxListed = 12
+
+	formId := "formGoTo"
+	label := "Path:"
+	pwd := c.sourceFilePanel.GetPwd()
 
+	modalForm := tview.NewModal()
+	modalForm.SetBorder(true)
+	modalForm.SetTitle("Go to")
+	modalForm.SetTitleAlign(tview.AlignCenter)
+	modalForm.SetText("Absolute, relative or ~ path; Tab completes")
+	modalForm.GetForm().AddInputField(label, "", 50, nil, nil)
+	inputField := modalForm.GetForm().GetFormItemByLabel(label).(*tview.InputField)
+
+	goTo := func() {
+		fqfp, err := pathcomplete.Expand(inputField.GetText(), pwd)
+		if err == nil {
+			err = c.sourceFilePanel.GoTo(fqfp)
+		}
+		if err != nil {
+			modalForm.SetText(err.Error())
+			return
+		}
+		c.hideModalForm(formId)
+	}
+
+	inputField.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Key() {
+		case tcell.KeyTab:
+			completed, names := pathcomplete.Complete(inputField.GetText(), pwd)
+			inputField.SetText(completed)
+			switch {
+			case len(names) == 0:
+				modalForm.SetText("No matching files")
+			case len(names) > maxListed:
+				modalForm.SetText(fmt.Sprintf("%s ... (%d files)", strings.Join(names[:maxListed], "  "), len(names)))
+			case len(names) > 1:
+				modalForm.SetText(strings.Join(names, "  "))
+			}
+			return nil
+		case tcell.KeyEnter:
+			goTo()
+			return nil
+		}
+		return event
+	})
+
+	modalForm.AddButtons([]string{"OK", "Cancel"})
+	modalForm.SetDoneFunc(func(buttonIndex int, buttonLabel string) {
+		switch buttonLabel {
+		case "OK":
+			goTo()
+		case "Cancel":
+			c.hideModalForm(formId)
+		}
+	})
+
+	c.showModalForm(formId, modalForm)
+	return nil
+}
+
+// Undo shows the most recent operations from the journal, and reverses the chosen number of them
+func (c *FxxController) Undo() error {
+	if c.sourceFilePanel == nil || c.targetFilePanel == nil {
+		return nil
+	}
+
+	// number of operations listed in the dialog
+	const maxListed = 10
+	entries, err := c.journal.LastUndoable(maxListed)
+	if err != nil {
+		system.MessageBus.Error(err.Error())
+		return err
+	}
+	if len(entries) == 0 {
+		system.MessageBus.Error(journal.ErrNothingToUndo.Error())
+		return nil
+	}
+
+	lines := make([]string, 0, len(entries))
+	for idx, entry := range entries {
+		lines = append(lines, fmt.Sprintf("%d. %s  %s", idx+1, entry.Time.Format("2006-01-02 15:04:05"), entry))
+	}
+
+	formId := "formUndo"
+	label := "Operations to undo:"
+	modalForm := tview.NewModal()
+	modalForm.SetBorder(true)
+	modalForm.SetTitle("Undo")
+	modalForm.SetTitleAlign(tview.AlignCenter)
+	modalForm.SetText(strings.Join(lines, "\n"))
+	modalForm.GetForm().AddInputField(label, "1", 5, tview.InputFieldInteger, nil)
+	modalForm.AddButtons([]string{"OK", "Cancel"})
+	modalForm.SetDoneFunc(func(buttonIndex int, buttonLabel string) {
+		switch buttonLabel {
+		case "OK":
+			count, err := strconv.Atoi(modalForm.GetForm().GetFormItemByLabel(label).(*tview.InputField).GetText())
+			if err != nil || count < 1 {
+				system.MessageBus.Error("the number of operations to undo must be a positive integer")
+				return
+			}
 			c.hideModalForm(formId)
+
+			c.submitJob(fmt.Sprintf("Undo %d operations", count), func(job *jobs.Job) error {
+				job.Progress.SetTotals(int64(count), 0)
+				undone, err := c.journal.Undo(count)
+				for range undone {
+					job.FileDone()
+				}
+				return err
+			}, c.sourceFilePanel, c.targetFilePanel)
 		case "Cancel":
 			c.hideModalForm(formId)
 		}
@@ -398,6 +942,18 @@ func (c *FxxController) F8() error {
 	return nil
 }
 
+// ShowJobs opens the list of the background jobs, where they can be paused, resumed and cancelled
+func (c *FxxController) ShowJobs() error {
+	formId := "formJobs"
+	jobsController := NewJobsController(c.tviewApp, c.jobManager)
+	jobsController.SetDoneFunc(func() {
+		c.hideModalForm(formId)
+	})
+
+	c.showFullScreenForm(formId, jobsController.GraphicElement())
+	return jobsController.Render()
+}
+
 func (c *FxxController) F10() error {
 	modalWindow := tview.NewModal()
 	modalWindow.SetText("Do you want to quit the application?")
@@ -420,6 +976,16 @@ func (c *FxxController) hideModalForm(formId string) {
 	c.tviewApp.SetFocus(c.sourceFilePanel.graphicElement)
 }
 
+// showFullScreenForm displays the form on top of the main layout, resized to the whole screen
+func (c *FxxController) showFullScreenForm(formId string, form tview.Primitive) {
+	c.pages.AddPage(
+		formId,
+		form,
+		true,
+		true,
+	)
+}
+
 func (c *FxxController) showModalForm(formId string, form tview.Primitive) {
 	c.pages.AddPage(
 		formId,
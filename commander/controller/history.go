@@ -0,0 +1,173 @@
+package controller
+
+import (
+	"github.com/gdamore/tcell/v2"
+	"github.com/mushkevych/9ofm/commander/history"
+	"github.com/mushkevych/9ofm/commander/system"
+	log "github.com/sirupsen/logrus"
+	tview "gitlab.com/tslocum/cview"
+)
+
+// size of the popups, such as the directory history: number of chars and number of rows
+const (
+	popupWidth  = 80
+	popupHeight = 20
+)
+
+// HistoryController holds the UI objects for the popup listing the directories recently visited by a file panel.
+// Typing narrows the list down to the directories containing the text.
+type HistoryController struct {
+	tviewApp       *tview.Application
+	name           string
+	graphicElement GraphicElement
+
+	search  *tview.InputField
+	table   *tview.Table
+	entries []history.Entry
+
+	openFunc func(entry history.Entry)
+	doneFunc func()
+}
+
+// NewHistoryController creates a new HistoryController object attached the the global [tview] screen object.
+func NewHistoryController(tviewApp *tview.Application, entries []history.Entry) (controller *HistoryController) {
+	controller = new(HistoryController)
+	controller.tviewApp = tviewApp
+	controller.name = "history"
+	controller.entries = entries
+
+	controller.table = tview.NewTable()
+	controller.table.SetSelectable(true, false)
+
+	controller.search = tview.NewInputField()
+	controller.search.SetLabel("Search: ")
+	controller.search.SetChangedFunc(func(text string) {
+		if err := controller.Render(); err != nil {
+			log.WithError(err).Error("unable to render the history")
+		}
+	})
+	controller.search.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		row, _ := controller.table.GetSelection()
+		switch event.Key() {
+		case tcell.KeyEscape:
+			if controller.doneFunc != nil {
+				controller.doneFunc()
+			}
+		case tcell.KeyEnter:
+			controller.open()
+		case tcell.KeyUp:
+			if row > 0 {
+				controller.table.Select(row-1, 0)
+			}
+		case tcell.KeyDown:
+			if row+1 < controller.table.GetRowCount() {
+				controller.table.Select(row+1, 0)
+			}
+		default:
+			return event
+		}
+		return nil
+	})
+
+	popup := tview.NewFlex()
+	popup.SetDirection(tview.FlexRow)
+	popup.SetBorder(true)
+	popup.SetTitle("Directory history  [type to search, Enter: open, Esc: close]")
+	popup.SetBackgroundTransparent(false)
+	popup.AddItem(controller.search, 1, 0, true)
+	popup.AddItem(controller.table, 0, 1, false)
+
+	controller.graphicElement = centered(popup, popupWidth, popupHeight)
+	return controller
+}
+
+// centered places the primitive of the given size in the middle of the screen
+func centered(primitive tview.Primitive, width, height int) *tview.Flex {
+	row := tview.NewFlex()
+	row.SetDirection(tview.FlexRow)
+	row.AddItem(nil, 0, 1, false)
+	row.AddItem(primitive, height, 0, true)
+	row.AddItem(nil, 0, 1, false)
+
+	column := tview.NewFlex()
+	column.AddItem(nil, 0, 1, false)
+	column.AddItem(row, width, 0, true)
+	column.AddItem(nil, 0, 1, false)
+	return column
+}
+
+// SetOpenFunc sets the handler which is called with the directory chosen by the user
+func (c *HistoryController) SetOpenFunc(handler func(entry history.Entry)) {
+	c.openFunc = handler
+}
+
+// SetDoneFunc sets the handler which is called when the user closes the popup
+func (c *HistoryController) SetDoneFunc(handler func()) {
+	c.doneFunc = handler
+}
+
+func (c *HistoryController) open() {
+	row, _ := c.table.GetSelection()
+	entry, ok := c.table.GetCell(row, 0).Reference.(history.Entry)
+	if !ok || c.openFunc == nil {
+		return
+	}
+	c.openFunc(entry)
+}
+
+func (c *HistoryController) Name() string {
+	return c.name
+}
+
+// Render lists the directories matching the search
+func (c *HistoryController) Render() error {
+	log.Tracef("controller.Render() %s", c.Name())
+
+	c.table.Clear()
+	for idx, entry := range history.Matching(c.entries, c.search.GetText()) {
+		tableCell := tview.NewTableCell(entry.Dir)
+		tableCell.SetReference(entry)
+		c.table.SetCell(idx, 0, tableCell)
+	}
+	c.table.SetOffset(0, 0)
+	c.table.Select(0, 0)
+	return nil
+}
+
+// IsVisible indicates if the history popup is currently initialized
+func (c *HistoryController) IsVisible() bool {
+	return c != nil
+}
+
+// SetVisible is not used for the history popup: it is shown and hidden as a page
+func (c *HistoryController) SetVisible(visible bool) error {
+	return nil
+}
+
+// GraphicElement returns UI graphicElement used by tview framework to render the UI interface
+func (c *HistoryController) GraphicElement() GraphicElement {
+	return c.graphicElement
+}
+
+// ShowHistory opens the popup with the directories recently visited by the active panel, and enters the chosen one
+func (c *FxxController) ShowHistory() error {
+	if c.sourceFilePanel == nil {
+		return nil
+	}
+
+	formId := "formHistory"
+	filePanel := c.sourceFilePanel
+	historyController := NewHistoryController(c.tviewApp, filePanel.RecentDirs())
+	historyController.SetDoneFunc(func() {
+		c.hideModalForm(formId)
+	})
+	historyController.SetOpenFunc(func(entry history.Entry) {
+		c.hideModalForm(formId)
+		if err := filePanel.OpenHistoryEntry(entry); err != nil {
+			system.MessageBus.Error(err.Error())
+		}
+	})
+
+	c.showFullScreenForm(formId, historyController.GraphicElement())
+	return historyController.Render()
+}
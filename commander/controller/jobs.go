@@ -0,0 +1,192 @@
+package controller
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/mushkevych/9ofm/commander/jobs"
+	"github.com/mushkevych/9ofm/utils"
+	log "github.com/sirupsen/logrus"
+	tview "gitlab.com/tslocum/cview"
+)
+
+var jobStateColor = map[jobs.State]tcell.Color{
+	jobs.Queued:    tcell.ColorWhite,
+	jobs.Running:   tcell.ColorYellow,
+	jobs.Paused:    tcell.ColorTeal,
+	jobs.Done:      tcell.ColorGreen,
+	jobs.Failed:    tcell.ColorRed,
+	jobs.Cancelled: tcell.ColorGray,
+}
+
+// JobsController holds the UI objects for the full-screen list of the background jobs
+type JobsController struct {
+	tviewApp       *tview.Application
+	name           string
+	graphicElement GraphicElement
+
+	jobManager *jobs.Manager
+	stop       chan struct{}
+	doneFunc   func()
+}
+
+// NewJobsController creates a new JobsController object attached the the global [tview] screen object.
+func NewJobsController(tviewApp *tview.Application, jobManager *jobs.Manager) (controller *JobsController) {
+	controller = new(JobsController)
+	controller.tviewApp = tviewApp
+	controller.name = "jobs"
+	controller.jobManager = jobManager
+	controller.stop = make(chan struct{})
+
+	table := tview.NewTable()
+	table.SetBorder(true)
+	table.SetTitle("Jobs  [p: pause/resume, c: cancel, Esc: close]")
+	table.SetFixed(1, 0)
+	table.SetSelectable(true, false)
+
+	table.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch {
+		case event.Key() == tcell.KeyEscape || event.Rune() == 'q':
+			controller.close()
+			return nil
+		case event.Rune() == 'p' || event.Rune() == ' ':
+			if job := controller.getSelectedJob(); job != nil {
+				job.TogglePause()
+			}
+		case event.Rune() == 'c' || event.Key() == tcell.KeyDelete:
+			if job := controller.getSelectedJob(); job != nil {
+				job.Cancel()
+			}
+		default:
+			return event
+		}
+
+		if err := controller.Render(); err != nil {
+			log.WithError(err).Error("unable to render jobs")
+		}
+		return nil
+	})
+
+	controller.graphicElement = table
+	go controller.refreshPeriodically()
+	return controller
+}
+
+// SetDoneFunc sets the handler which is called when the user closes the job list
+func (c *JobsController) SetDoneFunc(handler func()) {
+	c.doneFunc = handler
+}
+
+func (c *JobsController) close() {
+	close(c.stop)
+	if c.doneFunc != nil {
+		c.doneFunc()
+	}
+}
+
+// refreshPeriodically redraws the job list until it is closed
+func (c *JobsController) refreshPeriodically() {
+	ticker := time.NewTicker(progressRefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.stop:
+			return
+		case <-ticker.C:
+			c.tviewApp.QueueUpdateDraw(func() {
+				if err := c.Render(); err != nil {
+					log.WithError(err).Error("unable to render jobs")
+				}
+			})
+		}
+	}
+}
+
+func (c *JobsController) getSelectedJob() *jobs.Job {
+	table := c.graphicElement.(*tview.Table)
+	row, _ := table.GetSelection()
+	job, ok := table.GetCell(row, 0).Reference.(*jobs.Job)
+	if !ok {
+		return nil
+	}
+	return job
+}
+
+func (c *JobsController) Name() string {
+	return c.name
+}
+
+// Render flushes the state objects to the screen.
+func (c *JobsController) Render() error {
+	log.Tracef("controller.Render() %s", c.Name())
+
+	table := c.graphicElement.(*tview.Table)
+	table.Clear()
+	headerColumns := []string{"ID", "State", "Progress", "Files", "Bytes", "Speed", "ETA", "Title", "Error"}
+	for idx, columnName := range headerColumns {
+		tableCell := tview.NewTableCell(columnName)
+		tableCell.SetTextColor(tcell.ColorYellow)
+		tableCell.SetAlign(tview.AlignCenter)
+		tableCell.SetSelectable(false)
+		table.SetCell(0, idx, tableCell)
+	}
+
+	// the most recent jobs go first
+	jobList := c.jobManager.Jobs()
+	for idx := range jobList {
+		job := jobList[len(jobList)-1-idx]
+		progress := job.Progress
+		filesDone, filesTotal := progress.Files()
+		bytesDone, bytesTotal := progress.Bytes()
+
+		eta := ""
+		if remaining := progress.ETA(); remaining > 0 && !job.State().IsFinal() {
+			eta = utils.HumanDuration(remaining)
+		}
+		errorText := ""
+		if err := job.Err(); err != nil {
+			errorText = err.Error()
+		}
+
+		columns := []string{
+			strconv.Itoa(job.ID),
+			job.State().String(),
+			fmt.Sprintf("%d%%", progress.Percent()),
+			fmt.Sprintf("%d/%d", filesDone, filesTotal),
+			fmt.Sprintf("%s/%s", utils.HumanBytes(bytesDone), utils.HumanBytes(bytesTotal)),
+			utils.HumanBytes(int64(progress.Throughput())) + "/s",
+			eta,
+			job.Title,
+			errorText,
+		}
+		for idxCol, text := range columns {
+			tableCell := tview.NewTableCell(text)
+			tableCell.SetTextColor(jobStateColor[job.State()])
+			tableCell.SetReference(job)
+			table.SetCell(idx+1, idxCol, tableCell)
+		}
+	}
+
+	if row, _ := table.GetSelection(); row == 0 && table.GetRowCount() > 1 {
+		table.Select(1, 0)
+	}
+	return nil
+}
+
+// IsVisible indicates if the job list is currently initialized
+func (c *JobsController) IsVisible() bool {
+	return c != nil
+}
+
+// SetVisible is not used for the job list: it is shown and hidden as a page
+func (c *JobsController) SetVisible(visible bool) error {
+	return nil
+}
+
+// GraphicElement returns UI graphicElement used by tview framework to render the UI interface
+func (c *JobsController) GraphicElement() GraphicElement {
+	return c.graphicElement
+}
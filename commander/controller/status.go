@@ -0,0 +1,120 @@
+package controller
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/mushkevych/9ofm/commander/jobs"
+	"github.com/mushkevych/9ofm/utils"
+	log "github.com/sirupsen/logrus"
+	tview "gitlab.com/tslocum/cview"
+)
+
+// interval between two consecutive refreshes of the job progress on the screen
+const progressRefreshInterval = 500 * time.Millisecond
+
+const idleStatus = "9ofm  [Ctrl+T: jobs]"
+
+// StatusController defines the top UI row, which shows the progress of the background jobs
+type StatusController struct {
+	tviewApp       *tview.Application
+	name           string
+	graphicElement GraphicElement
+
+	jobManager *jobs.Manager
+	wasActive  bool
+}
+
+// NewStatusController creates a new controller object attached the the global [tview] screen object.
+func NewStatusController(tviewApp *tview.Application, jobManager *jobs.Manager) (controller *StatusController) {
+	controller = new(StatusController)
+
+	// populate main fields
+	controller.tviewApp = tviewApp
+	controller.name = "status_row"
+	controller.jobManager = jobManager
+
+	textView := tview.NewTextView()
+	textView.SetDynamicColors(false)
+	textView.SetWrap(false)
+	textView.SetText(idleStatus)
+	controller.graphicElement = textView
+
+	go controller.refreshPeriodically()
+	return controller
+}
+
+// refreshPeriodically redraws the status row while there are active jobs, and once more after they are gone
+func (c *StatusController) refreshPeriodically() {
+	ticker := time.NewTicker(progressRefreshInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		isActive := len(c.jobManager.Active()) > 0
+		if isActive || c.wasActive {
+			c.tviewApp.QueueUpdateDraw(func() {
+				if err := c.Render(); err != nil {
+					log.WithError(err).Error("unable to render status row")
+				}
+			})
+		}
+		c.wasActive = isActive
+	}
+}
+
+func (c *StatusController) Name() string {
+	return c.name
+}
+
+// Render flushes the state objects to the screen.
+func (c *StatusController) Render() error {
+	log.Tracef("controller.Render() %s", c.Name())
+
+	textView := c.graphicElement.(*tview.TextView)
+	active := c.jobManager.Active()
+	if len(active) == 0 {
+		textView.SetText(idleStatus)
+		return nil
+	}
+
+	// the first active job is the one making progress; the rest is waiting in the queue
+	textView.SetText(fmt.Sprintf("%s  (+%d queued)  [Ctrl+T: jobs]", describeProgress(active[0]), len(active)-1))
+	return nil
+}
+
+// describeProgress formats the job progress as a single line
+func describeProgress(job *jobs.Job) string {
+	progress := job.Progress
+	bytesDone, bytesTotal := progress.Bytes()
+	filesDone, filesTotal := progress.Files()
+
+	eta := "--:--"
+	if remaining := progress.ETA(); remaining > 0 {
+		eta = utils.HumanDuration(remaining)
+	}
+
+	return fmt.Sprintf("[%s] %s  %d%%  %d/%d files  %s/%s  %s/s  ETA %s",
+		job.State(),
+		job.Title,
+		progress.Percent(),
+		filesDone, filesTotal,
+		utils.HumanBytes(bytesDone), utils.HumanBytes(bytesTotal),
+		utils.HumanBytes(int64(progress.Throughput())),
+		eta,
+	)
+}
+
+// IsVisible indicates if the status controller pane is currently initialized.
+func (c *StatusController) IsVisible() bool {
+	return c != nil
+}
+
+// SetVisible is not used for the status row
+func (c *StatusController) SetVisible(visible bool) error {
+	return nil
+}
+
+// GraphicElement returns UI graphicElement used by tview framework to render the UI interface
+func (c *StatusController) GraphicElement() GraphicElement {
+	return c.graphicElement
+}
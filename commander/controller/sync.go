@@ -0,0 +1,396 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/mushkevych/9ofm/commander/jobs"
+	"github.com/mushkevych/9ofm/commander/journal"
+	"github.com/mushkevych/9ofm/commander/model"
+	"github.com/mushkevych/9ofm/commander/syncplan"
+	"github.com/mushkevych/9ofm/commander/system"
+	"github.com/mushkevych/9ofm/utils"
+	log "github.com/sirupsen/logrus"
+	tview "gitlab.com/tslocum/cview"
+)
+
+var syncActionColor = map[syncplan.Action]tcell.Color{
+	syncplan.Skip:    tcell.ColorGray,
+	syncplan.Copy:    tcell.ColorGreen,
+	syncplan.Replace: tcell.ColorYellow,
+	syncplan.Delete:  tcell.ColorRed,
+}
+
+// SyncController holds the UI objects for the full-screen plan of syncing the directories of both panels
+type SyncController struct {
+	tviewApp       *tview.Application
+	name           string
+	graphicElement GraphicElement
+
+	plan       *syncplan.Plan
+	runFunc    func(plan *syncplan.Plan)
+	exportFunc func(plan *syncplan.Plan)
+	doneFunc   func()
+}
+
+// NewSyncController creates a new SyncController object attached the the global [tview] screen object.
+func NewSyncController(tviewApp *tview.Application, plan *syncplan.Plan) (controller *SyncController) {
+	controller = new(SyncController)
+	controller.tviewApp = tviewApp
+	controller.name = "sync"
+	controller.plan = plan
+
+	table := tview.NewTable()
+	table.SetBorder(true)
+	table.SetFixed(1, 0)
+	table.SetSelectable(true, false)
+
+	table.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		item := controller.getSelectedItem()
+		switch {
+		case event.Key() == tcell.KeyEscape || event.Rune() == 'q':
+			if controller.doneFunc != nil {
+				controller.doneFunc()
+			}
+			return nil
+		case event.Key() == tcell.KeyEnter || event.Rune() == 'r':
+			if controller.runFunc != nil {
+				controller.runFunc(controller.plan)
+			}
+			return nil
+		case event.Rune() == 'e':
+			if controller.exportFunc != nil {
+				controller.exportFunc(controller.plan)
+			}
+			return nil
+		case event.Key() == tcell.KeyRight || event.Rune() == '>':
+			if item != nil {
+				item.Direction = syncplan.LeftToRight
+			}
+		case event.Key() == tcell.KeyLeft || event.Rune() == '<':
+			if item != nil {
+				item.Direction = syncplan.RightToLeft
+			}
+		case event.Rune() == 'f':
+			if item != nil {
+				item.Flip()
+			}
+		case event.Rune() == ' ' || event.Rune() == 'x':
+			if item != nil {
+				item.Excluded = !item.Excluded
+			}
+		default:
+			return event
+		}
+
+		if err := controller.Render(); err != nil {
+			log.WithError(err).Error("unable to render sync plan")
+		}
+		return nil
+	})
+
+	controller.graphicElement = table
+	return controller
+}
+
+// SetRunFunc sets the handler which is called when the user asks to execute the plan
+func (c *SyncController) SetRunFunc(handler func(plan *syncplan.Plan)) {
+	c.runFunc = handler
+}
+
+// SetExportFunc sets the handler which is called when the user asks to save the plan into a file
+func (c *SyncController) SetExportFunc(handler func(plan *syncplan.Plan)) {
+	c.exportFunc = handler
+}
+
+// SetDoneFunc sets the handler which is called when the user closes the plan
+func (c *SyncController) SetDoneFunc(handler func()) {
+	c.doneFunc = handler
+}
+
+func (c *SyncController) getSelectedItem() *syncplan.Item {
+	table := c.graphicElement.(*tview.Table)
+	row, _ := table.GetSelection()
+	if row < 1 || row > len(c.plan.Items) {
+		return nil
+	}
+	return &c.plan.Items[row-1]
+}
+
+func (c *SyncController) Name() string {
+	return c.name
+}
+
+// Render flushes the state objects to the screen.
+func (c *SyncController) Render() error {
+	log.Tracef("controller.Render() %s", c.Name())
+
+	table := c.graphicElement.(*tview.Table)
+	table.SetTitle(fmt.Sprintf("Sync (%s) %s : %s  %s  [</>: direction, Space: exclude, e: export, Enter: run, Esc: close]",
+		c.plan.Mode, c.plan.Left, c.plan.Right, describeSyncCounts(c.plan.Counts())))
+	table.Clear()
+	headerColumns := []string{"Left", "Direction", "Right", "Action", "Path", "Note"}
+	for idx, columnName := range headerColumns {
+		tableCell := tview.NewTableCell(columnName)
+		tableCell.SetTextColor(tcell.ColorYellow)
+		tableCell.SetAlign(tview.AlignCenter)
+		tableCell.SetSelectable(false)
+		table.SetCell(0, idx, tableCell)
+	}
+
+	for idx, item := range c.plan.Items {
+		direction := string(item.Direction)
+		if direction == "" {
+			direction = "?"
+		}
+		note := item.Conflict
+		if item.Excluded {
+			note = "excluded"
+		}
+
+		columns := []string{
+			string(item.Left),
+			direction,
+			string(item.Right),
+			string(item.Action()),
+			item.Path,
+			note,
+		}
+		for idxCol, text := range columns {
+			tableCell := tview.NewTableCell(text)
+			tableCell.SetTextColor(syncActionColor[item.Action()])
+			if idxCol == 1 {
+				tableCell.SetAlign(tview.AlignCenter)
+			}
+			table.SetCell(idx+1, idxCol, tableCell)
+		}
+	}
+
+	if row, _ := table.GetSelection(); row == 0 && table.GetRowCount() > 1 {
+		table.Select(1, 0)
+	}
+	return nil
+}
+
+// IsVisible indicates if the sync plan is currently initialized
+func (c *SyncController) IsVisible() bool {
+	return c != nil
+}
+
+// SetVisible is not used for the sync plan: it is shown and hidden as a page
+func (c *SyncController) SetVisible(visible bool) error {
+	return nil
+}
+
+// GraphicElement returns UI graphicElement used by tview framework to render the UI interface
+func (c *SyncController) GraphicElement() GraphicElement {
+	return c.graphicElement
+}
+
+// describeSyncCounts lists the number of items for every action, except for the skipped ones
+func describeSyncCounts(counts map[syncplan.Action]int) string {
+	var parts []string
+	for _, action := range []syncplan.Action{syncplan.Copy, syncplan.Replace, syncplan.Delete} {
+		if counts[action] > 0 {
+			parts = append(parts, fmt.Sprintf("%s: %d", action, counts[action]))
+		}
+	}
+	if len(parts) == 0 {
+		return "nothing to do"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// SyncPanels asks for the sync mode, then reads and compares the directories of both panels in the background,
+// the same way as DeepComparePanels does, and shows the plan of bringing them in sync. The active panel is the left
+// side of the plan. Directories below the "compare.depth" levels are not read, and hence are not synced.
+func (c *FxxController) SyncPanels() error {
+	if c.sourceFilePanel == nil || c.targetFilePanel == nil {
+		return nil
+	}
+	leftPwd, rightPwd := c.sourceFilePanel.GetPwd(), c.targetFilePanel.GetPwd()
+
+	formId := "formSyncMode"
+	modalWindow := tview.NewModal()
+	modalWindow.SetBorder(true)
+	modalWindow.SetTitle("Sync")
+	modalWindow.SetTitleAlign(tview.AlignCenter)
+	modalWindow.SetText(fmt.Sprintf("%s : %s\n\n"+
+		"Mirror: make the right side look like the left one, including deletes\n"+
+		"Update: copy the missing and the newer files to the right side\n"+
+		"Two-way: copy the missing and the newer files both ways", leftPwd, rightPwd))
+	modes := map[string]syncplan.Mode{"Mirror": syncplan.Mirror, "Update": syncplan.Update, "Two-way": syncplan.TwoWay}
+	modalWindow.AddButtons([]string{"Mirror", "Update", "Two-way", "Cancel"})
+	modalWindow.SetDoneFunc(func(buttonIndex int, buttonLabel string) {
+		c.hideModalForm(formId)
+		if mode, ok := modes[buttonLabel]; ok {
+			c.planSync(leftPwd, rightPwd, mode)
+		}
+	})
+
+	c.showModalForm(formId, modalWindow)
+	return nil
+}
+
+// planSync builds the sync plan in the background, and shows it once it is ready
+func (c *FxxController) planSync(leftPwd, rightPwd string, mode syncplan.Mode) {
+	options := DeepCompareOptions()
+	criteria, err := CompareCriteria()
+	if err != nil {
+		system.MessageBus.Error(err.Error())
+		return
+	}
+
+	var plan *syncplan.Plan
+	title := fmt.Sprintf("Plan %s sync of %s with %s", mode, leftPwd, rightPwd)
+	c.jobManager.Submit(title, func(job *jobs.Job) error {
+		leftTree, rightTree, err := c.readTrees(job, leftPwd, rightPwd, options, criteria.Content)
+		if leftTree == nil || rightTree == nil {
+			return err
+		}
+		if compareErr := model.CompareAndMarkWith(leftTree, rightTree, criteria); compareErr != nil {
+			return compareErr
+		}
+
+		var buildErr error
+		if plan, buildErr = syncplan.Build(leftTree, rightTree, mode); buildErr != nil {
+			return buildErr
+		}
+		// files that could not be hashed are synced as modified
+		return err
+	}, func(job *jobs.Job) {
+		c.tviewApp.QueueUpdateDraw(func() {
+			if errors.Is(job.Err(), jobs.ErrCancelled) {
+				return
+			}
+			if err := job.Err(); err != nil {
+				system.MessageBus.Error(err.Error())
+			}
+			if plan != nil {
+				c.showSyncPlan(plan)
+			}
+		})
+	})
+}
+
+// showSyncPlan opens the plan, where the direction of the items can be changed before it is executed
+func (c *FxxController) showSyncPlan(plan *syncplan.Plan) {
+	formId := "formSync"
+	syncController := NewSyncController(c.tviewApp, plan)
+	syncController.SetDoneFunc(func() {
+		c.hideModalForm(formId)
+	})
+	syncController.SetExportFunc(func(plan *syncplan.Plan) {
+		c.exportSyncPlan(plan, syncController)
+	})
+	syncController.SetRunFunc(func(plan *syncplan.Plan) {
+		c.confirmSync(plan, syncController, func() {
+			c.hideModalForm(formId)
+		})
+	})
+
+	c.showFullScreenForm(formId, syncController.GraphicElement())
+	if err := syncController.Render(); err != nil {
+		system.MessageBus.Error(err.Error())
+	}
+}
+
+// hideDialog removes the dialog shown on top of the full-screen controller, and focuses the controller again
+func (c *FxxController) hideDialog(formId string, parent Renderer) {
+	c.pages.HidePage(formId)
+	c.pages.RemovePage(formId)
+	c.tviewApp.SetFocus(parent.GraphicElement())
+}
+
+// exportSyncPlan asks for the file name and saves the plan as JSON; the saved plan is executed by "9ofm sync"
+func (c *FxxController) exportSyncPlan(plan *syncplan.Plan, parent Renderer) {
+	defaultPath := "sync-plan.json"
+	if home, err := os.UserHomeDir(); err == nil {
+		defaultPath = filepath.Join(home, defaultPath)
+	}
+
+	formId := "formSyncExport"
+	label := "Save as:"
+	modalForm := tview.NewModal()
+	modalForm.SetBorder(true)
+	modalForm.SetTitle("Export sync plan")
+	modalForm.SetTitleAlign(tview.AlignCenter)
+	modalForm.GetForm().AddInputField(label, defaultPath, 40, nil, nil)
+	modalForm.AddButtons([]string{"OK", "Cancel"})
+	modalForm.SetDoneFunc(func(buttonIndex int, buttonLabel string) {
+		c.hideDialog(formId, parent)
+		if buttonLabel != "OK" {
+			return
+		}
+
+		fqfp := modalForm.GetForm().GetFormItemByLabel(label).(*tview.InputField).GetText()
+		if err := plan.Save(fqfp); err != nil {
+			system.MessageBus.Error(err.Error())
+		}
+	})
+
+	c.showModalForm(formId, modalForm)
+}
+
+// confirmSync summarizes the plan, and executes it in the background once confirmed.
+// Every executed item is recorded in the journal for the reference: syncing can not be undone.
+func (c *FxxController) confirmSync(plan *syncplan.Plan, parent Renderer, closePlan func()) {
+	counts := plan.Counts()
+	if counts[syncplan.Copy]+counts[syncplan.Replace]+counts[syncplan.Delete] == 0 {
+		system.MessageBus.Error("the sync plan has nothing to do")
+		return
+	}
+
+	question := fmt.Sprintf("Sync %s : %s?\n\n%s\n\ncounting...", plan.Left, plan.Right, describeSyncCounts(counts))
+	formId := "formSyncRun"
+	modalWindow := tview.NewModal()
+	modalWindow.SetBorder(true)
+	modalWindow.SetTitle("Sync")
+	modalWindow.SetTitleAlign(tview.AlignCenter)
+	modalWindow.SetText(question)
+	modalWindow.AddButtons([]string{"OK", "Cancel"})
+
+	// walking large trees takes a while, so the totals are shown once they are ready
+	type totals struct{ files, bytes int64 }
+	measured := make(chan totals, 1)
+	go func() {
+		files, bytes := plan.Measure()
+		measured <- totals{files, bytes}
+		c.tviewApp.QueueUpdateDraw(func() {
+			modalWindow.SetText(strings.TrimSuffix(question, "counting...") +
+				fmt.Sprintf("%d files, %s", files, utils.HumanBytes(bytes)))
+		})
+	}()
+
+	modalWindow.SetDoneFunc(func(buttonIndex int, buttonLabel string) {
+		c.hideDialog(formId, parent)
+		if buttonLabel != "OK" {
+			return
+		}
+		closePlan()
+
+		title := fmt.Sprintf("Sync %s with %s", plan.Left, plan.Right)
+		c.submitJob(title, func(job *jobs.Job) error {
+			total := <-measured
+			job.Progress.SetTotals(total.files, total.bytes)
+
+			return plan.Execute(job, func(item syncplan.Item, err error) {
+				if err != nil {
+					return
+				}
+				source, target := plan.Paths(item)
+				if item.Action() == syncplan.Delete {
+					c.record(journal.NewEntry(journal.Delete, target, ""))
+				} else {
+					c.record(journal.NewEntry(journal.Copy, source, target))
+				}
+			})
+		}, c.sourceFilePanel, c.targetFilePanel)
+	})
+
+	c.showModalForm(formId, modalWindow)
+}
@@ -0,0 +1,282 @@
+package controller
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/mushkevych/9ofm/commander/model"
+	"github.com/mushkevych/9ofm/commander/view"
+	log "github.com/sirupsen/logrus"
+	tview "gitlab.com/tslocum/cview"
+)
+
+// ViewerController holds the UI objects and data models for the full-screen file viewer (F3)
+type ViewerController struct {
+	tviewApp       *tview.Application
+	pages          *tview.Pages
+	name           string
+	graphicElement GraphicElement
+
+	fv         *view.FileView
+	fqfp       string
+	lastSearch string
+	message    string // outcome of the last action, shown in the status line
+
+	// inner size of the viewer as of the last rendering; used by the scrolling functions
+	width  int
+	height int
+
+	doneFunc func()
+}
+
+// NewViewerController creates a new ViewerController object for the file at the given fully qualified file path.
+func NewViewerController(tviewApp *tview.Application, pages *tview.Pages, fqfp string) (controller *ViewerController, err error) {
+	controller = new(ViewerController)
+	controller.tviewApp = tviewApp
+	controller.pages = pages
+	controller.name = "viewer"
+	controller.fqfp = fqfp
+
+	controller.fv, err = view.NewFileView(fqfp)
+	if err != nil {
+		return nil, err
+	}
+
+	box := tview.NewBox()
+	box.SetBorder(true)
+	box.SetTitle(fqfp)
+	box.SetTitleAlign(tview.AlignLeft)
+	box.SetDrawFunc(controller.draw)
+
+	box.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		var err error
+		controller.message = ""
+		switch event.Key() {
+		case tcell.KeyEscape, tcell.KeyF3, tcell.KeyF10:
+			controller.close()
+			return nil
+		case tcell.KeyUp:
+			err = controller.fv.ScrollUp(controller.width, 1)
+		case tcell.KeyDown, tcell.KeyEnter:
+			err = controller.fv.ScrollDown(controller.width, 1)
+		case tcell.KeyPgUp:
+			err = controller.fv.ScrollUp(controller.width, controller.height)
+		case tcell.KeyPgDn:
+			err = controller.fv.ScrollDown(controller.width, controller.height)
+		case tcell.KeyHome:
+			controller.fv.ScrollToBeginning()
+		case tcell.KeyEnd:
+			err = controller.fv.ScrollToEnd(controller.width, controller.height)
+		case tcell.KeyF4:
+			err = controller.fv.ToggleMode()
+		case tcell.KeyF2:
+			controller.fv.ToggleWrap()
+		case tcell.KeyF5:
+			controller.showGoToForm()
+		case tcell.KeyF7:
+			controller.showSearchForm(true)
+		case tcell.KeyRune:
+			switch event.Rune() {
+			case 'q':
+				controller.close()
+				return nil
+			case ' ':
+				err = controller.fv.ScrollDown(controller.width, controller.height)
+			case 'b':
+				err = controller.fv.ScrollUp(controller.width, controller.height)
+			case 'h':
+				err = controller.fv.ToggleMode()
+			case 'w':
+				controller.fv.ToggleWrap()
+			case 'g':
+				controller.showGoToForm()
+			case '/':
+				controller.showSearchForm(true)
+			case '?':
+				controller.showSearchForm(false)
+			case 'n':
+				err = controller.search(controller.lastSearch, true)
+			case 'N':
+				err = controller.search(controller.lastSearch, false)
+			}
+		}
+
+		if err != nil {
+			controller.message = err.Error()
+		}
+		return nil
+	})
+
+	controller.graphicElement = box
+	return controller, nil
+}
+
+func (c *ViewerController) Name() string {
+	return c.name
+}
+
+// SetDoneFunc sets the handler which is called when the user closes the viewer
+func (c *ViewerController) SetDoneFunc(handler func()) {
+	c.doneFunc = handler
+}
+
+// close releases the file and notifies the done handler
+func (c *ViewerController) close() {
+	if err := c.fv.Close(); err != nil {
+		log.WithError(err).Errorf("unable to close %s", c.fqfp)
+	}
+	if c.doneFunc != nil {
+		c.doneFunc()
+	}
+}
+
+// search looks for the pattern and reports the outcome on the status line
+func (c *ViewerController) search(pattern string, forward bool) error {
+	if pattern == "" {
+		return nil
+	}
+	c.lastSearch = pattern
+
+	err := c.fv.Search(pattern, forward)
+	if err == model.ErrNotFound {
+		return fmt.Errorf("%q: %w", pattern, err)
+	}
+	return err
+}
+
+// showSearchForm asks the user for the search pattern
+func (c *ViewerController) showSearchForm(forward bool) {
+	title := "Search forward"
+	if !forward {
+		title = "Search backward"
+	}
+
+	c.showInputForm("formViewerSearch", title, "Pattern:", c.lastSearch, func(text string) error {
+		c.fv.MatchOffset = -1
+		return c.search(text, forward)
+	})
+}
+
+// showGoToForm asks the user for the offset to jump to
+func (c *ViewerController) showGoToForm() {
+	c.showInputForm("formViewerGoTo", "Go to offset (dec, 0xhex, %)", "Offset:", "", func(text string) error {
+		offset, err := view.ParseOffset(text, c.fv.Buffer.Size())
+		if err != nil {
+			return err
+		}
+		return c.fv.GoTo(offset)
+	})
+}
+
+// showInputForm displays a modal form with a single input field on top of the viewer
+func (c *ViewerController) showInputForm(formId, title, label, value string, handler func(string) error) {
+	modalForm := tview.NewModal()
+	modalForm.SetBorder(true)
+	modalForm.SetTitle(title)
+	modalForm.SetTitleAlign(tview.AlignCenter)
+	modalForm.GetForm().AddInputField(label, value, 20, nil, nil)
+	modalForm.AddButtons([]string{"OK", "Cancel"})
+	modalForm.SetDoneFunc(func(buttonIndex int, buttonLabel string) {
+		if buttonLabel == "OK" {
+			text := modalForm.GetForm().GetFormItemByLabel(label).(*tview.InputField).GetText()
+			if err := handler(text); err != nil {
+				c.message = err.Error()
+			}
+		}
+
+		c.pages.HidePage(formId)
+		c.pages.RemovePage(formId)
+		c.tviewApp.SetFocus(c.graphicElement)
+	})
+
+	c.pages.AddPage(formId, modalForm, false, true)
+}
+
+// draw renders visible rows of the file and the status line into the box
+func (c *ViewerController) draw(screen tcell.Screen, x, y, width, height int) (int, int, int, int) {
+	// account for the border
+	x, y, width, height = x+1, y+1, width-2, height-2
+	if width <= 0 || height <= 1 {
+		return x, y, width, height
+	}
+
+	// the bottom row is reserved for the status line
+	c.width, c.height = width, height-1
+
+	rows, err := c.fv.Rows(c.width, c.height)
+	if err != nil {
+		log.WithError(err).Errorf("unable to read %s", c.fqfp)
+	}
+
+	defaultStyle := tcell.StyleDefault.Foreground(tcell.ColorWhite)
+	matchStyle := tcell.StyleDefault.Foreground(tcell.ColorBlack).Background(tcell.ColorYellow)
+	decorationStyle := tcell.StyleDefault.Foreground(tcell.ColorTeal)
+	for rowIdx, row := range rows {
+		for col, r := range row.Text {
+			if col >= c.width {
+				break
+			}
+
+			style := defaultStyle
+			if row.Offsets[col] < 0 {
+				style = decorationStyle
+			} else if c.fv.IsMatch(row.Offsets[col]) {
+				style = matchStyle
+			}
+			screen.SetContent(x+col, y+rowIdx, r, nil, style)
+		}
+	}
+
+	c.drawStatusLine(screen, x, y+height-1, width)
+	return x, y, width, height
+}
+
+// drawStatusLine renders the file name, position and active mode
+func (c *ViewerController) drawStatusLine(screen tcell.Screen, x, y, width int) {
+	size := c.fv.Buffer.Size()
+	percent := int64(100)
+	if size > 0 {
+		percent = c.fv.Offset * 100 / size
+	}
+
+	mode := "text"
+	if c.fv.Mode == view.HexMode {
+		mode = "hex"
+	} else if c.fv.Wrap {
+		mode = "text/wrap"
+	}
+
+	hint := "F4:hex F2:wrap F7:search n/N:next/prev F5:goto Esc:close"
+	if c.message != "" {
+		hint = c.message
+	}
+	status := fmt.Sprintf(" %s  %d/%d (%d%%)  [%s]  %s",
+		filepath.Base(c.fqfp), c.fv.Offset, size, percent, mode, hint)
+	style := tcell.StyleDefault.Foreground(tcell.ColorBlack).Background(tcell.ColorTeal)
+	for col := 0; col < width; col++ {
+		screen.SetContent(x+col, y, ' ', nil, style)
+	}
+	tview.PrintStyle(screen, []byte(tview.Escape(status)), x, y, width, tview.AlignLeft, style)
+}
+
+// Render flushes the state objects to the screen.
+func (c *ViewerController) Render() error {
+	log.Tracef("controller.Render() %s", c.Name())
+	return nil
+}
+
+// IsVisible indicates if the viewer is currently initialized
+func (c *ViewerController) IsVisible() bool {
+	return c != nil
+}
+
+// SetVisible is not used for the viewer: it is shown and hidden as a page
+func (c *ViewerController) SetVisible(visible bool) error {
+	return nil
+}
+
+// GraphicElement returns UI graphicElement used by tview framework to render the UI interface
+func (c *ViewerController) GraphicElement() GraphicElement {
+	return c.graphicElement
+}
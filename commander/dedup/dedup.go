@@ -0,0 +1,264 @@
+package dedup
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/mushkevych/9ofm/commander/fileops"
+	"github.com/mushkevych/9ofm/commander/model"
+)
+
+// Action removes the redundant copies of the duplicate files
+type Action string
+
+const (
+	Delete   Action = "delete"
+	Hardlink Action = "hardlink" // replaces the copies with the hard links to the kept file
+	Symlink  Action = "symlink"  // replaces the copies with the symbolic links to the kept file
+)
+
+// ErrChanged is reported for the files that have changed since the duplicates were found
+var ErrChanged = errors.New("changed since the duplicates were found")
+
+// ErrNotDuplicate is reported for the copy whose content differs from the kept file despite the equal hash,
+// such as the one found with the stale hash from the cache
+var ErrNotDuplicate = errors.New("content differs from the kept file")
+
+// File is a single copy of the duplicate content
+type File struct {
+	Path    string
+	ModTime time.Time
+}
+
+// Group lists the files of identical content
+type Group struct {
+	Size  int64
+	Hash  uint64
+	Files []File // ordered by the path
+	// index of the file kept by the Action; the rest of the files are the redundant copies
+	Keep int
+	// excluded groups are left intact by Execute
+	Excluded bool
+}
+
+// Reclaimable returns the number of bytes freed by removing the redundant copies
+func (g *Group) Reclaimable() int64 {
+	return g.Size * int64(len(g.Files)-1)
+}
+
+// Reclaimable returns the number of the redundant copies in the groups that are not excluded, and their total size
+func Reclaimable(groups []Group) (files int, bytes int64) {
+	for idx := range groups {
+		if !groups[idx].Excluded {
+			files += len(groups[idx].Files) - 1
+			bytes += groups[idx].Reclaimable()
+		}
+	}
+	return files, bytes
+}
+
+// Candidates returns the regular files below the PWD of the tree that have the same size as some other file,
+// and hence may have the same content. Empty files are not duplicates, and neither are hard links to the same file.
+func Candidates(tree *model.FileTreeModel) []*model.FileNode {
+	type fileId struct{ dev, inode uint64 }
+	bySize := make(map[int64][]*model.FileNode)
+	seen := make(map[fileId]bool)
+
+	root := tree.GetPwd()
+	_ = tree.DepthFirstSearch(func(node *model.FileNode) error {
+		info := &node.Data.FileInfo
+		if !info.Mode.IsRegular() || info.Size == 0 {
+			return nil
+		}
+		if dev, inode := info.FileId(); inode != 0 {
+			if seen[fileId{dev, inode}] {
+				return nil
+			}
+			seen[fileId{dev, inode}] = true
+		}
+		bySize[info.Size] = append(bySize[info.Size], node)
+		return nil
+	}, func(node *model.FileNode) bool {
+		return model.DepthBelow(root, node.AbsPath()) > 0
+	})
+
+	var candidates []*model.FileNode
+	for _, nodes := range bySize {
+		if len(nodes) > 1 {
+			candidates = append(candidates, nodes...)
+		}
+	}
+	return candidates
+}
+
+// stoppingMonitor remembers whether the monitor has stopped the hashing, which the hashing does not tell apart
+// from the read errors
+type stoppingMonitor struct {
+	fileops.Monitor
+	mutex sync.Mutex
+	err   error
+}
+
+func (m *stoppingMonitor) Checkpoint() error {
+	err := m.Monitor.Checkpoint()
+	if err != nil {
+		m.mutex.Lock()
+		m.err = err
+		m.mutex.Unlock()
+	}
+	return err
+}
+
+func (m *stoppingMonitor) stopped() bool {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return m.err != nil
+}
+
+// Find hashes the content of the candidates on a pool of at most workers goroutines, see model.HashFiles,
+// and groups the files of identical content; the groups freeing the most space go first.
+// The files that could not be hashed are left out, and their errors are returned along with the groups;
+// the error returned by the monitor's Checkpoint stops the search, and no groups are returned.
+func Find(candidates []*model.FileNode, workers int, monitor fileops.Monitor) ([]Group, error) {
+	if monitor == nil {
+		monitor = fileops.NopMonitor{}
+	}
+	stopping := &stoppingMonitor{Monitor: monitor}
+	err := model.HashFiles(candidates, workers, stopping)
+	if stopping.stopped() {
+		return nil, err
+	}
+
+	type key struct {
+		size int64
+		hash uint64
+	}
+	byContent := make(map[key]*Group)
+	for _, node := range candidates {
+		info := &node.Data.FileInfo
+		if !info.Hashed() {
+			continue
+		}
+
+		k := key{info.Size, info.Hash()}
+		group, ok := byContent[k]
+		if !ok {
+			group = &Group{Size: info.Size, Hash: info.Hash()}
+			byContent[k] = group
+		}
+		group.Files = append(group.Files, File{Path: node.AbsPath(), ModTime: info.ModTime})
+	}
+
+	var groups []Group
+	for _, group := range byContent {
+		if len(group.Files) < 2 {
+			continue
+		}
+		sort.Slice(group.Files, func(i, j int) bool {
+			return group.Files[i].Path < group.Files[j].Path
+		})
+		groups = append(groups, *group)
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		if groups[i].Reclaimable() != groups[j].Reclaimable() {
+			return groups[i].Reclaimable() > groups[j].Reclaimable()
+		}
+		return groups[i].Files[0].Path < groups[j].Files[0].Path
+	})
+	return groups, err
+}
+
+// Execute applies the action to the redundant copies of every group that is not excluded, keeping the file
+// selected by Group.Keep. The copy that has changed since the duplicates were found fails with ErrChanged,
+// and so do all the copies of the group whose kept file has changed. Every copy is compared with the kept file
+// byte by byte before it is removed, and the one that differs fails with ErrNotDuplicate.
+// The done function, if not nil, is called after every processed copy with its error.
+// Returns nil or *fileops.OperationErrors with an error for every copy that could not be processed;
+// the error returned by the monitor's Checkpoint stops the execution and is included in the errors.
+func Execute(groups []Group, action Action, monitor fileops.Monitor, done func(kept, path string, err error)) error {
+	if monitor == nil {
+		monitor = fileops.NopMonitor{}
+	}
+	errs := new(fileops.OperationErrors)
+
+	for _, group := range groups {
+		if group.Excluded {
+			continue
+		}
+		kept := group.Files[group.Keep]
+		keptErr := checkFile(kept, group.Size)
+
+		for idx, file := range group.Files {
+			if idx == group.Keep {
+				continue
+			}
+			if err := monitor.Checkpoint(); err != nil {
+				errs.Add(err)
+				return errs.ErrorOrNil()
+			}
+
+			err := keptErr
+			if err == nil {
+				err = checkFile(file, group.Size)
+			}
+			if err == nil {
+				err = checkContent(kept.Path, file.Path)
+			}
+			if err == nil {
+				err = apply(action, kept.Path, file.Path)
+			}
+			if err == nil {
+				monitor.BytesDone(group.Size)
+			}
+			monitor.FileDone()
+
+			if done != nil {
+				done(kept.Path, file.Path, err)
+			}
+			errs.Add(err)
+		}
+	}
+	return errs.ErrorOrNil()
+}
+
+// checkFile verifies that the file is still the regular file of the size and the modification time it was found with
+func checkFile(file File, size int64) error {
+	info, err := os.Lstat(file.Path)
+	if err != nil {
+		return err
+	}
+	if !info.Mode().IsRegular() || info.Size() != size || !info.ModTime().Equal(file.ModTime) {
+		return fmt.Errorf("%s: %w", file.Path, ErrChanged)
+	}
+	return nil
+}
+
+// checkContent verifies that the copy at the path has the same content as the kept file
+func checkContent(kept, path string) error {
+	equal, err := fileops.SameContent(kept, path)
+	if err != nil {
+		return err
+	}
+	if !equal {
+		return fmt.Errorf("%s: %w", path, ErrNotDuplicate)
+	}
+	return nil
+}
+
+// apply removes the copy at the path, or replaces it with the link to the kept file
+func apply(action Action, kept, path string) error {
+	switch action {
+	case Delete:
+		return os.Remove(path)
+	case Hardlink:
+		return fileops.ReplaceWithLink(kept, path, false)
+	case Symlink:
+		return fileops.ReplaceWithLink(kept, path, true)
+	default:
+		return fmt.Errorf("unknown action: %s", action)
+	}
+}
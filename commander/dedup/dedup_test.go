@@ -0,0 +1,187 @@
+package dedup
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/mushkevych/9ofm/commander/model"
+)
+
+// helperFixture writes the files into a temporary directory, and links "hardlink.txt" to "a.txt"
+func helperFixture(t *testing.T) string {
+	root := t.TempDir()
+
+	for name, content := range map[string]string{
+		"a.txt":            "same",
+		"sub/b.txt":        "same",
+		"sub/deeper/c.txt": "same",
+		"other.txt":        "diff",
+		"big/one.bin":      "larger content",
+		"big/two.bin":      "larger content",
+		"unique.txt":       "unique content",
+		"empty1":           "",
+		"empty2":           "",
+	} {
+		fqfp := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(fqfp), 0755); err != nil {
+			t.Fatalf("could not setup test: %v", err)
+		}
+		if err := ioutil.WriteFile(fqfp, []byte(content), 0644); err != nil {
+			t.Fatalf("could not setup test: %v", err)
+		}
+	}
+	if err := os.Link(filepath.Join(root, "a.txt"), filepath.Join(root, "hardlink.txt")); err != nil {
+		t.Fatalf("could not setup test: %v", err)
+	}
+	return root
+}
+
+func helperFind(t *testing.T, root string) []Group {
+	tree, err := model.ReadFileTreeWithOptions(root, model.ReadOptions{}, nil)
+	if err != nil {
+		t.Fatalf("could not read the tree: %v", err)
+	}
+	groups, err := Find(Candidates(tree), 2, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return groups
+}
+
+// describe lists the paths of every group relative to the root
+func describe(root string, groups []Group) [][]string {
+	var result [][]string
+	for _, group := range groups {
+		var paths []string
+		for _, file := range group.Files {
+			relative, _ := filepath.Rel(root, file.Path)
+			paths = append(paths, filepath.ToSlash(relative))
+		}
+		result = append(result, paths)
+	}
+	return result
+}
+
+func TestFind(t *testing.T) {
+	root := helperFixture(t)
+	groups := helperFind(t, root)
+
+	expected := [][]string{
+		{"big/one.bin", "big/two.bin"},
+		// the hard link is the same file as a.txt, whichever of them is found first
+		{"a.txt", "sub/b.txt", "sub/deeper/c.txt"},
+	}
+	actual := describe(root, groups)
+	if len(actual) == 2 && actual[1][0] == "hardlink.txt" {
+		actual[1][0] = "a.txt"
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("expected %v, got %v", expected, actual)
+	}
+
+	files, bytes := Reclaimable(groups)
+	if files != 3 || bytes != 14+2*4 {
+		t.Errorf("expected 3 files and 22 bytes to reclaim, got %d files and %d bytes", files, bytes)
+	}
+
+	groups[0].Excluded = true
+	if files, bytes = Reclaimable(groups); files != 2 || bytes != 8 {
+		t.Errorf("expected 2 files and 8 bytes to reclaim, got %d files and %d bytes", files, bytes)
+	}
+}
+
+func TestExecute(t *testing.T) {
+	for _, action := range []Action{Delete, Hardlink, Symlink} {
+		root := helperFixture(t)
+		groups := helperFind(t, root)
+		groups[0].Excluded = true
+		groups[1].Keep = 1
+		kept := groups[1].Files[1].Path
+
+		var processed []string
+		err := Execute(groups, action, nil, func(keptPath, path string, err error) {
+			if err != nil {
+				t.Errorf("%s: unexpected error for %s: %v", action, path, err)
+			}
+			if keptPath != kept {
+				t.Errorf("%s: expected %s to be kept, got %s", action, kept, keptPath)
+			}
+			processed = append(processed, path)
+		})
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", action, err)
+		}
+		if len(processed) != 2 {
+			t.Fatalf("%s: expected 2 copies processed, got %v", action, processed)
+		}
+
+		for _, path := range processed {
+			info, err := os.Lstat(path)
+			switch action {
+			case Delete:
+				if !os.IsNotExist(err) {
+					t.Errorf("expected %s to be deleted, got %v", path, err)
+				}
+			case Hardlink:
+				keptInfo, _ := os.Stat(kept)
+				if err != nil || !os.SameFile(info, keptInfo) {
+					t.Errorf("expected %s to be a hard link to %s", path, kept)
+				}
+			case Symlink:
+				if err != nil || info.Mode()&os.ModeSymlink == 0 {
+					t.Errorf("expected %s to be a symlink", path)
+				} else if content, _ := ioutil.ReadFile(path); string(content) != "same" {
+					t.Errorf("expected %s to point at the content of %s, got %q", path, kept, content)
+				}
+			}
+		}
+		if _, err = os.Stat(filepath.Join(root, "big", "two.bin")); err != nil {
+			t.Errorf("%s: expected the excluded group to be intact: %v", action, err)
+		}
+	}
+}
+
+func TestExecuteChanged(t *testing.T) {
+	root := helperFixture(t)
+	groups := helperFind(t, root)
+	changed := groups[0].Files[1].Path
+	if err := ioutil.WriteFile(changed, []byte("changed content"), 0644); err != nil {
+		t.Fatalf("could not change the file: %v", err)
+	}
+
+	err := Execute(groups[:1], Delete, nil, nil)
+	if !errors.Is(err, ErrChanged) {
+		t.Fatalf("expected ErrChanged, got %v", err)
+	}
+	if _, err = os.Stat(changed); err != nil {
+		t.Errorf("expected the changed file to be left intact: %v", err)
+	}
+}
+
+func TestExecuteNotDuplicate(t *testing.T) {
+	root := helperFixture(t)
+
+	// the files of equal size and equal hash, such as a stale one from the cache, but of different content
+	var files []File
+	for _, name := range []string{"a.txt", "other.txt"} {
+		fqfp := filepath.Join(root, name)
+		info, err := os.Lstat(fqfp)
+		if err != nil {
+			t.Fatalf("could not setup test: %v", err)
+		}
+		files = append(files, File{Path: fqfp, ModTime: info.ModTime()})
+	}
+	groups := []Group{{Size: 4, Hash: 1, Files: files}}
+
+	err := Execute(groups, Delete, nil, nil)
+	if !errors.Is(err, ErrNotDuplicate) {
+		t.Fatalf("expected ErrNotDuplicate, got %v", err)
+	}
+	if content, err := ioutil.ReadFile(files[1].Path); err != nil || string(content) != "diff" {
+		t.Errorf("expected the different file to be left intact, got %q, %v", content, err)
+	}
+}
@@ -0,0 +1,197 @@
+package fileops
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// ConflictPolicy defines what happens when the destination of a copy or move already exists
+type ConflictPolicy int
+
+const (
+	Overwrite ConflictPolicy = iota
+	Skip
+	Rename                 // keep both, the new file gets a numeric suffix
+	OverwriteIfNewer       // overwrite if the source was modified after the destination
+	OverwriteIfSizeDiffers // overwrite if the source and the destination sizes differ
+	Abort                  // stop the whole operation
+)
+
+// ErrAborted is returned when the operation was stopped as a result of the Abort policy
+var ErrAborted = errors.New("operation aborted")
+
+var conflictPolicyNames = map[ConflictPolicy]string{
+	Overwrite:              "overwrite",
+	Skip:                   "skip",
+	Rename:                 "rename",
+	OverwriteIfNewer:       "newer",
+	OverwriteIfSizeDiffers: "size",
+	Abort:                  "abort",
+}
+
+// String of a ConflictPolicy
+func (policy ConflictPolicy) String() string {
+	if name, ok := conflictPolicyNames[policy]; ok {
+		return name
+	}
+	return fmt.Sprintf("%d", int(policy))
+}
+
+// ParseConflictPolicy converts the policy name (as returned by ConflictPolicy.String) into the ConflictPolicy
+func ParseConflictPolicy(name string) (ConflictPolicy, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for policy, policyName := range conflictPolicyNames {
+		if policyName == name {
+			return policy, nil
+		}
+	}
+	return Abort, fmt.Errorf("unknown conflict policy: %s", name)
+}
+
+// Conflict describes the destination that already exists
+type Conflict struct {
+	Source          string
+	Destination     string
+	SourceInfo      os.FileInfo
+	DestinationInfo os.FileInfo
+}
+
+// ConflictResolver chooses the ConflictPolicy for a given conflict.
+// Resolve may block, for instance while waiting for the user's answer.
+type ConflictResolver interface {
+	Resolve(conflict Conflict) ConflictPolicy
+}
+
+// FixedPolicy resolves every conflict with the same policy. Used for the non-interactive runs.
+type FixedPolicy ConflictPolicy
+
+// Resolve returns the fixed policy
+func (policy FixedPolicy) Resolve(conflict Conflict) ConflictPolicy {
+	return ConflictPolicy(policy)
+}
+
+// PromptFunc asks the user about the conflict. Returns the chosen policy and
+// whether it should be applied to all the following conflicts of the same operation.
+type PromptFunc func(conflict Conflict) (policy ConflictPolicy, applyToAll bool)
+
+// PromptResolver resolves conflicts by asking the user, and remembers the "apply to all" answer
+type PromptResolver struct {
+	prompt PromptFunc
+
+	mutex      sync.Mutex
+	applyToAll bool
+	policy     ConflictPolicy
+}
+
+// NewPromptResolver creates a ConflictResolver that delegates the decision to the prompt function
+func NewPromptResolver(prompt PromptFunc) *PromptResolver {
+	return &PromptResolver{prompt: prompt}
+}
+
+// Resolve asks the user, unless an earlier answer was to be applied to all conflicts
+func (r *PromptResolver) Resolve(conflict Conflict) ConflictPolicy {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.applyToAll {
+		return r.policy
+	}
+
+	policy, applyToAll := r.prompt(conflict)
+	if applyToAll {
+		r.applyToAll = true
+		r.policy = policy
+	}
+	return policy
+}
+
+// conflictAction is the decision made for a specific conflict after the policy has been evaluated
+type conflictAction int
+
+const (
+	actionProceed conflictAction = iota
+	actionSkip
+	actionAbort
+	// the destination exists, and is replaced only once the new content is completely in place
+	actionReplace
+)
+
+// resolveConflict checks if the destination exists and, if so, applies the policy chosen by the resolver.
+// Returns the action and the destination to write to, which differs from the given one for the Rename policy.
+// The destination to be overwritten is left in place: it is up to the caller to replace it with actionReplace.
+func resolveConflict(resolver ConflictResolver, source, destination string, sourceInfo os.FileInfo) (conflictAction, string, error) {
+	destinationInfo, err := os.Lstat(destination)
+	if os.IsNotExist(err) {
+		return actionProceed, destination, nil
+	}
+	if err != nil {
+		return actionSkip, destination, err
+	}
+
+	if sourceInfo.IsDir() && destinationInfo.IsDir() {
+		// directories are merged
+		return actionProceed, destination, nil
+	}
+
+	conflict := Conflict{
+		Source:          source,
+		Destination:     destination,
+		SourceInfo:      sourceInfo,
+		DestinationInfo: destinationInfo,
+	}
+
+	policy := Overwrite
+	if resolver != nil {
+		policy = resolver.Resolve(conflict)
+	}
+
+	switch policy {
+	case Skip:
+		return actionSkip, destination, nil
+	case Abort:
+		return actionAbort, destination, ErrAborted
+	case Rename:
+		return actionProceed, uniqueName(destination), nil
+	case OverwriteIfNewer:
+		if !sourceInfo.ModTime().After(destinationInfo.ModTime()) {
+			return actionSkip, destination, nil
+		}
+	case OverwriteIfSizeDiffers:
+		if sourceInfo.Size() == destinationInfo.Size() {
+			return actionSkip, destination, nil
+		}
+	}
+
+	if destinationInfo.IsDir() {
+		return actionSkip, destination, &os.PathError{Op: "overwrite", Path: destination, Err: errors.New("destination is a directory")}
+	}
+	return actionReplace, destination, nil
+}
+
+// temporaryName returns the non-existing hidden path next to the given one, such as ".name.9ofm-part_1";
+// the kind tells what the temporary file is for
+func temporaryName(fqfp, kind string) string {
+	return uniqueName(filepath.Join(filepath.Dir(fqfp), fmt.Sprintf(".%s.9ofm-%s", filepath.Base(fqfp), kind)))
+}
+
+// uniqueName returns the first non-existing path in the form of "name_N.ext"
+func uniqueName(fqfp string) string {
+	dir, name := filepath.Split(fqfp)
+	ext := filepath.Ext(name)
+	if ext == name {
+		// dot-files, such as ".profile", have no extension
+		ext = ""
+	}
+	base := strings.TrimSuffix(name, ext)
+
+	for i := 1; ; i++ {
+		candidate := filepath.Join(dir, fmt.Sprintf("%s_%d%s", base, i, ext))
+		if _, err := os.Lstat(candidate); os.IsNotExist(err) {
+			return candidate
+		}
+	}
+}
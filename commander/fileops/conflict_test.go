@@ -0,0 +1,276 @@
+package fileops
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestParseConflictPolicy(t *testing.T) {
+	for policy := range conflictPolicyNames {
+		actual, err := ParseConflictPolicy(" " + policy.String() + " ")
+		if err != nil || actual != policy {
+			t.Errorf("expected %v, got %v (%v)", policy, actual, err)
+		}
+	}
+
+	if _, err := ParseConflictPolicy("ask"); err == nil {
+		t.Errorf("expected error for unknown policy")
+	}
+}
+
+func TestCopyConflictPolicies(t *testing.T) {
+	old := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	recent := time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	// format: <policy: {source content, source mtime, expected content of the target}>
+	fixture := []struct {
+		policy        ConflictPolicy
+		sourceContent string
+		sourceMtime   time.Time
+		expected      string
+	}{
+		{Overwrite, "new", old, "new"},
+		{Skip, "new", recent, "old"},
+		{OverwriteIfNewer, "new", recent, "new"},
+		{OverwriteIfNewer, "new", old, "old"},
+		{OverwriteIfSizeDiffers, "newer", old, "newer"},
+		{OverwriteIfSizeDiffers, "new", recent, "old"},
+	}
+
+	for _, testCase := range fixture {
+		root := t.TempDir()
+		source := filepath.Join(root, "source.txt")
+		target := filepath.Join(root, "target.txt")
+		helperWriteFile(t, source, testCase.sourceContent, 0644)
+		helperWriteFile(t, target, "old", 0644)
+		_ = os.Chtimes(source, testCase.sourceMtime, testCase.sourceMtime)
+		middle := time.Date(2020, 3, 1, 0, 0, 0, 0, time.UTC)
+		_ = os.Chtimes(target, middle, middle)
+
+		options := DefaultCopyOptions()
+		options.Conflicts = FixedPolicy(testCase.policy)
+		copier := NewCopier(options)
+		if err := copier.Copy(source, target); err != nil {
+			t.Fatalf("%v: unexpected error: %v", testCase.policy, err)
+		}
+		helperAssertContent(t, target, testCase.expected)
+
+		// the skipped file is not reported as copied
+		expectedCopiedTo := target
+		if testCase.expected == "old" {
+			expectedCopiedTo = ""
+		}
+		if copiedTo := copier.CopiedTo(source); copiedTo != expectedCopiedTo {
+			t.Errorf("%v: expected the source to be copied to %q, got %q", testCase.policy, expectedCopiedTo, copiedTo)
+		}
+	}
+}
+
+func TestCopyConflictRename(t *testing.T) {
+	root := t.TempDir()
+	helperWriteFile(t, filepath.Join(root, "source", "a.txt"), "new", 0644)
+	helperWriteFile(t, filepath.Join(root, "target", "a.txt"), "old", 0644)
+	helperWriteFile(t, filepath.Join(root, "target", "a_1.txt"), "older", 0644)
+
+	options := DefaultCopyOptions()
+	options.Conflicts = FixedPolicy(Rename)
+	copier := NewCopier(options)
+	if err := copier.Copy(filepath.Join(root, "source"), filepath.Join(root, "target")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if copiedTo := copier.CopiedTo(filepath.Join(root, "source", "a.txt")); copiedTo != filepath.Join(root, "target", "a_2.txt") {
+		t.Errorf("expected the renamed destination, got %q", copiedTo)
+	}
+
+	helperAssertContent(t, filepath.Join(root, "target", "a.txt"), "old")
+	helperAssertContent(t, filepath.Join(root, "target", "a_1.txt"), "older")
+	helperAssertContent(t, filepath.Join(root, "target", "a_2.txt"), "new")
+}
+
+func TestCopyConflictAbort(t *testing.T) {
+	root := t.TempDir()
+	helperWriteFile(t, filepath.Join(root, "source", "a.txt"), "new", 0644)
+	helperWriteFile(t, filepath.Join(root, "source", "b.txt"), "new", 0644)
+	helperWriteFile(t, filepath.Join(root, "target", "a.txt"), "old", 0644)
+
+	options := DefaultCopyOptions()
+	options.Conflicts = FixedPolicy(Abort)
+	err := NewCopier(options).Copy(filepath.Join(root, "source"), filepath.Join(root, "target"))
+	if !errors.Is(err, ErrAborted) {
+		t.Fatalf("expected ErrAborted, got %v", err)
+	}
+	if _, err = os.Stat(filepath.Join(root, "target", "b.txt")); !os.IsNotExist(err) {
+		t.Errorf("expected the copy to stop at the first conflict")
+	}
+}
+
+func TestPromptResolverApplyToAll(t *testing.T) {
+	prompts := 0
+	resolver := NewPromptResolver(func(conflict Conflict) (ConflictPolicy, bool) {
+		prompts++
+		return Skip, prompts == 2
+	})
+
+	for i := 0; i < 5; i++ {
+		if policy := resolver.Resolve(Conflict{}); policy != Skip {
+			t.Errorf("expected Skip, got %v", policy)
+		}
+	}
+	if prompts != 2 {
+		t.Errorf("expected the user to be asked twice, got %d", prompts)
+	}
+}
+
+func TestMoveConflict(t *testing.T) {
+	root := t.TempDir()
+	source := filepath.Join(root, "source.txt")
+	target := filepath.Join(root, "target.txt")
+	helperWriteFile(t, source, "new", 0644)
+	helperWriteFile(t, target, "old", 0644)
+
+	if _, err := Move(source, target, FixedPolicy(Skip)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	helperAssertContent(t, source, "new")
+	helperAssertContent(t, target, "old")
+
+	if _, err := Move(source, target, FixedPolicy(Overwrite)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	helperAssertContent(t, target, "new")
+	if _, err := os.Stat(source); !os.IsNotExist(err) {
+		t.Errorf("expected source to be moved")
+	}
+}
+
+func TestMoveReturnsDestination(t *testing.T) {
+	root := t.TempDir()
+	source := filepath.Join(root, "a.txt")
+	target := filepath.Join(root, "b.txt")
+	helperWriteFile(t, source, "new", 0644)
+	helperWriteFile(t, target, "old", 0644)
+
+	destination, err := Move(source, target, FixedPolicy(Rename))
+	if err != nil || destination != filepath.Join(root, "b_1.txt") {
+		t.Fatalf("expected b_1.txt, got %s (%v)", destination, err)
+	}
+	helperAssertContent(t, destination, "new")
+
+	helperWriteFile(t, source, "new", 0644)
+	if destination, err = Move(source, target, FixedPolicy(Skip)); err != nil || destination != "" {
+		t.Errorf("expected the skipped move to have no destination, got %s (%v)", destination, err)
+	}
+}
+
+// stopAtCheckpoint is a Monitor that stops the operation at the given Checkpoint call
+type stopAtCheckpoint struct {
+	checkpoints int
+}
+
+func (m *stopAtCheckpoint) Checkpoint() error {
+	if m.checkpoints--; m.checkpoints <= 0 {
+		return errStopped
+	}
+	return nil
+}
+func (m *stopAtCheckpoint) BytesDone(n int64) {}
+func (m *stopAtCheckpoint) FileDone()         {}
+
+func TestCopyOverwriteKeepsDestinationUntilComplete(t *testing.T) {
+	root := t.TempDir()
+	source := filepath.Join(root, "source.txt")
+	target := filepath.Join(root, "target.txt")
+	helperWriteFile(t, source, "new", 0644)
+	helperWriteFile(t, target, "old", 0644)
+
+	// the first Checkpoint is passed by the walk, the second one stops the copy of the content
+	options := DefaultCopyOptions()
+	options.Conflicts = FixedPolicy(Overwrite)
+	options.Monitor = &stopAtCheckpoint{checkpoints: 2}
+	if err := NewCopier(options).Copy(source, target); !errors.Is(err, errStopped) {
+		t.Fatalf("expected the copy to be stopped, got %v", err)
+	}
+	helperAssertContent(t, target, "old")
+
+	content, _ := ioutil.ReadDir(root)
+	if len(content) != 2 {
+		t.Errorf("expected the temporary copy to be removed, found %d files", len(content))
+	}
+}
+
+func TestCopyOverwriteFileWithDirectory(t *testing.T) {
+	root := t.TempDir()
+	source := filepath.Join(root, "source")
+	target := filepath.Join(root, "target")
+	helperWriteFile(t, filepath.Join(source, "dir", "a.txt"), "alpha", 0644)
+	helperWriteFile(t, filepath.Join(target, "dir"), "file", 0644)
+
+	options := DefaultCopyOptions()
+	options.Conflicts = FixedPolicy(Overwrite)
+	if err := NewCopier(options).Copy(source, target); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	helperAssertContent(t, filepath.Join(target, "dir", "a.txt"), "alpha")
+
+	content, _ := ioutil.ReadDir(target)
+	if len(content) != 1 {
+		t.Errorf("expected the replaced file to be removed, found %d files", len(content))
+	}
+}
+
+func TestMoveMergesDirectories(t *testing.T) {
+	root := t.TempDir()
+	source := filepath.Join(root, "source", "dir")
+	target := filepath.Join(root, "target", "dir")
+	helperWriteFile(t, filepath.Join(source, "a.txt"), "new", 0644)
+	helperWriteFile(t, filepath.Join(source, "sub", "b.txt"), "beta", 0644)
+	helperWriteFile(t, filepath.Join(source, "skipped.txt"), "new", 0644)
+	helperWriteFile(t, filepath.Join(target, "a.txt"), "old", 0644)
+	helperWriteFile(t, filepath.Join(target, "sub", "kept.txt"), "kept", 0644)
+	helperWriteFile(t, filepath.Join(target, "skipped.txt"), "old", 0644)
+
+	resolver := NewPromptResolver(func(conflict Conflict) (ConflictPolicy, bool) {
+		if filepath.Base(conflict.Source) == "skipped.txt" {
+			return Skip, false
+		}
+		return Overwrite, false
+	})
+	destination, err := Move(source, target, resolver)
+	if err != nil || destination != target {
+		t.Fatalf("expected the move into %s, got %s (%v)", target, destination, err)
+	}
+
+	helperAssertContent(t, filepath.Join(target, "a.txt"), "new")
+	helperAssertContent(t, filepath.Join(target, "sub", "b.txt"), "beta")
+	helperAssertContent(t, filepath.Join(target, "sub", "kept.txt"), "kept")
+	helperAssertContent(t, filepath.Join(target, "skipped.txt"), "old")
+
+	// the skipped file keeps its directory in place, the rest of the source is gone
+	helperAssertContent(t, filepath.Join(source, "skipped.txt"), "new")
+	content, _ := ioutil.ReadDir(source)
+	if len(content) != 1 {
+		t.Errorf("expected only the skipped file to stay in the source, found %d entries", len(content))
+	}
+}
+
+func TestMoveDirectoryOverFile(t *testing.T) {
+	root := t.TempDir()
+	source := filepath.Join(root, "dir")
+	target := filepath.Join(root, "file")
+	helperWriteFile(t, filepath.Join(source, "a.txt"), "alpha", 0644)
+	helperWriteFile(t, target, "file", 0644)
+
+	if _, err := Move(source, target, FixedPolicy(Overwrite)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	helperAssertContent(t, filepath.Join(target, "a.txt"), "alpha")
+
+	content, _ := ioutil.ReadDir(root)
+	if len(content) != 1 {
+		t.Errorf("expected the replaced file to be removed, found %d entries", len(content))
+	}
+}
@@ -0,0 +1,418 @@
+package fileops
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// size of the buffer used to stream the content of a single file
+const defaultBufferSize = 128 * 1024
+
+// CopyOptions defines which file properties are carried over by the Copier
+type CopyOptions struct {
+	PreserveMode  bool // permission bits, including setuid, setgid and sticky
+	PreserveTimes bool // modification and access time
+	PreserveOwner bool // uid and gid; silently skipped when the process lacks privileges
+	PreserveLinks bool // hard links within the copied tree are recreated as hard links
+	Sparse        bool // holes in sparse source files are kept as holes in the destination
+	BufferSize    int
+
+	// decides what to do with the already existing destination files; nil means Overwrite
+	Conflicts ConflictResolver
+
+	// observes the progress and may pause or stop the copy; nil means no monitoring
+	Monitor Monitor
+}
+
+// DefaultCopyOptions returns the options that preserve as much of the source as possible
+func DefaultCopyOptions() CopyOptions {
+	return CopyOptions{
+		PreserveMode:  true,
+		PreserveTimes: true,
+		PreserveOwner: true,
+		PreserveLinks: true,
+		Sparse:        true,
+		BufferSize:    defaultBufferSize,
+	}
+}
+
+// inodeKey uniquely identifies a file on the host, and is used to detect hard links
+type inodeKey struct {
+	dev uint64
+	ino uint64
+}
+
+// Copier copies files and directory trees, collecting per-file errors instead of stopping at the first one
+type Copier struct {
+	options CopyOptions
+	monitor Monitor
+	buffer  []byte
+
+	// error returned by the Monitor.Checkpoint, which stops the copy
+	stopped error
+
+	// source inode -> first destination path it was copied to
+	hardLinks map[inodeKey]string
+
+	// directories whose metadata is applied once their content has been copied
+	pendingDirs []pendingDir
+
+	// source directory -> destination directory; differs from the plain join when a directory was renamed
+	dirDestinations map[string]string
+
+	// source -> destination of every entry copied by the last Copy call
+	copied map[string]string
+
+	// files set aside by the last Copy call to be replaced with directories; removed once the copy succeeds
+	replaced []string
+}
+
+type pendingDir struct {
+	destination string
+	info        os.FileInfo
+}
+
+// NewCopier creates a new Copier with the given options
+func NewCopier(options CopyOptions) *Copier {
+	if options.BufferSize <= 0 {
+		options.BufferSize = defaultBufferSize
+	}
+
+	var monitor Monitor = NopMonitor{}
+	if options.Monitor != nil {
+		monitor = options.Monitor
+	}
+
+	return &Copier{
+		options:         options,
+		monitor:         monitor,
+		buffer:          make([]byte, options.BufferSize),
+		hardLinks:       make(map[inodeKey]string),
+		dirDestinations: make(map[string]string),
+	}
+}
+
+// Copy recursively copies source to destination with DefaultCopyOptions.
+// See Copier.Copy for details.
+func Copy(source, destination string) error {
+	return NewCopier(DefaultCopyOptions()).Copy(source, destination)
+}
+
+// Copy recursively copies source to destination, so that the destination becomes a replica of the source.
+// Symbolic links are copied as links and never followed.
+// Existing destination files are handled according to the CopyOptions.Conflicts resolver.
+// Returns nil or *OperationErrors with an error for every file that could not be copied;
+// if the operation was aborted by the resolver, the errors include ErrAborted.
+func (c *Copier) Copy(source, destination string) error {
+	errs := new(OperationErrors)
+
+	source = filepath.Clean(source)
+	destination = filepath.Clean(destination)
+	if isWithin(destination, source) {
+		errs.Add(fmt.Errorf("cannot copy %s into itself: %s", source, destination))
+		return errs.ErrorOrNil()
+	}
+
+	c.pendingDirs = c.pendingDirs[:0]
+	c.dirDestinations = make(map[string]string)
+	c.copied = make(map[string]string)
+	c.replaced = nil
+	c.stopped = nil
+	err := filepath.Walk(source, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			errs.Add(err)
+			if info != nil && info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if c.stopped = c.monitor.Checkpoint(); c.stopped != nil {
+			return c.stopped
+		}
+
+		target := destination
+		if path != source {
+			target = filepath.Join(c.dirDestinations[filepath.Dir(path)], info.Name())
+		}
+
+		action, target, err := resolveConflict(c.options.Conflicts, path, target, info)
+		if action == actionAbort {
+			return err
+		}
+		if action == actionSkip {
+			errs.Add(err)
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			c.fileDone(info)
+			return nil
+		}
+
+		if action == actionReplace {
+			err = c.replaceEntry(path, target, info)
+		} else {
+			err = c.copyEntry(path, target, info)
+		}
+		if c.stopped != nil {
+			return c.stopped
+		}
+		if err != nil {
+			errs.Add(err)
+			if info.IsDir() {
+				// there is no place to copy the content of this directory to
+				return filepath.SkipDir
+			}
+		} else {
+			c.copied[path] = target
+			c.rememberLink(info, target)
+		}
+		if !info.IsDir() {
+			c.monitor.FileDone()
+		}
+
+		if info.IsDir() {
+			c.dirDestinations[path] = target
+		}
+		return nil
+	})
+	errs.Add(err)
+
+	// apply directory metadata bottom-up, so that copying the content does not alter the mtime
+	for i := len(c.pendingDirs) - 1; i >= 0; i-- {
+		pending := c.pendingDirs[i]
+		errs.Add(c.applyMetadata(pending.destination, pending.info))
+	}
+	c.pendingDirs = c.pendingDirs[:0]
+
+	for _, aside := range c.replaced {
+		if errs.ErrorOrNil() == nil {
+			errs.Add(os.Remove(aside))
+		} else {
+			errs.Add(fmt.Errorf("copy is incomplete, the replaced file is kept as %s", aside))
+		}
+	}
+
+	return errs.ErrorOrNil()
+}
+
+// CopiedTo returns the path the source, or an entry within it, was copied to by the last Copy call.
+// Returns "" if it was not copied: it was skipped, could not be copied or the copy was stopped before it.
+func (c *Copier) CopiedTo(source string) string {
+	return c.copied[filepath.Clean(source)]
+}
+
+// replaceEntry copies the entry under a temporary name next to the existing destination, and renames it over
+// the destination once the copy is complete, so that a failed or stopped copy leaves the destination intact.
+// A directory can not be renamed over a file: the file is set aside instead, and removed once the whole copy succeeds.
+func (c *Copier) replaceEntry(source, destination string, info os.FileInfo) error {
+	if info.IsDir() {
+		aside := temporaryName(destination, "replaced")
+		if err := os.Rename(destination, aside); err != nil {
+			return err
+		}
+		if err := c.copyEntry(source, destination, info); err != nil {
+			_ = os.Rename(aside, destination)
+			return err
+		}
+		c.replaced = append(c.replaced, aside)
+		return nil
+	}
+
+	temporary := temporaryName(destination, "part")
+	err := c.copyEntry(source, temporary, info)
+	if err == nil {
+		err = os.Rename(temporary, destination)
+	}
+	if err != nil {
+		_ = os.Remove(temporary)
+	}
+	return err
+}
+
+// copyEntry copies a single file system entry, without descending into directories
+func (c *Copier) copyEntry(source, destination string, info os.FileInfo) error {
+	mode := info.Mode()
+	switch {
+	case mode.IsDir():
+		// keep the directory writable until its content is copied
+		err := os.Mkdir(destination, mode.Perm()|0700)
+		if err != nil && !(os.IsExist(err) && isDir(destination)) {
+			return err
+		}
+		c.pendingDirs = append(c.pendingDirs, pendingDir{destination: destination, info: info})
+		return nil
+
+	case mode&os.ModeSymlink != 0:
+		target, err := os.Readlink(source)
+		if err != nil {
+			return err
+		}
+		if err = os.Symlink(target, destination); err != nil {
+			return err
+		}
+		return c.applyOwner(destination, statOf(info))
+
+	case mode.IsRegular():
+		stat := statOf(info)
+		if c.options.PreserveLinks && stat.nlink > 1 {
+			if linkedDestination, ok := c.hardLinks[inodeKey{dev: stat.dev, ino: stat.ino}]; ok {
+				return os.Link(linkedDestination, destination)
+			}
+		}
+
+		if err := c.copyFile(source, destination, info); err != nil {
+			return err
+		}
+		return c.applyMetadata(destination, info)
+
+	default:
+		return &os.PathError{Op: "copy", Path: source, Err: fmt.Errorf("unsupported file type %v", mode.Type())}
+	}
+}
+
+// rememberLink records the copy of the regular file with several hard links, so that the following links
+// to the same inode are linked to it; only the complete copy at its final destination is recorded
+func (c *Copier) rememberLink(info os.FileInfo, destination string) {
+	stat := statOf(info)
+	if !c.options.PreserveLinks || !info.Mode().IsRegular() || stat.nlink < 2 {
+		return
+	}
+	key := inodeKey{dev: stat.dev, ino: stat.ino}
+	if _, ok := c.hardLinks[key]; !ok {
+		c.hardLinks[key] = destination
+	}
+}
+
+// copyFile streams the content of the regular file
+func (c *Copier) copyFile(source, destination string, info os.FileInfo) error {
+	sourceFile, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	defer sourceFile.Close()
+
+	destinationFile, err := os.OpenFile(destination, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, info.Mode().Perm()|0200)
+	if err != nil {
+		return err
+	}
+
+	err = c.copyContent(sourceFile, destinationFile, info.Size(), c.options.Sparse && statOf(info).sparse)
+	if closeErr := destinationFile.Close(); err == nil {
+		err = closeErr
+	}
+	if c.stopped != nil {
+		// do not leave partially copied file behind
+		_ = os.Remove(destination)
+		return c.stopped
+	}
+	if err != nil {
+		return &os.PathError{Op: "copy", Path: source, Err: err}
+	}
+	return nil
+}
+
+// copyContent streams the file content through the buffer, reporting the progress to the monitor.
+// If sparse is true, blocks of zeros are skipped over instead of being written, leaving holes in the destination.
+func (c *Copier) copyContent(source io.Reader, destination *os.File, size int64, sparse bool) error {
+	for {
+		if c.stopped = c.monitor.Checkpoint(); c.stopped != nil {
+			return c.stopped
+		}
+
+		n, err := source.Read(c.buffer)
+		if n > 0 {
+			var writeErr error
+			if sparse && isZero(c.buffer[:n]) {
+				_, writeErr = destination.Seek(int64(n), io.SeekCurrent)
+			} else {
+				_, writeErr = destination.Write(c.buffer[:n])
+			}
+			if writeErr != nil {
+				return writeErr
+			}
+			c.monitor.BytesDone(int64(n))
+		}
+
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if sparse {
+		// trailing hole is not materialized by seeking alone
+		return destination.Truncate(size)
+	}
+	return nil
+}
+
+// fileDone reports the file, which was not copied, as processed
+func (c *Copier) fileDone(info os.FileInfo) {
+	if info.Mode().IsRegular() {
+		c.monitor.BytesDone(info.Size())
+	}
+	c.monitor.FileDone()
+}
+
+// applyMetadata carries over owner, permissions and times according to the options
+func (c *Copier) applyMetadata(destination string, info os.FileInfo) error {
+	stat := statOf(info)
+	if err := c.applyOwner(destination, stat); err != nil {
+		return err
+	}
+
+	mode := info.Mode()
+	if c.options.PreserveMode {
+		// chmod goes after chown, since the latter clears setuid and setgid bits
+		err := os.Chmod(destination, mode&(os.ModePerm|os.ModeSetuid|os.ModeSetgid|os.ModeSticky))
+		if err != nil {
+			return err
+		}
+	}
+
+	if c.options.PreserveTimes {
+		return os.Chtimes(destination, stat.atime, info.ModTime())
+	}
+	return nil
+}
+
+// applyOwner carries over uid and gid; lack of privileges is not considered an error
+func (c *Copier) applyOwner(destination string, stat fileStat) error {
+	if !c.options.PreserveOwner || stat.uid < 0 || stat.gid < 0 {
+		return nil
+	}
+
+	err := os.Lchown(destination, stat.uid, stat.gid)
+	if err != nil && isPermissionError(err) {
+		return nil
+	}
+	return err
+}
+
+// isWithin returns true if the path is the parent itself or is located inside of the parent directory
+func isWithin(path, parent string) bool {
+	if path == parent {
+		return true
+	}
+	return strings.HasPrefix(path, strings.TrimSuffix(parent, string(os.PathSeparator))+string(os.PathSeparator))
+}
+
+func isDir(path string) bool {
+	info, err := os.Lstat(path)
+	return err == nil && info.IsDir()
+}
+
+func isZero(data []byte) bool {
+	for _, b := range data {
+		if b != 0 {
+			return false
+		}
+	}
+	return true
+}
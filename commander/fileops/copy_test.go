@@ -0,0 +1,204 @@
+package fileops
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func helperWriteFile(t *testing.T, fqfp, content string, mode os.FileMode) {
+	if err := os.MkdirAll(filepath.Dir(fqfp), 0755); err != nil {
+		t.Fatalf("unable to create dir: %v", err)
+	}
+	if err := ioutil.WriteFile(fqfp, []byte(content), mode); err != nil {
+		t.Fatalf("unable to write file: %v", err)
+	}
+	if err := os.Chmod(fqfp, mode); err != nil {
+		t.Fatalf("unable to chmod file: %v", err)
+	}
+}
+
+func helperAssertContent(t *testing.T, fqfp, expected string) {
+	actual, err := ioutil.ReadFile(fqfp)
+	if err != nil {
+		t.Fatalf("unable to read %s: %v", fqfp, err)
+	}
+	if string(actual) != expected {
+		t.Errorf("%s: expected %q, got %q", fqfp, expected, actual)
+	}
+}
+
+func TestCopyTree(t *testing.T) {
+	root := t.TempDir()
+	source := filepath.Join(root, "source")
+	destination := filepath.Join(root, "destination")
+
+	helperWriteFile(t, filepath.Join(source, "a.txt"), "alpha", 0640)
+	helperWriteFile(t, filepath.Join(source, "nested", "deeper", "b.sh"), "#!/bin/sh", 0755)
+	if err := os.Symlink("nested/deeper/b.sh", filepath.Join(source, "link")); err != nil {
+		t.Fatalf("unable to create symlink: %v", err)
+	}
+	if err := os.Link(filepath.Join(source, "a.txt"), filepath.Join(source, "nested", "hard.txt")); err != nil {
+		t.Fatalf("unable to create hard link: %v", err)
+	}
+
+	mtime := time.Date(2020, 11, 30, 12, 0, 0, 0, time.UTC)
+	if err := os.Chtimes(filepath.Join(source, "a.txt"), mtime, mtime); err != nil {
+		t.Fatalf("unable to set times: %v", err)
+	}
+	if err := os.Chtimes(filepath.Join(source, "nested"), mtime, mtime); err != nil {
+		t.Fatalf("unable to set times: %v", err)
+	}
+
+	if err := Copy(source, destination); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	helperAssertContent(t, filepath.Join(destination, "a.txt"), "alpha")
+	helperAssertContent(t, filepath.Join(destination, "nested", "deeper", "b.sh"), "#!/bin/sh")
+
+	info, err := os.Stat(filepath.Join(destination, "a.txt"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if info.Mode().Perm() != 0640 {
+		t.Errorf("expected mode 0640, got %v", info.Mode().Perm())
+	}
+	if !info.ModTime().Equal(mtime) {
+		t.Errorf("expected mtime %v, got %v", mtime, info.ModTime())
+	}
+
+	info, err = os.Stat(filepath.Join(destination, "nested"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !info.ModTime().Equal(mtime) {
+		t.Errorf("expected directory mtime %v, got %v", mtime, info.ModTime())
+	}
+
+	target, err := os.Readlink(filepath.Join(destination, "link"))
+	if err != nil || target != "nested/deeper/b.sh" {
+		t.Errorf("expected symlink to nested/deeper/b.sh, got %q (%v)", target, err)
+	}
+
+	first, _ := os.Stat(filepath.Join(destination, "a.txt"))
+	second, _ := os.Stat(filepath.Join(destination, "nested", "hard.txt"))
+	if !os.SameFile(first, second) {
+		t.Errorf("expected hard link to be preserved")
+	}
+}
+
+func TestCopySingleFile(t *testing.T) {
+	root := t.TempDir()
+	helperWriteFile(t, filepath.Join(root, "source.txt"), "content", 0600)
+
+	if err := Copy(filepath.Join(root, "source.txt"), filepath.Join(root, "copy.txt")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	helperAssertContent(t, filepath.Join(root, "copy.txt"), "content")
+}
+
+func TestCopySparseFile(t *testing.T) {
+	root := t.TempDir()
+	source := filepath.Join(root, "sparse")
+
+	file, err := os.Create(source)
+	if err != nil {
+		t.Fatalf("unable to create file: %v", err)
+	}
+	_, _ = file.WriteAt([]byte("head"), 0)
+	_, _ = file.WriteAt([]byte("tail"), 4*defaultBufferSize)
+	_ = file.Truncate(8 * defaultBufferSize)
+	_ = file.Close()
+
+	if err := Copy(source, filepath.Join(root, "copy")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected, _ := ioutil.ReadFile(source)
+	actual, _ := ioutil.ReadFile(filepath.Join(root, "copy"))
+	if string(expected) != string(actual) {
+		t.Errorf("sparse file content mismatch")
+	}
+}
+
+func TestCopyIntoItself(t *testing.T) {
+	root := t.TempDir()
+	helperWriteFile(t, filepath.Join(root, "source", "a.txt"), "alpha", 0644)
+
+	err := Copy(filepath.Join(root, "source"), filepath.Join(root, "source", "inner"))
+	if err == nil {
+		t.Fatalf("expected error when copying directory into itself")
+	}
+	if _, ok := err.(*OperationErrors); !ok {
+		t.Errorf("expected *OperationErrors, got %T", err)
+	}
+}
+
+func TestCopyCollectsPerFileErrors(t *testing.T) {
+	root := t.TempDir()
+	source := filepath.Join(root, "source")
+	destination := filepath.Join(root, "destination")
+	helperWriteFile(t, filepath.Join(source, "a.txt"), "alpha", 0644)
+	helperWriteFile(t, filepath.Join(source, "b.txt"), "beta", 0644)
+
+	// a directory in place of a file makes the copy of a.txt fail, while b.txt is still copied
+	if err := os.MkdirAll(filepath.Join(destination, "a.txt"), 0755); err != nil {
+		t.Fatalf("unable to create dir: %v", err)
+	}
+
+	err := Copy(source, destination)
+	errs, ok := err.(*OperationErrors)
+	if !ok || len(errs.Errors) != 1 {
+		t.Fatalf("expected a single per-file error, got %v", err)
+	}
+	helperAssertContent(t, filepath.Join(destination, "b.txt"), "beta")
+}
+
+// stopAfter is a Monitor that stops the copy once the given number of files has been copied
+type stopAfter struct {
+	files int
+	bytes int64
+}
+
+var errStopped = errors.New("stopped")
+
+func (m *stopAfter) Checkpoint() error {
+	if m.files <= 0 {
+		return errStopped
+	}
+	return nil
+}
+func (m *stopAfter) BytesDone(n int64) { m.bytes += n }
+func (m *stopAfter) FileDone()         { m.files-- }
+
+func TestCopyMonitor(t *testing.T) {
+	root := t.TempDir()
+	source := filepath.Join(root, "source")
+	destination := filepath.Join(root, "destination")
+	helperWriteFile(t, filepath.Join(source, "a.txt"), "alpha", 0644)
+	helperWriteFile(t, filepath.Join(source, "b.txt"), "beta", 0644)
+
+	files, bytes := Measure(source)
+	if files != 2 || bytes != 9 {
+		t.Errorf("expected 2 files of 9 bytes, got %d files of %d bytes", files, bytes)
+	}
+
+	monitor := &stopAfter{files: 1}
+	options := DefaultCopyOptions()
+	options.Monitor = monitor
+	err := NewCopier(options).Copy(source, destination)
+	if !errors.Is(err, errStopped) {
+		t.Fatalf("expected the copy to be stopped, got %v", err)
+	}
+	if monitor.bytes != 5 {
+		t.Errorf("expected 5 bytes reported, got %d", monitor.bytes)
+	}
+	helperAssertContent(t, filepath.Join(destination, "a.txt"), "alpha")
+	if _, err := os.Stat(filepath.Join(destination, "b.txt")); !os.IsNotExist(err) {
+		t.Errorf("expected b.txt not to be copied, got %v", err)
+	}
+}
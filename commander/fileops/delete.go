@@ -0,0 +1,105 @@
+package fileops
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// Summary describes the content of one or more file trees
+type Summary struct {
+	Files int64 // anything but directories
+	Dirs  int64
+	Bytes int64 // total size of the regular files
+}
+
+// Summarize walks the given trees and counts their files, directories and bytes.
+// Symbolic links are not followed. Unreadable entries are ignored.
+func Summarize(paths ...string) Summary {
+	var summary Summary
+	for _, path := range paths {
+		_ = filepath.Walk(path, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return nil
+			}
+
+			switch {
+			case info.IsDir():
+				summary.Dirs++
+			case info.Mode().IsRegular():
+				summary.Files++
+				summary.Bytes += info.Size()
+			default:
+				summary.Files++
+			}
+			return nil
+		})
+	}
+	return summary
+}
+
+// Delete recursively removes the path. Symbolic links are removed, never followed.
+// Deletion carries on after a failed entry; directories that could not be emptied are left in place.
+// Returns nil or *OperationErrors with an error for every entry that could not be removed;
+// the error returned by the monitor's Checkpoint stops the deletion and is included in the errors.
+func Delete(path string, monitor Monitor) error {
+	if monitor == nil {
+		monitor = NopMonitor{}
+	}
+	errs := new(OperationErrors)
+
+	type entry struct {
+		path string
+		info os.FileInfo
+	}
+	var entries []entry
+	err := filepath.Walk(path, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			errs.Add(err)
+			return nil
+		}
+		entries = append(entries, entry{path: path, info: info})
+		return nil
+	})
+	errs.Add(err)
+
+	// directories whose content could not be removed entirely
+	incomplete := make(map[string]bool)
+	markIncomplete := func(path string) {
+		for dir := filepath.Dir(path); dir != path; path, dir = dir, filepath.Dir(dir) {
+			incomplete[dir] = true
+		}
+	}
+	for _, failed := range errs.Errors {
+		if pathErr, ok := failed.(*os.PathError); ok {
+			markIncomplete(pathErr.Path)
+		}
+	}
+
+	// children go before their parents
+	for i := len(entries) - 1; i >= 0; i-- {
+		if err = monitor.Checkpoint(); err != nil {
+			errs.Add(err)
+			break
+		}
+
+		current := entries[i]
+		if current.info.IsDir() && incomplete[current.path] {
+			// the reason is already reported for the content of the directory
+			continue
+		}
+
+		if err = os.Remove(current.path); err != nil {
+			errs.Add(err)
+			markIncomplete(current.path)
+		}
+
+		if !current.info.IsDir() {
+			if current.info.Mode().IsRegular() {
+				monitor.BytesDone(current.info.Size())
+			}
+			monitor.FileDone()
+		}
+	}
+
+	return errs.ErrorOrNil()
+}
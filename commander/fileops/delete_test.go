@@ -0,0 +1,71 @@
+package fileops
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSummarize(t *testing.T) {
+	root := t.TempDir()
+	helperWriteFile(t, filepath.Join(root, "tree", "a.txt"), "alpha", 0644)
+	helperWriteFile(t, filepath.Join(root, "tree", "sub", "b.txt"), "beta", 0644)
+	if err := os.Symlink("a.txt", filepath.Join(root, "tree", "link")); err != nil {
+		t.Fatalf("unable to create symlink: %v", err)
+	}
+
+	summary := Summarize(filepath.Join(root, "tree"), filepath.Join(root, "missing"))
+	expected := Summary{Files: 3, Dirs: 2, Bytes: 9}
+	if summary != expected {
+		t.Errorf("expected %+v, got %+v", expected, summary)
+	}
+}
+
+func TestDeleteTree(t *testing.T) {
+	root := t.TempDir()
+	tree := filepath.Join(root, "tree")
+	helperWriteFile(t, filepath.Join(tree, "a.txt"), "alpha", 0644)
+	helperWriteFile(t, filepath.Join(tree, "sub", "deeper", "b.txt"), "beta", 0644)
+	helperWriteFile(t, filepath.Join(root, "outside.txt"), "outside", 0644)
+	if err := os.Symlink(filepath.Join(root, "outside.txt"), filepath.Join(tree, "link")); err != nil {
+		t.Fatalf("unable to create symlink: %v", err)
+	}
+
+	monitor := &stopAfter{files: 100}
+	if err := Delete(tree, monitor); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := os.Lstat(tree); !os.IsNotExist(err) {
+		t.Errorf("expected %s to be removed, got %v", tree, err)
+	}
+	// the link is removed, not followed
+	helperAssertContent(t, filepath.Join(root, "outside.txt"), "outside")
+	if monitor.files != 97 || monitor.bytes != 9 {
+		t.Errorf("expected 3 files of 9 bytes reported, got %d files of %d bytes", 100-monitor.files, monitor.bytes)
+	}
+}
+
+func TestDeleteCollectsPerItemErrors(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("permissions are not enforced for root")
+	}
+
+	root := t.TempDir()
+	tree := filepath.Join(root, "tree")
+	helperWriteFile(t, filepath.Join(tree, "locked", "a.txt"), "alpha", 0644)
+	helperWriteFile(t, filepath.Join(tree, "b.txt"), "beta", 0644)
+	locked := filepath.Join(tree, "locked")
+	if err := os.Chmod(locked, 0555); err != nil {
+		t.Fatalf("unable to chmod: %v", err)
+	}
+	defer os.Chmod(locked, 0755)
+
+	err := Delete(tree, nil)
+	errs, ok := err.(*OperationErrors)
+	if !ok || len(errs.Errors) != 1 {
+		t.Fatalf("expected a single error for the locked file, got %v", err)
+	}
+	if _, err = os.Lstat(filepath.Join(tree, "b.txt")); !os.IsNotExist(err) {
+		t.Errorf("expected b.txt to be removed, got %v", err)
+	}
+}
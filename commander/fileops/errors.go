@@ -0,0 +1,53 @@
+package fileops
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// OperationErrors collects per-file errors of a file operation, which carries on after a single file has failed
+type OperationErrors struct {
+	Errors []error
+}
+
+// Add appends the error, if it is not nil. Errors of the nested OperationErrors are appended one by one.
+func (e *OperationErrors) Add(err error) {
+	if nested, ok := err.(*OperationErrors); ok && nested != nil {
+		e.Errors = append(e.Errors, nested.Errors...)
+		return
+	}
+	if err != nil {
+		e.Errors = append(e.Errors, err)
+	}
+}
+
+// ErrorOrNil returns nil if no errors were collected, or the OperationErrors itself otherwise
+func (e *OperationErrors) ErrorOrNil() error {
+	if len(e.Errors) == 0 {
+		return nil
+	}
+	return e
+}
+
+func (e *OperationErrors) Error() string {
+	if len(e.Errors) == 1 {
+		return e.Errors[0].Error()
+	}
+
+	messages := make([]string, 0, len(e.Errors))
+	for _, err := range e.Errors {
+		messages = append(messages, err.Error())
+	}
+	return fmt.Sprintf("%d errors occurred:\n%s", len(e.Errors), strings.Join(messages, "\n"))
+}
+
+// Is reports whether any of the collected errors matches the target. It makes errors.Is work with OperationErrors.
+func (e *OperationErrors) Is(target error) bool {
+	for _, err := range e.Errors {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
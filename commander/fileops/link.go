@@ -0,0 +1,34 @@
+package fileops
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// ReplaceWithLink replaces the file at the path with a link to the target: a hard link, or a symbolic one holding
+// the target relative to the directory of the path. The link is created under a temporary name next to the path
+// and renamed over it, so the path is never left missing; hard links across file systems fail and leave it intact.
+func ReplaceWithLink(target, path string, symbolic bool) error {
+	dir := filepath.Dir(path)
+	temporary := temporaryName(path, "link")
+
+	var err error
+	if symbolic {
+		linkTarget := target
+		if relative, relErr := filepath.Rel(dir, target); relErr == nil {
+			linkTarget = relative
+		}
+		err = os.Symlink(linkTarget, temporary)
+	} else {
+		err = os.Link(target, temporary)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err = os.Rename(temporary, path); err != nil {
+		_ = os.Remove(temporary)
+		return err
+	}
+	return nil
+}
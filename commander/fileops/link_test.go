@@ -0,0 +1,70 @@
+package fileops
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReplaceWithHardLink(t *testing.T) {
+	root := t.TempDir()
+	original, duplicate := filepath.Join(root, "a.txt"), filepath.Join(root, "sub", "b.txt")
+	helperWriteFile(t, original, "same", 0644)
+	helperWriteFile(t, duplicate, "same", 0644)
+
+	if err := ReplaceWithLink(original, duplicate, false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	originalInfo, _ := os.Stat(original)
+	duplicateInfo, err := os.Lstat(duplicate)
+	if err != nil {
+		t.Fatalf("expected %s to exist: %v", duplicate, err)
+	}
+	if !os.SameFile(originalInfo, duplicateInfo) {
+		t.Errorf("expected %s to be a hard link to %s", duplicate, original)
+	}
+	helperAssertNoTemporaryFiles(t, filepath.Join(root, "sub"), 1)
+}
+
+func TestReplaceWithSymlink(t *testing.T) {
+	root := t.TempDir()
+	original, duplicate := filepath.Join(root, "a.txt"), filepath.Join(root, "sub", "b.txt")
+	helperWriteFile(t, original, "same", 0644)
+	helperWriteFile(t, duplicate, "same", 0644)
+
+	if err := ReplaceWithLink(original, duplicate, true); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	target, err := os.Readlink(duplicate)
+	if err != nil {
+		t.Fatalf("expected %s to be a symlink: %v", duplicate, err)
+	}
+	if target != filepath.Join("..", "a.txt") {
+		t.Errorf("expected the relative target, got %s", target)
+	}
+	helperAssertContent(t, duplicate, "same")
+	helperAssertNoTemporaryFiles(t, filepath.Join(root, "sub"), 1)
+}
+
+func TestReplaceWithLinkMissingTarget(t *testing.T) {
+	root := t.TempDir()
+	duplicate := filepath.Join(root, "b.txt")
+	helperWriteFile(t, duplicate, "same", 0644)
+
+	if err := ReplaceWithLink(filepath.Join(root, "missing.txt"), duplicate, false); err == nil {
+		t.Fatalf("expected an error for the missing target")
+	}
+	helperAssertContent(t, duplicate, "same")
+	helperAssertNoTemporaryFiles(t, root, 1)
+}
+
+func helperAssertNoTemporaryFiles(t *testing.T, dir string, expected int) {
+	content, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("unable to read %s: %v", dir, err)
+	}
+	if len(content) != expected {
+		t.Errorf("expected %d entries in %s, got %d", expected, dir, len(content))
+	}
+}
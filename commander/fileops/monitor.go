@@ -0,0 +1,25 @@
+package fileops
+
+// Monitor observes and controls a running file operation
+type Monitor interface {
+	// Checkpoint blocks while the operation is paused, and returns an error if the operation must stop
+	Checkpoint() error
+	// BytesDone accounts for n more bytes processed
+	BytesDone(n int64)
+	// FileDone accounts for one more file (anything but a directory) processed
+	FileDone()
+}
+
+// NopMonitor is used when the operation is not monitored: it never pauses nor stops the operation
+type NopMonitor struct{}
+
+func (NopMonitor) Checkpoint() error { return nil }
+func (NopMonitor) BytesDone(n int64) {}
+func (NopMonitor) FileDone()         {}
+
+// Measure returns the number of files (anything but directories) and the total size of the regular files
+// in the given trees. Symbolic links are not followed. Unreadable entries are ignored.
+func Measure(paths ...string) (files, bytes int64) {
+	summary := Summarize(paths...)
+	return summary.Files, summary.Bytes
+}
@@ -0,0 +1,255 @@
+package fileops
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// rename is replaced in tests to simulate moves across file systems
+var rename = os.Rename
+
+// ErrNotVerified is reported for the source files that were kept in place, since their copy did not match them
+var ErrNotVerified = errors.New("copy does not match the source, the source is kept")
+
+// Move renames source to destination. If the destination already exists, the resolver decides
+// whether to overwrite it, skip the move or move under a different name; nil resolver means Overwrite.
+// See Copier.Move for details.
+func Move(source, destination string, conflicts ConflictResolver) (string, error) {
+	options := DefaultCopyOptions()
+	options.Conflicts = conflicts
+	return NewCopier(options).Move(source, destination)
+}
+
+// Move renames source to destination. When the destination is on another file system, the source is
+// copied with the Copier options, the copy is verified against the source, and only then the source is removed.
+// Source files that were not copied or could not be verified are left in place.
+// A directory moved onto an existing directory is merged into it entry by entry, resolving the conflict of every entry;
+// the destination replaced according to the conflict resolver stays in place until the source is completely there.
+// Returns the actual destination, which differs from the given one if the conflict was resolved with the Rename policy,
+// or is empty if the move was skipped. The error is nil or *OperationErrors with an error for every entry
+// that could not be moved.
+func (c *Copier) Move(source, destination string) (string, error) {
+	info, err := os.Lstat(source)
+	if err != nil {
+		return "", err
+	}
+	if c.stopped = c.monitor.Checkpoint(); c.stopped != nil {
+		return "", c.stopped
+	}
+
+	if destinationInfo, err := os.Lstat(destination); err == nil && info.IsDir() && destinationInfo.IsDir() {
+		return destination, c.mergeDir(source, destination)
+	}
+
+	action, destination, err := resolveConflict(c.options.Conflicts, source, destination, info)
+	if action != actionProceed && action != actionReplace {
+		return "", err
+	}
+	moved, err := c.moveEntry(source, destination, info, action == actionReplace)
+	if !moved {
+		return "", err
+	}
+	return destination, err
+}
+
+// mergeDir moves the content of the source directory into the existing destination directory, one entry at a time;
+// the source directory is removed once all of its content has been moved
+func (c *Copier) mergeDir(source, destination string) error {
+	dir, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	names, err := dir.Readdirnames(-1)
+	_ = dir.Close()
+	if err != nil {
+		return err
+	}
+
+	errs := new(OperationErrors)
+	for _, name := range names {
+		_, err = c.Move(filepath.Join(source, name), filepath.Join(destination, name))
+		errs.Add(err)
+		if c.stopped != nil || errors.Is(err, ErrAborted) {
+			return errs.ErrorOrNil()
+		}
+	}
+
+	if err = os.Remove(source); err != nil && !isDir(source) {
+		errs.Add(err)
+	}
+	// non-empty directory holds the entries that were skipped
+	return errs.ErrorOrNil()
+}
+
+// moveEntry renames the source to the destination, falling back to copy and delete across file systems.
+// If replace is true, the existing destination is replaced once the source is completely in its place.
+// Returns false if nothing has been moved.
+func (c *Copier) moveEntry(source, destination string, info os.FileInfo, replace bool) (bool, error) {
+	aside := ""
+	if replace && info.IsDir() {
+		// a directory can not be renamed over a file, which is set aside until the rename succeeds
+		aside = temporaryName(destination, "replaced")
+		if err := os.Rename(destination, aside); err != nil {
+			return false, err
+		}
+	}
+
+	err := rename(source, destination)
+	if err == nil {
+		summary := Summarize(destination)
+		c.monitor.BytesDone(summary.Bytes)
+		for i := int64(0); i < summary.Files; i++ {
+			c.monitor.FileDone()
+		}
+		if aside != "" {
+			return true, os.Remove(aside)
+		}
+		return true, nil
+	}
+	if aside != "" {
+		if restoreErr := os.Rename(aside, destination); restoreErr != nil {
+			return false, restoreErr
+		}
+	}
+	if !isCrossDevice(err) {
+		return false, err
+	}
+
+	// the copy replaces the destination once the copy is complete, without asking about the same conflict again
+	conflicts := c.options.Conflicts
+	if replace {
+		c.options.Conflicts = FixedPolicy(Overwrite)
+	}
+	err = c.Copy(source, destination)
+	c.options.Conflicts = conflicts
+
+	errs := new(OperationErrors)
+	errs.Add(err)
+	moved := len(c.copied) > 0
+	if c.stopped != nil || errors.Is(err, ErrAborted) {
+		// the copy is incomplete; the source stays intact
+		return moved, errs.ErrorOrNil()
+	}
+	errs.Add(c.removeCopied(source))
+	return moved, errs.ErrorOrNil()
+}
+
+// removeCopied removes the source entries which have been copied by the last Copy call, and whose copies match them.
+// Directories are removed once they are empty.
+func (c *Copier) removeCopied(source string) error {
+	errs := new(OperationErrors)
+
+	var entries []string
+	_ = filepath.Walk(source, func(path string, info os.FileInfo, err error) error {
+		if err == nil {
+			entries = append(entries, path)
+		}
+		return nil
+	})
+
+	// children go before their parents
+	for i := len(entries) - 1; i >= 0; i-- {
+		path := entries[i]
+		destination, ok := c.copied[path]
+		if !ok {
+			// either skipped, or the error is already reported by the copy
+			continue
+		}
+
+		info, err := os.Lstat(path)
+		if err != nil {
+			errs.Add(err)
+			continue
+		}
+
+		if info.IsDir() {
+			err = os.Remove(path)
+			if err != nil && !isDir(path) {
+				errs.Add(err)
+			}
+			// non-empty directory holds the files that were kept
+			continue
+		}
+
+		if err = c.verifyCopy(path, destination, info); err != nil {
+			errs.Add(err)
+			continue
+		}
+		errs.Add(os.Remove(path))
+	}
+	return errs.ErrorOrNil()
+}
+
+// verifyCopy compares the source file with its copy: type, size and content for regular files, target for symlinks
+func (c *Copier) verifyCopy(source, destination string, sourceInfo os.FileInfo) error {
+	notVerified := &os.PathError{Op: "move", Path: source, Err: ErrNotVerified}
+
+	destinationInfo, err := os.Lstat(destination)
+	if err != nil || destinationInfo.Mode()&os.ModeType != sourceInfo.Mode()&os.ModeType {
+		return notVerified
+	}
+
+	if sourceInfo.Mode()&os.ModeSymlink != 0 {
+		sourceTarget, err := os.Readlink(source)
+		if err != nil {
+			return err
+		}
+		destinationTarget, err := os.Readlink(destination)
+		if err != nil || sourceTarget != destinationTarget {
+			return notVerified
+		}
+		return nil
+	}
+
+	if destinationInfo.Size() != sourceInfo.Size() {
+		return notVerified
+	}
+	equal, err := SameContent(source, destination)
+	if err != nil {
+		return err
+	}
+	if !equal {
+		return notVerified
+	}
+	return nil
+}
+
+// SameContent compares two files byte by byte
+func SameContent(pathA, pathB string) (bool, error) {
+	fileA, err := os.Open(pathA)
+	if err != nil {
+		return false, err
+	}
+	defer fileA.Close()
+
+	fileB, err := os.Open(pathB)
+	if err != nil {
+		return false, err
+	}
+	defer fileB.Close()
+
+	bufferA := make([]byte, defaultBufferSize)
+	bufferB := make([]byte, defaultBufferSize)
+	for {
+		nA, errA := io.ReadFull(fileA, bufferA)
+		nB, errB := io.ReadFull(fileB, bufferB)
+		if !bytes.Equal(bufferA[:nA], bufferB[:nB]) {
+			return false, nil
+		}
+
+		endA := errA == io.EOF || errA == io.ErrUnexpectedEOF
+		endB := errB == io.EOF || errB == io.ErrUnexpectedEOF
+		if endA || endB {
+			return endA && endB, nil
+		}
+		if errA != nil {
+			return false, errA
+		}
+		if errB != nil {
+			return false, errB
+		}
+	}
+}
@@ -0,0 +1,126 @@
+// +build linux
+
+package fileops
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+)
+
+// helperCrossDevice makes every rename fail as if the destination was on another file system
+func helperCrossDevice(t *testing.T) {
+	rename = func(source, destination string) error {
+		return &os.LinkError{Op: "rename", Old: source, New: destination, Err: syscall.EXDEV}
+	}
+	t.Cleanup(func() { rename = os.Rename })
+}
+
+func TestMoveAcrossDevices(t *testing.T) {
+	helperCrossDevice(t)
+	root := t.TempDir()
+	source := filepath.Join(root, "source")
+	destination := filepath.Join(root, "destination")
+	helperWriteFile(t, filepath.Join(source, "a.txt"), "alpha", 0644)
+	helperWriteFile(t, filepath.Join(source, "sub", "b.txt"), "beta", 0600)
+	if err := os.Symlink("a.txt", filepath.Join(source, "link")); err != nil {
+		t.Fatalf("unable to create symlink: %v", err)
+	}
+
+	monitor := &stopAfter{files: 100}
+	options := DefaultCopyOptions()
+	options.Monitor = monitor
+	if _, err := NewCopier(options).Move(source, destination); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := os.Lstat(source); !os.IsNotExist(err) {
+		t.Errorf("expected source to be removed, got %v", err)
+	}
+	helperAssertContent(t, filepath.Join(destination, "a.txt"), "alpha")
+	helperAssertContent(t, filepath.Join(destination, "sub", "b.txt"), "beta")
+	if target, err := os.Readlink(filepath.Join(destination, "link")); err != nil || target != "a.txt" {
+		t.Errorf("expected link to a.txt, got %s (%v)", target, err)
+	}
+	if monitor.files != 97 || monitor.bytes != 9 {
+		t.Errorf("expected 3 files of 9 bytes reported, got %d files of %d bytes", 100-monitor.files, monitor.bytes)
+	}
+}
+
+func TestMoveAcrossDevicesKeepsSkippedFiles(t *testing.T) {
+	helperCrossDevice(t)
+	root := t.TempDir()
+	source := filepath.Join(root, "source")
+	destination := filepath.Join(root, "destination")
+	helperWriteFile(t, filepath.Join(source, "a.txt"), "alpha", 0644)
+	helperWriteFile(t, filepath.Join(source, "b.txt"), "beta", 0644)
+	helperWriteFile(t, filepath.Join(destination, "b.txt"), "old", 0644)
+
+	if _, err := Move(source, destination, FixedPolicy(Skip)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// the skipped file and its directory stay in place
+	helperAssertContent(t, filepath.Join(source, "b.txt"), "beta")
+	helperAssertContent(t, filepath.Join(destination, "b.txt"), "old")
+	helperAssertContent(t, filepath.Join(destination, "a.txt"), "alpha")
+	if _, err := os.Lstat(filepath.Join(source, "a.txt")); !os.IsNotExist(err) {
+		t.Errorf("expected a.txt to be moved, got %v", err)
+	}
+}
+
+func TestMoveNotVerified(t *testing.T) {
+	root := t.TempDir()
+	source := filepath.Join(root, "a.txt")
+	destination := filepath.Join(root, "b.txt")
+	helperWriteFile(t, source, "alpha", 0644)
+	helperWriteFile(t, destination, "omega", 0644)
+
+	info, err := os.Lstat(source)
+	if err != nil {
+		t.Fatalf("unable to stat: %v", err)
+	}
+	copier := NewCopier(DefaultCopyOptions())
+	if err = copier.verifyCopy(source, destination, info); !errors.Is(err, ErrNotVerified) {
+		t.Errorf("expected %v, got %v", ErrNotVerified, err)
+	}
+
+	if err = ioutil.WriteFile(destination, []byte("alpha"), 0644); err != nil {
+		t.Fatalf("unable to write: %v", err)
+	}
+	if err = copier.verifyCopy(source, destination, info); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestMoveAcrossDevicesMergesDirectories(t *testing.T) {
+	helperCrossDevice(t)
+	root := t.TempDir()
+	source := filepath.Join(root, "source")
+	destination := filepath.Join(root, "destination")
+	helperWriteFile(t, filepath.Join(source, "a.txt"), "new", 0644)
+	helperWriteFile(t, filepath.Join(destination, "a.txt"), "old", 0644)
+	helperWriteFile(t, filepath.Join(destination, "kept.txt"), "kept", 0644)
+
+	// the conflict is resolved once, even though the file is copied across the devices
+	prompts := 0
+	resolver := NewPromptResolver(func(conflict Conflict) (ConflictPolicy, bool) {
+		prompts++
+		return Overwrite, false
+	})
+	if _, err := Move(source, destination, resolver); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	helperAssertContent(t, filepath.Join(destination, "a.txt"), "new")
+	helperAssertContent(t, filepath.Join(destination, "kept.txt"), "kept")
+	if prompts != 1 {
+		t.Errorf("expected a single prompt, got %d", prompts)
+	}
+	if _, err := os.Lstat(source); !os.IsNotExist(err) {
+		t.Errorf("expected source to be removed, got %v", err)
+	}
+}
@@ -0,0 +1,54 @@
+// +build linux
+
+package fileops
+
+import (
+	"os"
+	"syscall"
+	"time"
+)
+
+// fileStat holds the platform-specific metadata of a file
+type fileStat struct {
+	dev   uint64
+	ino   uint64
+	nlink uint64
+	uid   int
+	gid   int
+	atime time.Time
+	// true if the file occupies less disk blocks than its size requires
+	sparse bool
+}
+
+func statOf(info os.FileInfo) fileStat {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return fileStat{uid: -1, gid: -1, atime: info.ModTime()}
+	}
+
+	return fileStat{
+		dev:    uint64(stat.Dev),
+		ino:    uint64(stat.Ino),
+		nlink:  uint64(stat.Nlink),
+		uid:    int(stat.Uid),
+		gid:    int(stat.Gid),
+		atime:  time.Unix(int64(stat.Atim.Sec), int64(stat.Atim.Nsec)),
+		sparse: stat.Blocks*512 < stat.Size,
+	}
+}
+
+// isPermissionError returns true for errors caused by the lack of privileges, e.g. chown by a regular user
+func isPermissionError(err error) bool {
+	if pathErr, ok := err.(*os.PathError); ok {
+		err = pathErr.Err
+	} else if linkErr, ok := err.(*os.LinkError); ok {
+		err = linkErr.Err
+	}
+	return err == syscall.EPERM || err == syscall.EACCES
+}
+
+// isCrossDevice returns true if the rename failed because the destination is on another file system
+func isCrossDevice(err error) bool {
+	linkErr, ok := err.(*os.LinkError)
+	return ok && linkErr.Err == syscall.EXDEV
+}
@@ -0,0 +1,50 @@
+// +build plan9
+
+package fileops
+
+import (
+	"os"
+	"syscall"
+	"time"
+)
+
+// fileStat holds the platform-specific metadata of a file
+type fileStat struct {
+	dev   uint64
+	ino   uint64
+	nlink uint64
+	uid   int
+	gid   int
+	atime time.Time
+	// true if the file occupies less disk blocks than its size requires
+	sparse bool
+}
+
+func statOf(info os.FileInfo) fileStat {
+	stat, ok := info.Sys().(*syscall.Dir)
+	if !ok {
+		return fileStat{uid: -1, gid: -1, atime: info.ModTime()}
+	}
+
+	// Plan9 has neither numeric owners nor hard links
+	return fileStat{
+		dev:   uint64(stat.Dev),
+		ino:   stat.Qid.Path,
+		nlink: 1,
+		uid:   -1,
+		gid:   -1,
+		atime: time.Unix(int64(stat.Atime), 0),
+	}
+}
+
+// isPermissionError returns true for errors caused by the lack of privileges
+func isPermissionError(err error) bool {
+	return os.IsPermission(err) || err == syscall.EPLAN9
+}
+
+// isCrossDevice returns true if the rename failed and the file has to be copied instead.
+// Plan9 renames files only within the same directory, so any failed rename is retried as a copy.
+func isCrossDevice(err error) bool {
+	_, ok := err.(*os.LinkError)
+	return ok
+}
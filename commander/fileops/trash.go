@@ -0,0 +1,173 @@
+package fileops
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/mitchellh/go-homedir"
+)
+
+const (
+	trashInfoExtension = ".trashinfo"
+	trashInfoHeader    = "[Trash Info]"
+	trashDateFormat    = "2006-01-02T15:04:05"
+)
+
+// Trash is a freedesktop.org compatible trash directory, with the "files" and "info" subdirectories.
+// See https://specifications.freedesktop.org/trash-spec/trashspec-latest.html
+type Trash struct {
+	Dir string
+}
+
+// NewHomeTrash returns the home trash: $XDG_DATA_HOME/Trash, or ~/.local/share/Trash if the variable is not set
+func NewHomeTrash() (*Trash, error) {
+	dataHome := os.Getenv("XDG_DATA_HOME")
+	if dataHome == "" {
+		home, err := homedir.Dir()
+		if err != nil {
+			return nil, err
+		}
+		dataHome = filepath.Join(home, ".local", "share")
+	}
+	return &Trash{Dir: filepath.Join(dataHome, "Trash")}, nil
+}
+
+// FilePath returns the location of the trashed item with the given name
+func (t *Trash) FilePath(name string) string {
+	return filepath.Join(t.filesDir(), name)
+}
+
+func (t *Trash) filesDir() string {
+	return filepath.Join(t.Dir, "files")
+}
+
+func (t *Trash) infoDir() string {
+	return filepath.Join(t.Dir, "info")
+}
+
+// Put moves the path into the trash, and returns the name it is stored under.
+// The name is unique within the trash, and is used to Restore the path. The path on another file system
+// than the trash is copied and then deleted; if only a part of it could be moved, both the name and the error are returned.
+func (t *Trash) Put(path string) (string, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	if _, err = os.Lstat(path); err != nil {
+		return "", err
+	}
+
+	for _, dir := range []string{t.filesDir(), t.infoDir()} {
+		if err = os.MkdirAll(dir, 0700); err != nil {
+			return "", err
+		}
+	}
+
+	// the info file is created exclusively, which reserves the name in the trash
+	name, infoFile, err := t.reserveName(filepath.Base(path))
+	if err != nil {
+		return "", err
+	}
+	_, err = fmt.Fprintf(infoFile, "%s\nPath=%s\nDeletionDate=%s\n",
+		trashInfoHeader,
+		(&url.URL{Path: path}).EscapedPath(),
+		time.Now().Format(trashDateFormat),
+	)
+	if closeErr := infoFile.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		_ = os.Remove(filepath.Join(t.infoDir(), name+trashInfoExtension))
+		return "", err
+	}
+
+	// the path on another file system is copied into the trash, and removed once the copy is verified
+	trashed, err := NewCopier(DefaultCopyOptions()).Move(path, t.FilePath(name))
+	if trashed == "" {
+		_ = os.Remove(filepath.Join(t.infoDir(), name+trashInfoExtension))
+		return "", err
+	}
+	// the partially trashed path keeps its info, so that what has reached the trash can be restored
+	return name, err
+}
+
+// reserveName creates the info file for the first free name in the form of "name", "name_1", "name_2", etc
+func (t *Trash) reserveName(base string) (string, *os.File, error) {
+	ext := filepath.Ext(base)
+	if ext == base {
+		ext = ""
+	}
+	stem := strings.TrimSuffix(base, ext)
+
+	for i := 0; ; i++ {
+		name := base
+		if i > 0 {
+			name = fmt.Sprintf("%s_%d%s", stem, i, ext)
+		}
+
+		infoPath := filepath.Join(t.infoDir(), name+trashInfoExtension)
+		infoFile, err := os.OpenFile(infoPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+		if os.IsExist(err) {
+			continue
+		}
+		if err != nil {
+			return "", nil, err
+		}
+
+		if _, err = os.Lstat(filepath.Join(t.filesDir(), name)); err == nil {
+			// orphaned file without the info; leave it alone
+			_ = infoFile.Close()
+			_ = os.Remove(infoPath)
+			continue
+		}
+		return name, infoFile, nil
+	}
+}
+
+// OriginalPath reads the location the trashed item was deleted from
+func (t *Trash) OriginalPath(name string) (string, error) {
+	infoFile, err := os.Open(filepath.Join(t.infoDir(), name+trashInfoExtension))
+	if err != nil {
+		return "", err
+	}
+	defer infoFile.Close()
+
+	scanner := bufio.NewScanner(infoFile)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "Path=") {
+			return url.PathUnescape(strings.TrimPrefix(line, "Path="))
+		}
+	}
+	if err = scanner.Err(); err != nil {
+		return "", err
+	}
+	return "", fmt.Errorf("%s: no Path in the trash info", name)
+}
+
+// Restore moves the trashed item back to its original location, which must not exist.
+// Returns the restored path.
+func (t *Trash) Restore(name string) (string, error) {
+	originalPath, err := t.OriginalPath(name)
+	if err != nil {
+		return "", err
+	}
+
+	if _, err = os.Lstat(originalPath); err == nil {
+		return "", &os.PathError{Op: "restore", Path: originalPath, Err: errors.New("file already exists")}
+	}
+	if err = os.MkdirAll(filepath.Dir(originalPath), 0755); err != nil {
+		return "", err
+	}
+	if _, err = Move(t.FilePath(name), originalPath, FixedPolicy(Abort)); err != nil {
+		return "", err
+	}
+	return originalPath, os.Remove(filepath.Join(t.infoDir(), name+trashInfoExtension))
+}
@@ -0,0 +1,79 @@
+// +build linux
+
+package fileops
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+)
+
+func TestTrashAcrossDevices(t *testing.T) {
+	helperCrossDevice(t)
+	root := t.TempDir()
+	trash := &Trash{Dir: filepath.Join(root, "Trash")}
+	source := filepath.Join(root, "dir")
+	helperWriteFile(t, filepath.Join(source, "a.txt"), "alpha", 0644)
+
+	name, err := trash.Put(source)
+	if err != nil {
+		t.Fatalf("unable to trash: %v", err)
+	}
+	if _, err = os.Lstat(source); !os.IsNotExist(err) {
+		t.Errorf("expected %s to be gone, got %v", source, err)
+	}
+	helperAssertContent(t, filepath.Join(trash.FilePath(name), "a.txt"), "alpha")
+
+	restored, err := trash.Restore(name)
+	if err != nil || restored != source {
+		t.Fatalf("expected %s to be restored, got %s (%v)", source, restored, err)
+	}
+	helperAssertContent(t, filepath.Join(source, "a.txt"), "alpha")
+}
+
+func TestTrashAcrossDevicesPartially(t *testing.T) {
+	helperCrossDevice(t)
+	root := t.TempDir()
+	trash := &Trash{Dir: filepath.Join(root, "Trash")}
+	source := filepath.Join(root, "dir")
+	helperWriteFile(t, filepath.Join(source, "a.txt"), "alpha", 0644)
+
+	// the named pipe can not be copied, hence it stays in place
+	if err := syscall.Mkfifo(filepath.Join(source, "pipe"), 0644); err != nil {
+		t.Fatalf("unable to create named pipe: %v", err)
+	}
+
+	name, err := trash.Put(source)
+	if err == nil || name == "" {
+		t.Fatalf("expected the partially trashed directory to be reported, got %s (%v)", name, err)
+	}
+	helperAssertContent(t, filepath.Join(trash.FilePath(name), "a.txt"), "alpha")
+	if _, err = os.Lstat(filepath.Join(source, "pipe")); err != nil {
+		t.Errorf("expected the pipe to stay in place, got %v", err)
+	}
+	if _, err = trash.OriginalPath(name); err != nil {
+		t.Errorf("expected the trash info to be kept, got %v", err)
+	}
+}
+
+func TestTrashAcrossDevicesFailure(t *testing.T) {
+	helperCrossDevice(t)
+	root := t.TempDir()
+	trash := &Trash{Dir: filepath.Join(root, "Trash")}
+	source := filepath.Join(root, "pipe")
+	if err := syscall.Mkfifo(source, 0644); err != nil {
+		t.Fatalf("unable to create named pipe: %v", err)
+	}
+
+	if name, err := trash.Put(source); err == nil || name != "" {
+		t.Fatalf("expected the trash to fail, got %s (%v)", name, err)
+	}
+	if _, err := os.Lstat(source); err != nil {
+		t.Errorf("expected the pipe to stay in place, got %v", err)
+	}
+	if content, _ := ioutil.ReadDir(filepath.Join(trash.Dir, "info")); len(content) != 0 {
+		t.Errorf("expected the trash info to be removed, found %d files", len(content))
+	}
+}
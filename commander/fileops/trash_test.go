@@ -0,0 +1,60 @@
+package fileops
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTrashPutRestore(t *testing.T) {
+	root := t.TempDir()
+	trash := &Trash{Dir: filepath.Join(root, "Trash")}
+	first := filepath.Join(root, "dir one", "a.txt")
+	helperWriteFile(t, first, "first", 0644)
+
+	name, err := trash.Put(first)
+	if err != nil {
+		t.Fatalf("unable to trash: %v", err)
+	}
+	if name != "a.txt" {
+		t.Errorf("expected a.txt, got %s", name)
+	}
+	if _, err = os.Lstat(first); !os.IsNotExist(err) {
+		t.Errorf("expected %s to be gone, got %v", first, err)
+	}
+	helperAssertContent(t, filepath.Join(trash.Dir, "files", "a.txt"), "first")
+
+	info, err := ioutil.ReadFile(filepath.Join(trash.Dir, "info", "a.txt.trashinfo"))
+	if err != nil {
+		t.Fatalf("unable to read trash info: %v", err)
+	}
+	lines := strings.Split(string(info), "\n")
+	if lines[0] != "[Trash Info]" || lines[1] != "Path="+strings.ReplaceAll(first, " ", "%20") || !strings.HasPrefix(lines[2], "DeletionDate=") {
+		t.Errorf("unexpected trash info: %q", info)
+	}
+
+	// the same name is trashed under a new one
+	helperWriteFile(t, first, "second", 0644)
+	secondName, err := trash.Put(first)
+	if err != nil || secondName != "a_1.txt" {
+		t.Fatalf("expected a_1.txt, got %s (%v)", secondName, err)
+	}
+
+	// restore does not overwrite the existing file
+	helperWriteFile(t, first, "third", 0644)
+	if _, err = trash.Restore(name); err == nil {
+		t.Errorf("expected error when the original path exists")
+	}
+	_ = os.Remove(first)
+
+	restored, err := trash.Restore(name)
+	if err != nil || restored != first {
+		t.Fatalf("expected %s to be restored, got %s (%v)", first, restored, err)
+	}
+	helperAssertContent(t, first, "first")
+	if _, err = os.Lstat(filepath.Join(trash.Dir, "info", "a.txt.trashinfo")); !os.IsNotExist(err) {
+		t.Errorf("expected trash info to be removed, got %v", err)
+	}
+}
@@ -0,0 +1,159 @@
+package hashcache
+
+import (
+	"bufio"
+	"container/list"
+	"encoding/gob"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/mushkevych/9ofm/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+// Key identifies the content of a file: the content is assumed unchanged
+// as long as the file keeps its device, inode, size and modification time
+type Key struct {
+	Dev     uint64
+	Inode   uint64
+	Size    int64
+	ModTime int64 // nanoseconds since the epoch
+}
+
+// entry is a single hash stored in the cache
+type entry struct {
+	Key  Key
+	Hash uint64
+}
+
+// Cache remembers the content hashes of the files, so that unchanged files are not hashed again.
+// It keeps at most maxEntries hashes, evicting the least recently used ones.
+// The cache is loaded from a single file and written back by Save; it is safe for concurrent use.
+type Cache struct {
+	path       string
+	maxEntries int
+
+	mutex   sync.Mutex
+	entries map[Key]*list.Element
+	// the most recently used entries first
+	recent *list.List
+	// true if there are changes not saved yet
+	dirty bool
+}
+
+// Open loads the cache from the file; a missing file is an empty cache. The file of an unknown format
+// is discarded, since the hashes can always be computed again.
+func Open(path string, maxEntries int) (*Cache, error) {
+	cache := &Cache{
+		path:       path,
+		maxEntries: maxEntries,
+		entries:    make(map[Key]*list.Element),
+		recent:     list.New(),
+	}
+
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return cache, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var stored []entry
+	if err = gob.NewDecoder(bufio.NewReader(file)).Decode(&stored); err != nil {
+		log.WithError(err).Warnf("discarding malformed hash cache %s", path)
+		cache.dirty = true
+		return cache, nil
+	}
+	// stored from the least recently used, so that the most recent ends up in front
+	for _, e := range stored {
+		cache.put(e.Key, e.Hash)
+	}
+	cache.dirty = false
+	return cache, nil
+}
+
+// Get returns the hash of the file content, if it is known
+func (c *Cache) Get(key Key) (uint64, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	element, ok := c.entries[key]
+	if !ok {
+		return 0, false
+	}
+	c.recent.MoveToFront(element)
+	return element.Value.(*entry).Hash, true
+}
+
+// Put remembers the hash of the file content, evicting the least recently used hash if the cache is full
+func (c *Cache) Put(key Key, hash uint64) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.put(key, hash)
+}
+
+func (c *Cache) put(key Key, hash uint64) {
+	c.dirty = true
+	if element, ok := c.entries[key]; ok {
+		element.Value.(*entry).Hash = hash
+		c.recent.MoveToFront(element)
+		return
+	}
+
+	c.entries[key] = c.recent.PushFront(&entry{Key: key, Hash: hash})
+	for c.maxEntries > 0 && c.recent.Len() > c.maxEntries {
+		oldest := c.recent.Back()
+		c.recent.Remove(oldest)
+		delete(c.entries, oldest.Value.(*entry).Key)
+	}
+}
+
+// Len returns the number of cached hashes
+func (c *Cache) Len() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.recent.Len()
+}
+
+// Invalidate forgets all cached hashes and removes the cache file
+func (c *Cache) Invalidate() error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.entries = make(map[Key]*list.Element)
+	c.recent.Init()
+	c.dirty = false
+	if err := os.Remove(c.path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// Save writes the cache to its file, unless it has not changed since it was loaded or saved.
+// The file is replaced atomically.
+func (c *Cache) Save() error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if !c.dirty {
+		return nil
+	}
+
+	stored := make([]entry, 0, c.recent.Len())
+	for element := c.recent.Back(); element != nil; element = element.Prev() {
+		stored = append(stored, *element.Value.(*entry))
+	}
+
+	err := utils.WriteFileAtomic(c.path, 0600, func(w io.Writer) error {
+		return gob.NewEncoder(w).Encode(stored)
+	})
+	if err != nil {
+		return err
+	}
+
+	c.dirty = false
+	return nil
+}
@@ -0,0 +1,84 @@
+package hashcache
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func helperOpen(t *testing.T, path string, maxEntries int) *Cache {
+	cache, err := Open(path, maxEntries)
+	if err != nil {
+		t.Fatalf("unable to open the cache: %v", err)
+	}
+	return cache
+}
+
+func helperAssertHash(t *testing.T, cache *Cache, key Key, expected uint64, expectedOk bool) {
+	hash, ok := cache.Get(key)
+	if ok != expectedOk || hash != expected {
+		t.Errorf("%v: expected hash %d (cached=%v), got %d (cached=%v)", key, expected, expectedOk, hash, ok)
+	}
+}
+
+func TestCacheEviction(t *testing.T) {
+	cache := helperOpen(t, filepath.Join(t.TempDir(), "hashes"), 2)
+	first, second, third := Key{Inode: 1}, Key{Inode: 2}, Key{Inode: 3}
+
+	cache.Put(first, 100)
+	cache.Put(second, 200)
+	// the first is used more recently than the second, which is evicted
+	helperAssertHash(t, cache, first, 100, true)
+	cache.Put(third, 300)
+
+	helperAssertHash(t, cache, first, 100, true)
+	helperAssertHash(t, cache, second, 0, false)
+	helperAssertHash(t, cache, third, 300, true)
+	if cache.Len() != 2 {
+		t.Errorf("expected 2 cached hashes, got %d", cache.Len())
+	}
+
+	// any change of the file is a different key
+	helperAssertHash(t, cache, Key{Inode: 1, Size: 10}, 0, false)
+}
+
+func TestCacheSaveAndInvalidate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache", "hashes")
+	cache := helperOpen(t, path, 2)
+	first, second := Key{Dev: 1, Inode: 1, Size: 5, ModTime: 42}, Key{Dev: 1, Inode: 2}
+	cache.Put(second, 200)
+	cache.Put(first, 100)
+	if err := cache.Save(); err != nil {
+		t.Fatalf("unable to save the cache: %v", err)
+	}
+
+	loaded := helperOpen(t, path, 2)
+	helperAssertHash(t, loaded, first, 100, true)
+	helperAssertHash(t, loaded, second, 200, true)
+
+	// recency is preserved: the second is the least recently used one
+	loaded = helperOpen(t, path, 2)
+	loaded.Put(Key{Inode: 3}, 300)
+	helperAssertHash(t, loaded, second, 0, false)
+
+	if err := cache.Invalidate(); err != nil {
+		t.Fatalf("unable to invalidate the cache: %v", err)
+	}
+	helperAssertHash(t, cache, first, 0, false)
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("expected the cache file to be removed, got %v", err)
+	}
+}
+
+func TestCacheMalformedFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "hashes")
+	if err := ioutil.WriteFile(path, []byte("not a cache"), 0600); err != nil {
+		t.Fatalf("unable to write file: %v", err)
+	}
+
+	cache := helperOpen(t, path, 10)
+	if cache.Len() != 0 {
+		t.Errorf("expected malformed cache to be discarded, got %d hashes", cache.Len())
+	}
+}
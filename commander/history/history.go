@@ -0,0 +1,101 @@
+package history
+
+import (
+	"strings"
+)
+
+// Entry is a directory visited by the file panel
+type Entry struct {
+	Dir string
+	// name of the file under the cursor when the directory was left; empty if unknown
+	Selected string
+}
+
+// History is the bounded back/forward stack of the directories visited by a single file panel.
+// The entry at the position is the current directory; the entries before it are reached by Back,
+// and the entries after it by Forward, until a new directory is visited.
+type History struct {
+	entries  []Entry
+	position int
+	limit    int
+}
+
+// New creates the History keeping at most limit entries; the oldest entries are dropped first
+func New(limit int) *History {
+	if limit < 2 {
+		// the current directory and the one to go back to
+		limit = 2
+	}
+	return &History{limit: limit}
+}
+
+// Visit records leaving the current directory, with the cursor on current.Selected, for the dir.
+// The entries reachable by Forward are discarded, the same way as in a web browser.
+func (h *History) Visit(current Entry, dir string) {
+	if len(h.entries) == 0 {
+		h.entries = append(h.entries, current)
+	} else {
+		h.entries[h.position] = current
+	}
+	if dir == current.Dir {
+		return
+	}
+
+	h.entries = append(h.entries[:h.position+1], Entry{Dir: dir})
+	if len(h.entries) > h.limit {
+		h.entries = h.entries[len(h.entries)-h.limit:]
+	}
+	h.position = len(h.entries) - 1
+}
+
+// Back records the cursor in the current directory, and returns the previously visited directory;
+// ok is false if there is none
+func (h *History) Back(current Entry) (entry Entry, ok bool) {
+	return h.move(current, -1)
+}
+
+// Forward records the cursor in the current directory, and returns the directory left by Back;
+// ok is false if there is none
+func (h *History) Forward(current Entry) (entry Entry, ok bool) {
+	return h.move(current, 1)
+}
+
+func (h *History) move(current Entry, step int) (Entry, bool) {
+	next := h.position + step
+	if next < 0 || next >= len(h.entries) {
+		return Entry{}, false
+	}
+	h.entries[h.position] = current
+	h.position = next
+	return h.entries[next], true
+}
+
+// Recent returns the visited directories, the most recently added first, each of them listed once
+func (h *History) Recent() []Entry {
+	recent := make([]Entry, 0, len(h.entries))
+	listed := make(map[string]bool)
+	for idx := len(h.entries) - 1; idx >= 0; idx-- {
+		entry := h.entries[idx]
+		if !listed[entry.Dir] {
+			listed[entry.Dir] = true
+			recent = append(recent, entry)
+		}
+	}
+	return recent
+}
+
+// Matching returns the entries whose directory contains the query, ignoring the case; an empty query matches all
+func Matching(entries []Entry, query string) []Entry {
+	query = strings.ToLower(strings.TrimSpace(query))
+	if query == "" {
+		return entries
+	}
+
+	matching := make([]Entry, 0, len(entries))
+	for _, entry := range entries {
+		if strings.Contains(strings.ToLower(entry.Dir), query) {
+			matching = append(matching, entry)
+		}
+	}
+	return matching
+}
@@ -0,0 +1,104 @@
+package history
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBackAndForward(t *testing.T) {
+	h := New(10)
+	if _, ok := h.Back(Entry{Dir: "/"}); ok {
+		t.Fatalf("expected nothing to go back to in the empty history")
+	}
+
+	h.Visit(Entry{Dir: "/", Selected: "home"}, "/home")
+	h.Visit(Entry{Dir: "/home", Selected: "user"}, "/home/user")
+
+	entry, ok := h.Back(Entry{Dir: "/home/user", Selected: "notes.txt"})
+	if !ok || entry != (Entry{Dir: "/home", Selected: "user"}) {
+		t.Errorf("expected to go back to /home with user selected, got %+v, %v", entry, ok)
+	}
+	entry, ok = h.Back(Entry{Dir: "/home", Selected: "other"})
+	if !ok || entry != (Entry{Dir: "/", Selected: "home"}) {
+		t.Errorf("expected to go back to / with home selected, got %+v, %v", entry, ok)
+	}
+	if _, ok = h.Back(entry); ok {
+		t.Errorf("expected nothing to go back to from the oldest entry")
+	}
+
+	entry, ok = h.Forward(Entry{Dir: "/", Selected: "home"})
+	if !ok || entry != (Entry{Dir: "/home", Selected: "other"}) {
+		t.Errorf("expected the cursor recorded by Back, got %+v, %v", entry, ok)
+	}
+	entry, ok = h.Forward(entry)
+	if !ok || entry != (Entry{Dir: "/home/user", Selected: "notes.txt"}) {
+		t.Errorf("expected to go forward to /home/user, got %+v, %v", entry, ok)
+	}
+	if _, ok = h.Forward(entry); ok {
+		t.Errorf("expected nothing to go forward to from the newest entry")
+	}
+}
+
+func TestVisitDiscardsForward(t *testing.T) {
+	h := New(10)
+	h.Visit(Entry{Dir: "/"}, "/home")
+	h.Visit(Entry{Dir: "/home"}, "/home/user")
+	h.Back(Entry{Dir: "/home/user"})
+	h.Visit(Entry{Dir: "/home"}, "/tmp")
+
+	if _, ok := h.Forward(Entry{Dir: "/tmp"}); ok {
+		t.Errorf("expected the visit to discard the entries reachable by Forward")
+	}
+	entry, _ := h.Back(Entry{Dir: "/tmp"})
+	if entry.Dir != "/home" {
+		t.Errorf("expected to go back to /home, got %s", entry.Dir)
+	}
+}
+
+func TestVisitSameDir(t *testing.T) {
+	h := New(10)
+	h.Visit(Entry{Dir: "/"}, "/home")
+	h.Visit(Entry{Dir: "/home", Selected: "user"}, "/home")
+
+	entry, ok := h.Back(Entry{Dir: "/home"})
+	if !ok || entry.Dir != "/" {
+		t.Errorf("expected the refresh of /home not to be recorded, got %+v, %v", entry, ok)
+	}
+}
+
+func TestLimit(t *testing.T) {
+	h := New(3)
+	previous := "/"
+	for _, dir := range []string{"/a", "/b", "/c", "/d"} {
+		h.Visit(Entry{Dir: previous}, dir)
+		previous = dir
+	}
+
+	var dirs []string
+	for entry, ok := h.Back(Entry{Dir: "/d"}); ok; entry, ok = h.Back(entry) {
+		dirs = append(dirs, entry.Dir)
+	}
+	if expected := []string{"/c", "/b"}; !reflect.DeepEqual(dirs, expected) {
+		t.Errorf("expected %v, got %v", expected, dirs)
+	}
+}
+
+func TestRecentAndMatching(t *testing.T) {
+	h := New(10)
+	h.Visit(Entry{Dir: "/"}, "/home")
+	h.Visit(Entry{Dir: "/home"}, "/")
+	h.Visit(Entry{Dir: "/", Selected: "tmp"}, "/tmp")
+
+	recent := h.Recent()
+	expected := []Entry{{Dir: "/tmp"}, {Dir: "/", Selected: "tmp"}, {Dir: "/home"}}
+	if !reflect.DeepEqual(recent, expected) {
+		t.Errorf("expected %+v, got %+v", expected, recent)
+	}
+
+	if matching := Matching(recent, " HOME "); len(matching) != 1 || matching[0].Dir != "/home" {
+		t.Errorf("expected only /home to match, got %+v", matching)
+	}
+	if matching := Matching(recent, ""); len(matching) != len(recent) {
+		t.Errorf("expected the empty query to match all, got %+v", matching)
+	}
+}
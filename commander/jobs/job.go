@@ -0,0 +1,215 @@
+package jobs
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrCancelled is returned by Job.Checkpoint once the job has been cancelled
+var ErrCancelled = errors.New("job cancelled")
+
+// State of a Job
+type State int
+
+const (
+	Queued State = iota
+	Running
+	Paused
+	Done
+	Failed
+	Cancelled
+)
+
+// String of a State
+func (state State) String() string {
+	switch state {
+	case Queued:
+		return "Queued"
+	case Running:
+		return "Running"
+	case Paused:
+		return "Paused"
+	case Done:
+		return "Done"
+	case Failed:
+		return "Failed"
+	case Cancelled:
+		return "Cancelled"
+	default:
+		return fmt.Sprintf("%d", int(state))
+	}
+}
+
+// IsFinal returns true if the job in this state will never run again
+func (state State) IsFinal() bool {
+	return state == Done || state == Failed || state == Cancelled
+}
+
+// Task is the work performed by a Job. It should call Job.Checkpoint regularly to honour pause and cancel requests,
+// and account for the work done via Job.Progress.
+type Task func(job *Job) error
+
+// DoneFunc is called once the job reaches the final state
+type DoneFunc func(job *Job)
+
+// Job is a single unit of work executed by the Manager
+type Job struct {
+	ID       int
+	Title    string
+	Progress *Progress
+
+	task   Task
+	onDone DoneFunc
+
+	mutex     sync.Mutex
+	condition *sync.Cond
+	state     State
+	paused    bool
+	cancelled bool
+	err       error
+}
+
+func newJob(id int, title string, task Task, onDone DoneFunc) *Job {
+	job := &Job{
+		ID:       id,
+		Title:    title,
+		Progress: new(Progress),
+		task:     task,
+		onDone:   onDone,
+		state:    Queued,
+	}
+	job.condition = sync.NewCond(&job.mutex)
+	return job
+}
+
+// State returns the current state of the job
+func (job *Job) State() State {
+	job.mutex.Lock()
+	defer job.mutex.Unlock()
+	return job.state
+}
+
+// Err returns the error the job has finished with
+func (job *Job) Err() error {
+	job.mutex.Lock()
+	defer job.mutex.Unlock()
+	return job.err
+}
+
+// Pause requests the running job to stop at its next Checkpoint, until Resume is called
+func (job *Job) Pause() {
+	job.mutex.Lock()
+	defer job.mutex.Unlock()
+
+	if job.state.IsFinal() || job.paused {
+		return
+	}
+	job.paused = true
+	if job.state == Running {
+		job.state = Paused
+		job.Progress.pause()
+	}
+}
+
+// Resume continues the paused job
+func (job *Job) Resume() {
+	job.mutex.Lock()
+	defer job.mutex.Unlock()
+
+	if !job.paused {
+		return
+	}
+	job.paused = false
+	if job.state == Paused {
+		job.state = Running
+		job.Progress.resume()
+	}
+	job.condition.Broadcast()
+}
+
+// TogglePause pauses the running job, or resumes the paused one
+func (job *Job) TogglePause() {
+	if job.State() == Paused {
+		job.Resume()
+	} else {
+		job.Pause()
+	}
+}
+
+// Cancel requests the job to stop at its next Checkpoint. Queued job is not started at all.
+func (job *Job) Cancel() {
+	job.mutex.Lock()
+	defer job.mutex.Unlock()
+
+	if job.state.IsFinal() {
+		return
+	}
+	job.cancelled = true
+	job.condition.Broadcast()
+}
+
+// Checkpoint blocks while the job is paused, and returns ErrCancelled if the job has been cancelled
+func (job *Job) Checkpoint() error {
+	job.mutex.Lock()
+	defer job.mutex.Unlock()
+
+	for job.paused && !job.cancelled {
+		job.condition.Wait()
+	}
+	if job.cancelled {
+		return ErrCancelled
+	}
+	return nil
+}
+
+// BytesDone accounts for n more bytes processed; together with FileDone and Checkpoint it implements fileops.Monitor
+func (job *Job) BytesDone(n int64) {
+	job.Progress.BytesDone(n)
+}
+
+// FileDone accounts for one more file processed
+func (job *Job) FileDone() {
+	job.Progress.FileDone()
+}
+
+// run executes the task and moves the job into the final state
+func (job *Job) run() {
+	job.mutex.Lock()
+	if job.cancelled {
+		job.state = Cancelled
+		job.mutex.Unlock()
+		job.finish()
+		return
+	}
+	job.Progress.start()
+	job.state = Running
+	if job.paused {
+		// the job was paused while still in the queue
+		job.state = Paused
+		job.Progress.pause()
+	}
+	job.mutex.Unlock()
+
+	err := job.task(job)
+
+	job.mutex.Lock()
+	job.err = err
+	switch {
+	case job.cancelled || errors.Is(err, ErrCancelled):
+		job.state = Cancelled
+	case err != nil:
+		job.state = Failed
+	default:
+		job.state = Done
+	}
+	job.mutex.Unlock()
+	job.finish()
+}
+
+func (job *Job) finish() {
+	job.Progress.finish()
+	if job.onDone != nil {
+		job.onDone(job)
+	}
+}
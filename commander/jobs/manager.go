@@ -0,0 +1,94 @@
+package jobs
+
+import (
+	"sync"
+)
+
+// number of finished jobs kept for the job list
+const maxFinishedJobs = 100
+
+// Manager runs the submitted jobs in the background, with a bounded number of workers
+type Manager struct {
+	queue chan *Job
+
+	mutex  sync.Mutex
+	jobs   []*Job
+	nextId int
+}
+
+// NewManager creates a Manager that runs at most the given number of jobs at the same time
+func NewManager(workers int) *Manager {
+	if workers < 1 {
+		workers = 1
+	}
+
+	manager := &Manager{
+		queue:  make(chan *Job, 1024),
+		nextId: 1,
+	}
+	for i := 0; i < workers; i++ {
+		go manager.work()
+	}
+	return manager
+}
+
+func (m *Manager) work() {
+	for job := range m.queue {
+		job.run()
+	}
+}
+
+// Submit queues the task for execution. onDone, if not nil, is called from the worker goroutine
+// once the job reaches its final state.
+func (m *Manager) Submit(title string, task Task, onDone DoneFunc) *Job {
+	m.mutex.Lock()
+	job := newJob(m.nextId, title, task, onDone)
+	m.nextId++
+	m.jobs = append(m.jobs, job)
+	m.trim()
+	m.mutex.Unlock()
+
+	m.queue <- job
+	return job
+}
+
+// Jobs returns all jobs known to the manager, the most recent ones last
+func (m *Manager) Jobs() []*Job {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	result := make([]*Job, len(m.jobs))
+	copy(result, m.jobs)
+	return result
+}
+
+// Active returns jobs that are queued, running or paused
+func (m *Manager) Active() []*Job {
+	var result []*Job
+	for _, job := range m.Jobs() {
+		if !job.State().IsFinal() {
+			result = append(result, job)
+		}
+	}
+	return result
+}
+
+// trim forgets the oldest finished jobs beyond maxFinishedJobs
+func (m *Manager) trim() {
+	finished := 0
+	for _, job := range m.jobs {
+		if job.State().IsFinal() {
+			finished++
+		}
+	}
+
+	kept := m.jobs[:0]
+	for _, job := range m.jobs {
+		if finished > maxFinishedJobs && job.State().IsFinal() {
+			finished--
+			continue
+		}
+		kept = append(kept, job)
+	}
+	m.jobs = kept
+}
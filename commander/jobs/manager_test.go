@@ -0,0 +1,168 @@
+package jobs
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// helperSubmit submits a task and returns the channel signalled once the job is over
+func helperSubmit(manager *Manager, task Task) (*Job, chan struct{}) {
+	done := make(chan struct{})
+	job := manager.Submit("test", task, func(job *Job) { close(done) })
+	return job, done
+}
+
+func helperWait(t *testing.T, done chan struct{}) {
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("job did not finish in time")
+	}
+}
+
+func TestSubmit(t *testing.T) {
+	manager := NewManager(1)
+	job, done := helperSubmit(manager, func(job *Job) error {
+		job.Progress.SetTotals(2, 100)
+		job.BytesDone(60)
+		job.FileDone()
+		job.BytesDone(40)
+		job.FileDone()
+		return nil
+	})
+	helperWait(t, done)
+
+	if job.State() != Done || job.Err() != nil {
+		t.Errorf("expected Done without error, got %v (%v)", job.State(), job.Err())
+	}
+	if percent := job.Progress.Percent(); percent != 100 {
+		t.Errorf("expected 100%%, got %d", percent)
+	}
+	if filesDone, filesTotal := job.Progress.Files(); filesDone != 2 || filesTotal != 2 {
+		t.Errorf("expected 2/2 files, got %d/%d", filesDone, filesTotal)
+	}
+	if len(manager.Active()) != 0 || len(manager.Jobs()) != 1 {
+		t.Errorf("expected 1 finished job, got %d active of %d", len(manager.Active()), len(manager.Jobs()))
+	}
+}
+
+func TestFailedJob(t *testing.T) {
+	manager := NewManager(1)
+	failure := errors.New("failure")
+	job, done := helperSubmit(manager, func(job *Job) error { return failure })
+	helperWait(t, done)
+
+	if job.State() != Failed || job.Err() != failure {
+		t.Errorf("expected Failed with %v, got %v (%v)", failure, job.State(), job.Err())
+	}
+}
+
+func TestPauseResumeCancel(t *testing.T) {
+	manager := NewManager(1)
+	started := make(chan struct{})
+	proceed := make(chan struct{})
+	checkpoints := make(chan error, 2)
+	job, done := helperSubmit(manager, func(job *Job) error {
+		close(started)
+		<-proceed
+		checkpoints <- job.Checkpoint()
+		<-proceed
+		err := job.Checkpoint()
+		checkpoints <- err
+		return err
+	})
+
+	<-started
+	job.Pause()
+	if job.State() != Paused {
+		t.Fatalf("expected Paused, got %v", job.State())
+	}
+
+	proceed <- struct{}{}
+	select {
+	case <-checkpoints:
+		t.Fatalf("checkpoint of the paused job should block")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	job.TogglePause()
+	if err := <-checkpoints; err != nil {
+		t.Errorf("unexpected error after resume: %v", err)
+	}
+	if job.State() != Running {
+		t.Errorf("expected Running, got %v", job.State())
+	}
+
+	job.Cancel()
+	proceed <- struct{}{}
+	if err := <-checkpoints; err != ErrCancelled {
+		t.Errorf("expected %v, got %v", ErrCancelled, err)
+	}
+	helperWait(t, done)
+	if job.State() != Cancelled {
+		t.Errorf("expected Cancelled, got %v", job.State())
+	}
+}
+
+func TestCancelQueuedJob(t *testing.T) {
+	manager := NewManager(1)
+	release := make(chan struct{})
+	_, firstDone := helperSubmit(manager, func(job *Job) error {
+		<-release
+		return nil
+	})
+
+	executed := false
+	queued, queuedDone := helperSubmit(manager, func(job *Job) error {
+		executed = true
+		return nil
+	})
+	if queued.State() != Queued {
+		t.Fatalf("expected Queued, got %v", queued.State())
+	}
+	if len(manager.Active()) != 2 {
+		t.Errorf("expected 2 active jobs, got %d", len(manager.Active()))
+	}
+
+	queued.Cancel()
+	close(release)
+	helperWait(t, firstDone)
+	helperWait(t, queuedDone)
+
+	if executed || queued.State() != Cancelled {
+		t.Errorf("expected the queued job to be cancelled without running, got %v", queued.State())
+	}
+}
+
+func TestProgressEstimates(t *testing.T) {
+	progress := new(Progress)
+	if progress.ETA() != 0 || progress.Throughput() != 0 || progress.Percent() != 0 {
+		t.Errorf("expected no estimates before the start")
+	}
+
+	progress.SetTotals(0, 1000)
+	progress.start()
+	progress.started = progress.started.Add(-2 * time.Second)
+	progress.BytesDone(250)
+
+	if percent := progress.Percent(); percent != 25 {
+		t.Errorf("expected 25%%, got %d", percent)
+	}
+	throughput := progress.Throughput()
+	if throughput < 100 || throughput > 125 {
+		t.Errorf("expected throughput about 125 bytes/s, got %f", throughput)
+	}
+	eta := progress.ETA()
+	if eta < 5*time.Second || eta > 8*time.Second {
+		t.Errorf("expected ETA about 6s, got %v", eta)
+	}
+
+	// time in pause is not accounted as elapsed
+	progress.pause()
+	progress.pausedAt = progress.pausedAt.Add(-time.Hour)
+	progress.resume()
+	if elapsed := progress.Elapsed(); elapsed > 3*time.Second {
+		t.Errorf("expected pause to be excluded from elapsed time, got %v", elapsed)
+	}
+}
@@ -0,0 +1,134 @@
+package jobs
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Progress tracks the amount of work done by a Job. All methods are safe for concurrent use.
+type Progress struct {
+	bytesDone  int64
+	bytesTotal int64
+	filesDone  int64
+	filesTotal int64
+
+	mutex     sync.Mutex
+	started   time.Time
+	finished  time.Time
+	pausedAt  time.Time     // zero unless the job is paused
+	pausedFor time.Duration // total time spent in pause so far
+}
+
+// SetTotals defines the amount of work to be done; unknown totals are left at 0
+func (p *Progress) SetTotals(files, bytes int64) {
+	atomic.StoreInt64(&p.filesTotal, files)
+	atomic.StoreInt64(&p.bytesTotal, bytes)
+}
+
+// BytesDone accounts for n more bytes processed
+func (p *Progress) BytesDone(n int64) {
+	atomic.AddInt64(&p.bytesDone, n)
+}
+
+// FileDone accounts for one more file processed
+func (p *Progress) FileDone() {
+	atomic.AddInt64(&p.filesDone, 1)
+}
+
+// Bytes returns the number of processed and the total number of bytes
+func (p *Progress) Bytes() (done, total int64) {
+	return atomic.LoadInt64(&p.bytesDone), atomic.LoadInt64(&p.bytesTotal)
+}
+
+// Files returns the number of processed and the total number of files
+func (p *Progress) Files() (done, total int64) {
+	return atomic.LoadInt64(&p.filesDone), atomic.LoadInt64(&p.filesTotal)
+}
+
+// Percent returns the share of the processed bytes (or files, if the bytes total is unknown) in the range 0-100
+func (p *Progress) Percent() int {
+	done, total := p.Bytes()
+	if total == 0 {
+		done, total = p.Files()
+	}
+	if total == 0 {
+		return 0
+	}
+	if done >= total {
+		return 100
+	}
+	return int(done * 100 / total)
+}
+
+// Elapsed returns the time the job has been running, excluding pauses
+func (p *Progress) Elapsed() time.Duration {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.started.IsZero() {
+		return 0
+	}
+
+	end := time.Now()
+	if !p.finished.IsZero() {
+		end = p.finished
+	}
+	if !p.pausedAt.IsZero() {
+		end = p.pausedAt
+	}
+	return end.Sub(p.started) - p.pausedFor
+}
+
+// Throughput returns the average number of bytes processed per second
+func (p *Progress) Throughput() float64 {
+	elapsed := p.Elapsed().Seconds()
+	if elapsed <= 0 {
+		return 0
+	}
+	done, _ := p.Bytes()
+	return float64(done) / elapsed
+}
+
+// ETA returns the estimated time remaining; zero if it can not be estimated
+func (p *Progress) ETA() time.Duration {
+	done, total := p.Bytes()
+	throughput := p.Throughput()
+	if total == 0 || done >= total || throughput <= 0 {
+		return 0
+	}
+	return time.Duration(float64(total-done) / throughput * float64(time.Second))
+}
+
+func (p *Progress) start() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.started = time.Now()
+}
+
+func (p *Progress) finish() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.finished = time.Now()
+	if !p.pausedAt.IsZero() {
+		p.pausedFor += p.finished.Sub(p.pausedAt)
+		p.pausedAt = time.Time{}
+	}
+}
+
+func (p *Progress) pause() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if p.pausedAt.IsZero() {
+		p.pausedAt = time.Now()
+	}
+}
+
+func (p *Progress) resume() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if !p.pausedAt.IsZero() {
+		p.pausedFor += time.Since(p.pausedAt)
+		p.pausedAt = time.Time{}
+	}
+}
@@ -0,0 +1,244 @@
+package journal
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/mushkevych/9ofm/commander/fileops"
+	"github.com/mushkevych/9ofm/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+// Operation is the kind of the recorded file operation
+type Operation string
+
+const (
+	Rename Operation = "rename"
+	Move   Operation = "move"
+	Mkdir  Operation = "mkdir"
+	Trash  Operation = "trash"
+	Copy   Operation = "copy"
+	Delete Operation = "delete"
+	// the file replaced with the hard or the symbolic link to another file
+	Hardlink Operation = "hardlink"
+	Symlink  Operation = "symlink"
+)
+
+// ErrConflict is returned when the operation can not be undone, since the file system has changed since
+var ErrConflict = errors.New("file system has changed since the operation")
+
+// ErrNothingToUndo is returned when the journal holds no operations that can be undone
+var ErrNothingToUndo = errors.New("nothing to undo")
+
+// Entry is a single file operation recorded in the journal
+type Entry struct {
+	Operation Operation `json:"operation"`
+	// Rename, Move, Copy: the original path; Mkdir: the created directory; Trash, Delete: the removed path;
+	// Hardlink, Symlink: the target of the link
+	Source string `json:"source"`
+	// Rename, Move, Copy: the new path; Trash: the location of the item in the trash; Hardlink, Symlink: the link
+	Destination string    `json:"destination,omitempty"`
+	Time        time.Time `json:"time"`
+	Undoable    bool      `json:"undoable"`
+}
+
+// NewEntry creates the journal entry timestamped with the current time.
+// Copy, Delete, Hardlink and Symlink are recorded for the reference only: they can not be undone.
+func NewEntry(operation Operation, source, destination string) Entry {
+	return Entry{
+		Operation:   operation,
+		Source:      source,
+		Destination: destination,
+		Time:        time.Now(),
+		Undoable:    operation == Rename || operation == Move || operation == Mkdir || operation == Trash,
+	}
+}
+
+// String of an Entry
+func (entry Entry) String() string {
+	if entry.Destination == "" {
+		return fmt.Sprintf("%s %s", entry.Operation, entry.Source)
+	}
+	return fmt.Sprintf("%s %s -> %s", entry.Operation, entry.Source, entry.Destination)
+}
+
+// Journal is the file with the history of the file operations, one JSON entry per line, the most recent last
+type Journal struct {
+	path       string
+	maxEntries int
+	mutex      sync.Mutex
+}
+
+// Open returns the journal stored in the file, which is created on the first Record.
+// The journal keeps at most maxEntries of the most recent operations.
+func Open(path string, maxEntries int) *Journal {
+	return &Journal{path: path, maxEntries: maxEntries}
+}
+
+// Record appends the entries to the journal
+func (j *Journal) Record(entries ...Entry) error {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	all, err := j.read()
+	if err != nil {
+		return err
+	}
+	all = append(all, entries...)
+	if j.maxEntries > 0 && len(all) > j.maxEntries {
+		all = all[len(all)-j.maxEntries:]
+	}
+	return j.write(all)
+}
+
+// Entries returns all recorded entries, the most recent last
+func (j *Journal) Entries() ([]Entry, error) {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	return j.read()
+}
+
+// LastUndoable returns at most n of the most recent entries that can be undone, the most recent first
+func (j *Journal) LastUndoable(n int) ([]Entry, error) {
+	entries, err := j.Entries()
+	if err != nil {
+		return nil, err
+	}
+
+	var result []Entry
+	for i := len(entries) - 1; i >= 0 && len(result) < n; i-- {
+		if entries[i].Undoable {
+			result = append(result, entries[i])
+		}
+	}
+	return result, nil
+}
+
+// Undo reverses at most n of the most recent operations that can be undone, the most recent first.
+// It stops at the first operation that can not be reversed, for instance due to ErrConflict.
+// Undone operations are removed from the journal; returns them, the most recent first.
+func (j *Journal) Undo(n int) ([]Entry, error) {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	entries, err := j.read()
+	if err != nil {
+		return nil, err
+	}
+
+	var undone []Entry
+	removed := make(map[int]bool)
+	for i := len(entries) - 1; i >= 0 && len(undone) < n; i-- {
+		if !entries[i].Undoable {
+			continue
+		}
+		if err = undo(entries[i]); err != nil {
+			break
+		}
+		undone = append(undone, entries[i])
+		removed[i] = true
+	}
+	if len(undone) == 0 && err == nil {
+		return nil, ErrNothingToUndo
+	}
+
+	kept := make([]Entry, 0, len(entries)-len(removed))
+	for i, entry := range entries {
+		if !removed[i] {
+			kept = append(kept, entry)
+		}
+	}
+	if writeErr := j.write(kept); err == nil {
+		err = writeErr
+	}
+	return undone, err
+}
+
+// undo reverses the single operation, unless the file system has changed in a conflicting way
+func undo(entry Entry) error {
+	conflict := func(format string, args ...interface{}) error {
+		return fmt.Errorf("cannot undo %s: %s: %w", entry, fmt.Sprintf(format, args...), ErrConflict)
+	}
+
+	switch entry.Operation {
+	case Rename, Move:
+		if _, err := os.Lstat(entry.Destination); err != nil {
+			return conflict("%s no longer exists", entry.Destination)
+		}
+		if _, err := os.Lstat(entry.Source); err == nil {
+			return conflict("%s already exists", entry.Source)
+		}
+		_, err := fileops.Move(entry.Destination, entry.Source, fileops.FixedPolicy(fileops.Abort))
+		return err
+
+	case Mkdir:
+		info, err := os.Lstat(entry.Source)
+		if err != nil || !info.IsDir() {
+			return conflict("%s is no longer a directory", entry.Source)
+		}
+		if content, err := ioutil.ReadDir(entry.Source); err == nil && len(content) > 0 {
+			return conflict("%s is not empty", entry.Source)
+		}
+		return os.Remove(entry.Source)
+
+	case Trash:
+		if _, err := os.Lstat(entry.Destination); err != nil {
+			return conflict("%s is no longer in the trash", entry.Destination)
+		}
+		if _, err := os.Lstat(entry.Source); err == nil {
+			return conflict("%s already exists", entry.Source)
+		}
+		// the trashed item is stored in the "files" subdirectory of the trash
+		trash := &fileops.Trash{Dir: filepath.Dir(filepath.Dir(entry.Destination))}
+		_, err := trash.Restore(filepath.Base(entry.Destination))
+		return err
+
+	default:
+		return fmt.Errorf("cannot undo %s: the operation is irreversible", entry)
+	}
+}
+
+// read loads the journal; a missing file is an empty journal, and malformed lines are skipped
+func (j *Journal) read() ([]Entry, error) {
+	file, err := os.Open(j.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var entry Entry
+		if err = json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			log.WithError(err).Warnf("skipping malformed journal entry: %s", scanner.Text())
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries, scanner.Err()
+}
+
+// write replaces the journal with the entries; the file is replaced atomically
+func (j *Journal) write(entries []Entry) error {
+	return utils.WriteFileAtomic(j.path, 0600, func(w io.Writer) error {
+		encoder := json.NewEncoder(w)
+		for _, entry := range entries {
+			if err := encoder.Encode(entry); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,156 @@
+package journal
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/mushkevych/9ofm/commander/fileops"
+)
+
+func helperWriteFile(t *testing.T, fqfp, content string) {
+	if err := os.MkdirAll(filepath.Dir(fqfp), 0755); err != nil {
+		t.Fatalf("unable to create dir: %v", err)
+	}
+	if err := ioutil.WriteFile(fqfp, []byte(content), 0644); err != nil {
+		t.Fatalf("unable to write file: %v", err)
+	}
+}
+
+func helperAssertExists(t *testing.T, fqfp string, expected bool) {
+	_, err := os.Lstat(fqfp)
+	if exists := err == nil; exists != expected {
+		t.Errorf("%s: expected exists=%v, got %v", fqfp, expected, err)
+	}
+}
+
+func helperOperations(entries []Entry) []Operation {
+	var result []Operation
+	for _, entry := range entries {
+		result = append(result, entry.Operation)
+	}
+	return result
+}
+
+func TestRecordKeepsMostRecent(t *testing.T) {
+	root := t.TempDir()
+	journal := Open(filepath.Join(root, "config", "journal.jsonl"), 2)
+
+	for _, operation := range []Operation{Mkdir, Copy, Rename} {
+		if err := journal.Record(NewEntry(operation, "/a", "/b")); err != nil {
+			t.Fatalf("unable to record: %v", err)
+		}
+	}
+
+	entries, err := journal.Entries()
+	if err != nil {
+		t.Fatalf("unable to read: %v", err)
+	}
+	expected := []Operation{Copy, Rename}
+	if actual := helperOperations(entries); !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected %v, got %v", expected, actual)
+	}
+	if entries[0].Undoable || !entries[1].Undoable || entries[1].Time.IsZero() {
+		t.Errorf("unexpected entries: %+v", entries)
+	}
+}
+
+func TestUndo(t *testing.T) {
+	root := t.TempDir()
+	journal := Open(filepath.Join(root, "journal.jsonl"), 100)
+
+	// mkdir, rename, copy (irreversible) and trash
+	created := filepath.Join(root, "created")
+	if err := os.Mkdir(created, 0755); err != nil {
+		t.Fatalf("unable to mkdir: %v", err)
+	}
+	original := filepath.Join(root, "a.txt")
+	renamed := filepath.Join(root, "b.txt")
+	helperWriteFile(t, renamed, "alpha")
+	trash := &fileops.Trash{Dir: filepath.Join(root, "Trash")}
+	trashed := filepath.Join(root, "c.txt")
+	helperWriteFile(t, trashed, "gamma")
+	name, err := trash.Put(trashed)
+	if err != nil {
+		t.Fatalf("unable to trash: %v", err)
+	}
+
+	err = journal.Record(
+		NewEntry(Mkdir, created, ""),
+		NewEntry(Rename, original, renamed),
+		NewEntry(Copy, renamed, filepath.Join(root, "copy.txt")),
+		NewEntry(Trash, trashed, trash.FilePath(name)),
+	)
+	if err != nil {
+		t.Fatalf("unable to record: %v", err)
+	}
+
+	lastUndoable, err := journal.LastUndoable(10)
+	if err != nil {
+		t.Fatalf("unable to read: %v", err)
+	}
+	expected := []Operation{Trash, Rename, Mkdir}
+	if actual := helperOperations(lastUndoable); !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected %v, got %v", expected, actual)
+	}
+
+	undone, err := journal.Undo(2)
+	if err != nil {
+		t.Fatalf("unable to undo: %v", err)
+	}
+	expected = []Operation{Trash, Rename}
+	if actual := helperOperations(undone); !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected %v undone, got %v", expected, actual)
+	}
+	helperAssertExists(t, trashed, true)
+	helperAssertExists(t, original, true)
+	helperAssertExists(t, renamed, false)
+
+	// the directory is no longer empty
+	helperWriteFile(t, filepath.Join(created, "new.txt"), "new")
+	if _, err = journal.Undo(1); !errors.Is(err, ErrConflict) {
+		t.Errorf("expected %v, got %v", ErrConflict, err)
+	}
+	helperAssertExists(t, created, true)
+
+	if err = os.Remove(filepath.Join(created, "new.txt")); err != nil {
+		t.Fatalf("unable to remove: %v", err)
+	}
+	if _, err = journal.Undo(1); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	helperAssertExists(t, created, false)
+
+	if _, err = journal.Undo(1); err != ErrNothingToUndo {
+		t.Errorf("expected %v, got %v", ErrNothingToUndo, err)
+	}
+	entries, _ := journal.Entries()
+	if actual := helperOperations(entries); !reflect.DeepEqual([]Operation{Copy}, actual) {
+		t.Errorf("expected only the copy to remain, got %v", actual)
+	}
+}
+
+func TestUndoRefusesConflicts(t *testing.T) {
+	root := t.TempDir()
+	journal := Open(filepath.Join(root, "journal.jsonl"), 100)
+	original := filepath.Join(root, "a.txt")
+	moved := filepath.Join(root, "sub", "a.txt")
+	helperWriteFile(t, original, "replacement")
+	helperWriteFile(t, moved, "alpha")
+
+	if err := journal.Record(NewEntry(Move, original, moved)); err != nil {
+		t.Fatalf("unable to record: %v", err)
+	}
+	if _, err := journal.Undo(1); !errors.Is(err, ErrConflict) {
+		t.Errorf("expected %v, got %v", ErrConflict, err)
+	}
+
+	// the refused operation stays in the journal
+	entries, _ := journal.Entries()
+	if len(entries) != 1 {
+		t.Errorf("expected the entry to be kept, got %v", entries)
+	}
+}
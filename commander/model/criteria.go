@@ -0,0 +1,94 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Criteria selects the properties compared by FileInfo.CompareWith.
+// Files of different types, such as a file and a directory, always differ.
+type Criteria struct {
+	Size    bool // size of anything but directories
+	ModTime bool // modification time of anything but directories, see ModTimeTolerance
+	Perms   bool // permission bits, including setuid, setgid and sticky
+	Owner   bool // uid and gid
+	Link    bool // target of the symbolic links
+	Content bool // hash of the content of the regular files; files of different size differ in content as well
+
+	// the largest difference of the modification times still considered equal, such as 2s for FAT file systems
+	ModTimeTolerance time.Duration
+}
+
+// names of the criteria, in the order they are listed
+var criteriaNames = []string{"size", "mtime", "perms", "owner", "link", "hash"}
+
+// DefaultCriteria returns the criteria of the comparison unless configured otherwise: size, permissions and ownership.
+// The content is left out, since hashing the files is expensive; "hash" in compare.criteria or the criteria dialog adds it.
+func DefaultCriteria() Criteria {
+	return Criteria{Size: true, Perms: true, Owner: true}
+}
+
+// ParseCriteria converts the names of the criteria into the Criteria, e.g. ["size", "mtime", "hash"].
+// The names are: size, mtime, perms, owner, link and hash; blank names are skipped.
+func ParseCriteria(names []string, modTimeTolerance time.Duration) (Criteria, error) {
+	criteria := Criteria{ModTimeTolerance: modTimeTolerance}
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		flag := criteria.flag(name)
+		if flag == nil {
+			return Criteria{}, fmt.Errorf("unknown comparison criterion: %s (expected one of %s)",
+				name, strings.Join(criteriaNames, ", "))
+		}
+		*flag = true
+	}
+	return criteria, nil
+}
+
+// flag returns the field of the criterion with the name, or nil if there is no such criterion
+func (c *Criteria) flag(name string) *bool {
+	switch name {
+	case "size":
+		return &c.Size
+	case "mtime":
+		return &c.ModTime
+	case "perms":
+		return &c.Perms
+	case "owner":
+		return &c.Owner
+	case "link":
+		return &c.Link
+	case "hash":
+		return &c.Content
+	default:
+		return nil
+	}
+}
+
+// Names returns the names of the selected criteria
+func (c Criteria) Names() []string {
+	var names []string
+	for _, name := range criteriaNames {
+		if *c.flag(name) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// String of the Criteria, e.g. "size, mtime (±2s), hash"
+func (c Criteria) String() string {
+	names := c.Names()
+	for idx, name := range names {
+		if name == "mtime" && c.ModTimeTolerance > 0 {
+			names[idx] = fmt.Sprintf("mtime (±%s)", c.ModTimeTolerance)
+		}
+	}
+	if len(names) == 0 {
+		return "file type only"
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,87 @@
+package model
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestParseCriteria(t *testing.T) {
+	criteria, err := ParseCriteria([]string{"size", " MTime", "", "hash"}, 2*time.Second)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := Criteria{Size: true, ModTime: true, Content: true, ModTimeTolerance: 2 * time.Second}
+	if criteria != expected {
+		t.Errorf("expected %+v, got %+v", expected, criteria)
+	}
+	if actual := criteria.String(); actual != "size, mtime (±2s), hash" {
+		t.Errorf("unexpected string: %q", actual)
+	}
+
+	if _, err = ParseCriteria([]string{"size", "color"}, 0); err == nil {
+		t.Errorf("expected an error for the unknown criterion")
+	}
+
+	if actual := (Criteria{}).String(); actual != "file type only" {
+		t.Errorf("unexpected string of no criteria: %q", actual)
+	}
+}
+
+func TestCompareWithCriteria(t *testing.T) {
+	now := time.Now()
+	base := FileInfo{Fqfp: "/a/file", Size: 10, Mode: 0644, ModTime: now, Uid: "1000", Gid: "1000"}
+
+	cases := []struct {
+		name     string
+		change   func(info *FileInfo)
+		criteria Criteria
+		expected DiffType
+	}{
+		{"equal", func(info *FileInfo) {}, DefaultCriteria(), Unmodified},
+		{"size", func(info *FileInfo) { info.Size = 11 }, Criteria{Size: true}, Modified},
+		{"size ignored", func(info *FileInfo) { info.Size = 11 }, Criteria{Perms: true}, Unmodified},
+		{"size differs in content", func(info *FileInfo) { info.Size = 11 }, Criteria{Content: true}, Modified},
+		{"mtime", func(info *FileInfo) { info.ModTime = now.Add(time.Minute) }, Criteria{ModTime: true}, Modified},
+		{"mtime within tolerance", func(info *FileInfo) { info.ModTime = now.Add(-time.Second) },
+			Criteria{ModTime: true, ModTimeTolerance: 2 * time.Second}, Unmodified},
+		{"mtime ignored", func(info *FileInfo) { info.ModTime = now.Add(time.Minute) }, DefaultCriteria(), Unmodified},
+		{"perms", func(info *FileInfo) { info.Mode = 0600 }, Criteria{Perms: true}, Modified},
+		{"perms ignored", func(info *FileInfo) { info.Mode = 0600 }, Criteria{Size: true}, Unmodified},
+		{"owner", func(info *FileInfo) { info.Gid = "0" }, Criteria{Owner: true}, Modified},
+		{"type", func(info *FileInfo) { info.Mode = os.ModeDir | 0644 }, Criteria{}, Modified},
+		{"content", func(info *FileInfo) { info.hash, info.hashed = 1, true }, Criteria{Content: true}, Modified},
+	}
+	for _, test := range cases {
+		other := base
+		test.change(&other)
+		if actual := base.CompareWith(other, test.criteria); actual != test.expected {
+			t.Errorf("%s: expected %v, got %v", test.name, test.expected, actual)
+		}
+	}
+}
+
+func TestCompareWithLinkTarget(t *testing.T) {
+	link := FileInfo{Fqfp: "/a/link", Mode: os.ModeSymlink | 0777, LinkTarget: "file"}
+	other := link
+	other.LinkTarget = "other"
+
+	if actual := link.CompareWith(other, DefaultCriteria()); actual != Unmodified {
+		t.Errorf("expected the link target to be ignored by default, got %v", actual)
+	}
+	if actual := link.CompareWith(other, Criteria{Link: true}); actual != Modified {
+		t.Errorf("expected the links to differ by the target, got %v", actual)
+	}
+}
+
+func TestCompareDirectoriesIgnoreSizeAndModTime(t *testing.T) {
+	dir := FileInfo{Fqfp: "/a/dir", Size: 4096, Mode: os.ModeDir | 0755, ModTime: time.Now()}
+	other := dir
+	other.Size = 8192
+	other.ModTime = dir.ModTime.Add(time.Hour)
+
+	criteria := Criteria{Size: true, ModTime: true, Perms: true}
+	if actual := dir.CompareWith(other, criteria); actual != Unmodified {
+		t.Errorf("expected directories to be compared by their content only, got %v", actual)
+	}
+}
@@ -0,0 +1,233 @@
+package model
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+)
+
+const (
+	// size of a single block cached by the FileBuffer
+	fileBufferBlockSize = 64 * 1024
+
+	// maximum number of blocks kept in memory by the FileBuffer
+	fileBufferMaxBlocks = 16
+
+	// maximum number of bytes scanned backwards while looking for the beginning of a line
+	maxLineScan = 16 * 1024
+)
+
+// ErrNotFound is returned by FileBuffer.Search when the pattern is absent in the searched direction
+var ErrNotFound = errors.New("pattern not found")
+
+// FileBuffer provides random access to the file content without loading the whole file into memory.
+// Content is read lazily in fixed-size blocks, and only a bounded number of recently used blocks is kept.
+type FileBuffer struct {
+	file *os.File
+	size int64
+
+	blocks map[int64][]byte // block index -> block content
+	lru    []int64          // block indexes, the most recently used is the last one
+}
+
+// NewFileBuffer opens the file at the given fully qualified file path for lazy reading
+func NewFileBuffer(fqfp string) (*FileBuffer, error) {
+	file, err := os.Open(fqfp)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+
+	return &FileBuffer{
+		file:   file,
+		size:   info.Size(),
+		blocks: make(map[int64][]byte),
+		lru:    make([]int64, 0, fileBufferMaxBlocks),
+	}, nil
+}
+
+// Close releases the underlying file descriptor and the cached blocks
+func (fb *FileBuffer) Close() error {
+	fb.blocks = make(map[int64][]byte)
+	fb.lru = fb.lru[:0]
+	return fb.file.Close()
+}
+
+// Size returns the size of the file in bytes
+func (fb *FileBuffer) Size() int64 {
+	return fb.size
+}
+
+// block returns the content of the block with the given index, reading it from the disk if necessary
+func (fb *FileBuffer) block(index int64) ([]byte, error) {
+	if data, ok := fb.blocks[index]; ok {
+		fb.touch(index)
+		return data, nil
+	}
+
+	data := make([]byte, fileBufferBlockSize)
+	n, err := fb.file.ReadAt(data, index*fileBufferBlockSize)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	data = data[:n]
+
+	if len(fb.lru) >= fileBufferMaxBlocks {
+		// evict the least recently used block
+		delete(fb.blocks, fb.lru[0])
+		fb.lru = fb.lru[1:]
+	}
+	fb.blocks[index] = data
+	fb.lru = append(fb.lru, index)
+	return data, nil
+}
+
+// touch marks the block with the given index as the most recently used
+func (fb *FileBuffer) touch(index int64) {
+	for i, idx := range fb.lru {
+		if idx == index {
+			fb.lru = append(fb.lru[:i], fb.lru[i+1:]...)
+			break
+		}
+	}
+	fb.lru = append(fb.lru, index)
+}
+
+// ReadAt reads up to len(p) bytes starting at the given offset. It implements io.ReaderAt.
+func (fb *FileBuffer) ReadAt(p []byte, offset int64) (int, error) {
+	if offset < 0 {
+		return 0, errors.New("negative offset")
+	}
+
+	read := 0
+	for read < len(p) {
+		position := offset + int64(read)
+		if position >= fb.size {
+			return read, io.EOF
+		}
+
+		data, err := fb.block(position / fileBufferBlockSize)
+		if err != nil {
+			return read, err
+		}
+
+		shift := int(position % fileBufferBlockSize)
+		if shift >= len(data) {
+			// file was truncated after it was opened
+			return read, io.EOF
+		}
+		read += copy(p[read:], data[shift:])
+	}
+	return read, nil
+}
+
+// Slice returns at most length bytes starting at the given offset
+func (fb *FileBuffer) Slice(offset int64, length int) ([]byte, error) {
+	if offset >= fb.size || length <= 0 {
+		return []byte{}, nil
+	}
+	if remaining := fb.size - offset; int64(length) > remaining {
+		length = int(remaining)
+	}
+
+	data := make([]byte, length)
+	n, err := fb.ReadAt(data, offset)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	return data[:n], nil
+}
+
+// LineStart returns the offset of the first byte of the line, containing the given offset.
+// Lines longer than maxLineScan bytes are split at the maxLineScan boundary.
+func (fb *FileBuffer) LineStart(offset int64) (int64, error) {
+	if offset <= 0 {
+		return 0, nil
+	}
+	if offset > fb.size {
+		offset = fb.size
+	}
+
+	from := offset - maxLineScan
+	if from < 0 {
+		from = 0
+	}
+
+	data, err := fb.Slice(from, int(offset-from))
+	if err != nil {
+		return 0, err
+	}
+
+	if idx := bytes.LastIndexByte(data, '\n'); idx >= 0 {
+		return from + int64(idx) + 1, nil
+	}
+	return from, nil
+}
+
+// NextLineStart returns the offset of the first byte after the end of the line, containing the given offset.
+// Returns the file size if the line is the last one.
+func (fb *FileBuffer) NextLineStart(offset int64) (int64, error) {
+	for position := offset; position < fb.size; position += maxLineScan {
+		data, err := fb.Slice(position, maxLineScan)
+		if err != nil {
+			return 0, err
+		}
+
+		if idx := bytes.IndexByte(data, '\n'); idx >= 0 {
+			return position + int64(idx) + 1, nil
+		}
+	}
+	return fb.size, nil
+}
+
+// Search looks for the pattern starting at the given offset.
+// When forward is true the search goes towards the end of the file and the match may begin at the offset;
+// otherwise it goes towards the beginning of the file and the match must begin before the offset.
+// Returns the offset of the first byte of the match, or ErrNotFound.
+func (fb *FileBuffer) Search(pattern []byte, offset int64, forward bool) (int64, error) {
+	if len(pattern) == 0 {
+		return 0, ErrNotFound
+	}
+
+	// consecutive windows overlap, so that a match on the window boundary is not missed
+	overlap := int64(len(pattern) - 1)
+	window := int64(fileBufferBlockSize)
+
+	if forward {
+		for position := offset; position < fb.size; position += window {
+			data, err := fb.Slice(position, int(window+overlap))
+			if err != nil {
+				return 0, err
+			}
+			if idx := bytes.Index(data, pattern); idx >= 0 {
+				return position + int64(idx), nil
+			}
+		}
+		return 0, ErrNotFound
+	}
+
+	for end := offset + overlap; end > overlap; end -= window {
+		if end > fb.size {
+			end = fb.size
+		}
+		from := end - window - overlap
+		if from < 0 {
+			from = 0
+		}
+
+		data, err := fb.Slice(from, int(end-from))
+		if err != nil {
+			return 0, err
+		}
+		if idx := bytes.LastIndex(data, pattern); idx >= 0 && from+int64(idx) < offset {
+			return from + int64(idx), nil
+		}
+	}
+	return 0, ErrNotFound
+}
@@ -0,0 +1,119 @@
+package model
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func helperCreateFileBuffer(t *testing.T, content []byte) *FileBuffer {
+	file, err := ioutil.TempFile("", "9ofm-file-buffer")
+	if err != nil {
+		t.Fatalf("unable to create temp file: %v", err)
+	}
+	t.Cleanup(func() { _ = os.Remove(file.Name()) })
+
+	if _, err = file.Write(content); err != nil {
+		t.Fatalf("unable to write temp file: %v", err)
+	}
+	_ = file.Close()
+
+	fb, err := NewFileBuffer(file.Name())
+	if err != nil {
+		t.Fatalf("unable to open file buffer: %v", err)
+	}
+	t.Cleanup(func() { _ = fb.Close() })
+	return fb
+}
+
+func TestFileBufferReadAcrossBlocks(t *testing.T) {
+	content := bytes.Repeat([]byte("0123456789abcdef"), fileBufferBlockSize*(fileBufferMaxBlocks+2)/16)
+	fb := helperCreateFileBuffer(t, content)
+
+	if fb.Size() != int64(len(content)) {
+		t.Fatalf("expected size %d, got %d", len(content), fb.Size())
+	}
+
+	for _, offset := range []int64{0, fileBufferBlockSize - 3, int64(len(content)) - 5, 7} {
+		actual, err := fb.Slice(offset, 10)
+		if err != nil {
+			t.Fatalf("unexpected error at offset %d: %v", offset, err)
+		}
+
+		end := offset + 10
+		if end > int64(len(content)) {
+			end = int64(len(content))
+		}
+		if !bytes.Equal(actual, content[offset:end]) {
+			t.Errorf("offset %d: expected %q, got %q", offset, content[offset:end], actual)
+		}
+	}
+
+	if len(fb.blocks) > fileBufferMaxBlocks {
+		t.Errorf("expected at most %d cached blocks, got %d", fileBufferMaxBlocks, len(fb.blocks))
+	}
+}
+
+func TestFileBufferLines(t *testing.T) {
+	fb := helperCreateFileBuffer(t, []byte("first\nsecond\n\nfourth"))
+
+	// format: offset: {expected LineStart, expected NextLineStart}
+	fixture := map[int64][2]int64{
+		0:  {0, 6},
+		3:  {0, 6},
+		6:  {6, 13},
+		12: {6, 13},
+		13: {13, 14},
+		16: {14, 20},
+	}
+
+	for offset, expected := range fixture {
+		lineStart, err := fb.LineStart(offset)
+		if err != nil || lineStart != expected[0] {
+			t.Errorf("LineStart(%d): expected %d, got %d (%v)", offset, expected[0], lineStart, err)
+		}
+		nextLineStart, err := fb.NextLineStart(offset)
+		if err != nil || nextLineStart != expected[1] {
+			t.Errorf("NextLineStart(%d): expected %d, got %d (%v)", offset, expected[1], nextLineStart, err)
+		}
+	}
+}
+
+func TestFileBufferSearch(t *testing.T) {
+	content := bytes.Repeat([]byte{'.'}, 3*fileBufferBlockSize)
+	// place the needles on the block boundaries
+	copy(content[fileBufferBlockSize-2:], "needle")
+	copy(content[2*fileBufferBlockSize-3:], "needle")
+	fb := helperCreateFileBuffer(t, content)
+
+	first := int64(fileBufferBlockSize - 2)
+	second := int64(2*fileBufferBlockSize - 3)
+
+	offset, err := fb.Search([]byte("needle"), 0, true)
+	if err != nil || offset != first {
+		t.Errorf("expected forward match at %d, got %d (%v)", first, offset, err)
+	}
+
+	offset, err = fb.Search([]byte("needle"), first+1, true)
+	if err != nil || offset != second {
+		t.Errorf("expected forward match at %d, got %d (%v)", second, offset, err)
+	}
+
+	offset, err = fb.Search([]byte("needle"), fb.Size(), false)
+	if err != nil || offset != second {
+		t.Errorf("expected backward match at %d, got %d (%v)", second, offset, err)
+	}
+
+	offset, err = fb.Search([]byte("needle"), second, false)
+	if err != nil || offset != first {
+		t.Errorf("expected backward match at %d, got %d (%v)", first, offset, err)
+	}
+
+	if _, err = fb.Search([]byte("needle"), first, false); err != ErrNotFound {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+	if _, err = fb.Search([]byte("haystack"), 0, true); err != ErrNotFound {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
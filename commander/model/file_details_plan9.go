@@ -17,3 +17,11 @@ func GetXid(info os.FileInfo) (string, string) {
 	}
 	return UID, GID
 }
+
+// GetFileId returns the device (the server type and its instance) and the unique id of the file, or zeros if they are unknown
+func GetFileId(info os.FileInfo) (uint64, uint64) {
+	if stat, ok := info.Sys().(*syscall.Dir); ok {
+		return uint64(stat.Type)<<32 | uint64(stat.Dev), stat.Qid.Path
+	}
+	return 0, 0
+}
@@ -18,3 +18,11 @@ func GetXid(info os.FileInfo) (string, string) {
 	}
 	return UID, GID
 }
+
+// GetFileId returns the device and the inode numbers of the file, or zeros if they are unknown
+func GetFileId(info os.FileInfo) (uint64, uint64) {
+	if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+		return uint64(stat.Dev), uint64(stat.Ino)
+	}
+	return 0, 0
+}
@@ -5,42 +5,54 @@ package model
 import (
 	"fmt"
 	"github.com/cespare/xxhash"
-	log "github.com/sirupsen/logrus"
+	"github.com/mushkevych/9ofm/commander/fileops"
+	"github.com/mushkevych/9ofm/commander/hashcache"
 	"io"
 	"os"
+	"time"
 )
 
 // FileInfo contains tar metadata for a specific FileNode
 type FileInfo struct {
 	// fully qualified file path: slash-delimited string from the root ('/') to the desired node (e.g. '/a/node/fqfp')
-	Fqfp     string
-	Linkname string
-	hash     uint64
-	Size     int64
-	Mode     os.FileMode
-	Uid      string // User Id - owner of the file
-	Gid      string // Group Id - owner of the file
-	Err      error  // error discovered while retrieving metadata about this file, such as Insufficient Permission
+	Fqfp       string
+	Linkname   string
+	LinkTarget string // target of the symbolic link; empty for other files
+	hash       uint64
+	hashed     bool // hash is computed lazily, see ComputeHash
+	Size       int64
+	Mode       os.FileMode
+	ModTime    time.Time
+	Uid        string // User Id - owner of the file
+	Gid        string // Group Id - owner of the file
+	Err        error  // error discovered while retrieving metadata about this file, such as Insufficient Permission
+	dev        uint64 // device and inode identify the file in the hash cache; zero if unknown
+	inode      uint64
 }
 
 // NewFileInfo extracts the metadata from the info and file contents and generates a new FileInfo object.
 func NewFileInfo(fqfp string, info os.FileInfo, err error) FileInfo {
 	UID, GID := GetXid(info)
+	dev, inode := GetFileId(info)
 
-	var hash uint64 = 0
-	//if !info.IsDir() {
-	//	hash = computeFileHash(fqfp)
-	//}
+	var linkTarget string
+	if info.Mode()&os.ModeSymlink != 0 {
+		// the target is compared when requested by Criteria.Link; unreadable one is left empty
+		linkTarget, _ = os.Readlink(fqfp)
+	}
 
 	return FileInfo{
-		Fqfp:     fqfp,
-		Linkname: info.Name(),
-		hash:     hash,
-		Size:     info.Size(),
-		Mode:     info.Mode(),
-		Uid:      UID,
-		Gid:      GID,
-		Err:      err,
+		Fqfp:       fqfp,
+		Linkname:   info.Name(),
+		LinkTarget: linkTarget,
+		Size:       info.Size(),
+		Mode:       info.Mode(),
+		ModTime:    info.ModTime(),
+		Uid:        UID,
+		Gid:        GID,
+		Err:        err,
+		dev:        dev,
+		inode:      inode,
 	}
 }
 
@@ -58,55 +70,146 @@ func (info *FileInfo) Clone() *FileInfo {
 		return nil
 	}
 	return &FileInfo{
-		Fqfp:     info.Fqfp,
-		Linkname: info.Linkname,
-		hash:     info.hash,
-		Size:     info.Size,
-		Mode:     info.Mode,
-		Uid:      info.Uid,
-		Gid:      info.Gid,
-		Err:      info.Err,
+		Fqfp:       info.Fqfp,
+		Linkname:   info.Linkname,
+		LinkTarget: info.LinkTarget,
+		hash:       info.hash,
+		hashed:     info.hashed,
+		Size:       info.Size,
+		Mode:       info.Mode,
+		ModTime:    info.ModTime,
+		Uid:        info.Uid,
+		Gid:        info.Gid,
+		Err:        info.Err,
+		dev:        info.dev,
+		inode:      info.inode,
 	}
 }
 
-// Compare determines the DiffType between two FileInfos based on the type and contents of each given FileInfo
+// Compare determines the DiffType between two FileInfos by the DefaultCriteria and by the content.
+// Regular files of different size are always modified; the content is compared only if the hashes are computed.
 func (info *FileInfo) Compare(other FileInfo) DiffType {
-	if info.Mode == other.Mode {
-		if info.hash == other.hash &&
-			info.Mode == other.Mode &&
-			info.Uid == other.Uid &&
-			info.Gid == other.Gid {
-			return Unmodified
+	criteria := DefaultCriteria()
+	criteria.Content = true
+	return info.CompareWith(other, criteria)
+}
+
+// CompareWith determines the DiffType between two FileInfos by the properties selected by the criteria.
+// Files of different types are always modified.
+func (info *FileInfo) CompareWith(other FileInfo, criteria Criteria) DiffType {
+	if info.Mode&os.ModeType != other.Mode&os.ModeType {
+		return Modified
+	}
+	isDir := info.Mode.IsDir()
+	if (criteria.Size || criteria.Content && info.Mode.IsRegular()) && !isDir && info.Size != other.Size {
+		return Modified
+	}
+	if criteria.ModTime && !isDir {
+		delta := info.ModTime.Sub(other.ModTime)
+		if delta < 0 {
+			delta = -delta
+		}
+		if delta > criteria.ModTimeTolerance {
+			return Modified
 		}
 	}
-	return Modified
+	if criteria.Perms && info.Mode != other.Mode {
+		return Modified
+	}
+	if criteria.Owner && (info.Uid != other.Uid || info.Gid != other.Gid) {
+		return Modified
+	}
+	if criteria.Link && info.LinkTarget != other.LinkTarget {
+		return Modified
+	}
+	if criteria.Content && info.hash != other.hash {
+		return Modified
+	}
+	return Unmodified
+}
+
+// FileId returns the device and the inode of the file; zero if unknown.
+// Hard links to the same file share them.
+func (info *FileInfo) FileId() (dev, inode uint64) {
+	return info.dev, info.inode
+}
+
+// Hashed returns true if the hash of the file content has been computed
+func (info *FileInfo) Hashed() bool {
+	return info.hashed
+}
+
+// Hash returns the hash of the file content; zero unless Hashed
+func (info *FileInfo) Hash() uint64 {
+	return info.hash
 }
 
-func computeFileHash(fqfp string) uint64 {
+// ComputeHash computes the hash of the file content, unless it is known already or found in the hash cache;
+// only regular files are hashed.
+// The read error is recorded in Err. Returns the error of the monitor's Checkpoint, which stops the hashing.
+func (info *FileInfo) ComputeHash(monitor fileops.Monitor) error {
+	if info.hashed || !info.Mode.IsRegular() {
+		return nil
+	}
+
+	key := hashcache.Key{Dev: info.dev, Inode: info.inode, Size: info.Size, ModTime: info.ModTime.UnixNano()}
+	cacheable := hashCache != nil && info.inode != 0
+	if cacheable {
+		if hash, ok := hashCache.Get(key); ok {
+			info.hash = hash
+			info.hashed = true
+			monitor.BytesDone(info.Size)
+			return nil
+		}
+	}
+
+	hash, stopErr, readErr := computeFileHash(info.Fqfp, monitor)
+	if stopErr != nil {
+		return stopErr
+	}
+	if readErr != nil {
+		info.Err = readErr
+		return nil
+	}
+	info.hash = hash
+	info.hashed = true
+	if cacheable {
+		hashCache.Put(key, hash)
+	}
+	return nil
+}
+
+// computeFileHash reads the file and returns its xxhash. The error of the monitor's Checkpoint is returned
+// as stopErr, the error of reading the file as readErr.
+func computeFileHash(fqfp string, monitor fileops.Monitor) (hash uint64, stopErr, readErr error) {
 	//Open the passed argument and check for any error
 	file, err := os.Open(fqfp)
 	if err != nil {
-		return 0
+		return 0, nil, err
 	}
 	defer file.Close()
 
 	h := xxhash.New()
-	buf := make([]byte, 1024)
+	buf := make([]byte, hashBufferSize)
 
 	for {
+		if err = monitor.Checkpoint(); err != nil {
+			return 0, err, nil
+		}
+
 		n, err := file.Read(buf)
-		if err != nil && err != io.EOF {
-			log.Panic(err)
+		if n > 0 {
+			// hash.Hash never returns an error
+			_, _ = h.Write(buf[:n])
+			monitor.BytesDone(int64(n))
 		}
-		if n == 0 {
+		if err == io.EOF {
 			break
 		}
-
-		_, err = h.Write(buf[:n])
 		if err != nil {
-			log.Panic(err)
+			return 0, nil, err
 		}
 	}
 
-	return h.Sum64()
+	return h.Sum64(), nil, nil
 }
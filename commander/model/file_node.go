@@ -221,8 +221,8 @@ func (node *FileNode) AssignDiffType(diffType DiffType) error {
 	return nil
 }
 
-// compare the current node against the given node, returning a definitive DiffType.
-func (node *FileNode) compare(other *FileNode) DiffType {
+// compare the current node against the given node by the criteria, returning a definitive DiffType.
+func (node *FileNode) compare(other *FileNode, criteria Criteria) DiffType {
 	if node == nil && other == nil {
 		return Unmodified
 	}
@@ -239,5 +239,5 @@ func (node *FileNode) compare(other *FileNode) DiffType {
 		panic("comparing mismatched nodes")
 	}
 
-	return node.Data.FileInfo.Compare(other.Data.FileInfo)
+	return node.Data.FileInfo.CompareWith(other.Data.FileInfo, criteria)
 }
@@ -102,6 +102,18 @@ func (tree *FileTreeModel) GetNodeByName(name string) *FileNode {
 	return nil
 }
 
+// PwdChildren returns FileNodes in the PWD sorted by name; the ".." parent reference is not included
+func (tree *FileTreeModel) PwdChildren() []*FileNode {
+	var nodes []*FileNode
+	for _, name := range tree.sortedNamesInPwd() {
+		if name == ".." {
+			continue
+		}
+		nodes = append(nodes, tree.pwd.Children[name])
+	}
+	return nodes
+}
+
 func (tree *FileTreeModel) VisibleSize() int {
 	if tree.pwd != tree.Root {
 		// +1 includes ".." parent reference
@@ -279,16 +291,29 @@ func (tree *FileTreeModel) RemovePath(fqfp string) error {
 	return node.Remove()
 }
 
-// CompareAndMark compares this treeA and treeB by performing two passages:
-// 1: iterate over nodes in A and if they are absent in B - mark them as "deleted"
-// 2: iterate over nodes in B and if they are absent in A - mark them as "added"
+// CompareAndMark compares the content of the PWD of treeA and treeB by performing two passages:
+// 1: iterate over nodes in A and if they are absent in B - mark them as "added" in A
+// 2: iterate over nodes in B and if they are absent in A - mark them as "added" in B
 // *: rest of the nodes are compared for being "modified"
+// Nodes are matched by their path relative to the PWD, hence trees of two different directories can be compared;
+// marks of the previous comparison are cleared.
 // NOTE: for every "added", "deleted" or "modified" node -  all their parents are marked as well as "modified"
 func CompareAndMark(treeA, treeB *FileTreeModel) error {
+	criteria := DefaultCriteria()
+	criteria.Content = true
+	return CompareAndMarkWith(treeA, treeB, criteria)
+}
+
+// CompareAndMarkWith compares the content of the PWD of treeA and treeB like CompareAndMark does,
+// matching the files by the properties selected by the criteria.
+func CompareAndMarkWith(treeA, treeB *FileTreeModel, criteria Criteria) error {
+	treeA.clearDiffTypes()
+	treeB.clearDiffTypes()
+
 	comparator := func(left, right *FileTreeModel) error {
 		visitor := func(rightNode *FileNode) error {
-			leftNode, _ := left.GetNode(rightNode.AbsPath())
-			diffType := leftNode.compare(rightNode)
+			leftNode := right.counterpart(rightNode, left)
+			diffType := leftNode.compare(rightNode, criteria)
 			if diffType != Unmodified {
 				rightNode.Data.DiffType = diffType
 				err := markParentsModified(rightNode)
@@ -299,7 +324,7 @@ func CompareAndMark(treeA, treeB *FileTreeModel) error {
 			return nil
 		}
 
-		err := right.DepthFirstSearch(visitor, nil)
+		err := right.pwd.DepthFirstSearch(visitor, right.isBelowPwd)
 		if err != nil {
 			return err
 		}
@@ -318,6 +343,49 @@ func CompareAndMark(treeA, treeB *FileTreeModel) error {
 	return nil
 }
 
+// DiffTypeCounts returns the number of files and directories under the PWD for every DiffType
+func (tree *FileTreeModel) DiffTypeCounts() map[DiffType]int {
+	counts := make(map[DiffType]int)
+	_ = tree.pwd.DepthFirstSearch(func(node *FileNode) error {
+		counts[node.Data.DiffType]++
+		return nil
+	}, tree.isBelowPwd)
+	return counts
+}
+
+// isBelowPwd is the VisitEvaluator for the nodes under the PWD, excluding the PWD itself
+func (tree *FileTreeModel) isBelowPwd(node *FileNode) bool {
+	return node != tree.pwd
+}
+
+// counterpart returns the node of the other tree, whose path relative to the other PWD matches
+// the path of the given node relative to this PWD; returns nil if there is no such node
+func (tree *FileTreeModel) counterpart(node *FileNode, other *FileTreeModel) *FileNode {
+	var names []string
+	for ; node != nil && node != tree.pwd; node = node.Parent {
+		names = append(names, node.Name)
+	}
+	if node == nil {
+		// the node is outside of the PWD
+		return nil
+	}
+
+	result := other.pwd
+	for i := len(names) - 1; i >= 0 && result != nil; i-- {
+		result = result.Children[names[i]]
+	}
+	return result
+}
+
+// clearDiffTypes marks all nodes of the tree as Unmodified
+func (tree *FileTreeModel) clearDiffTypes() {
+	tree.Root.Data.DiffType = Unmodified
+	_ = tree.DepthFirstSearch(func(node *FileNode) error {
+		node.Data.DiffType = Unmodified
+		return nil
+	}, nil)
+}
+
 // markParentsModified traverses all parents and mark them as "modified"
 func markParentsModified(node *FileNode) error {
 	for parent := node.Parent; parent != nil; parent = parent.Parent {
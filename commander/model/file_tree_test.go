@@ -2,6 +2,8 @@ package model
 
 import (
 	"fmt"
+	"os"
+	"reflect"
 	"strings"
 	"testing"
 )
@@ -600,6 +602,78 @@ func TestCompareWithRemoves(t *testing.T) {
 	}
 }
 
+func TestCompareDifferentDirectories(t *testing.T) {
+	tree := NewFileTreeModel()
+	fixtures := []struct {
+		fqfp string
+		info FileInfo
+	}{
+		{"/home", FileInfo{Mode: os.ModeDir}},
+		{"/home/alpha", FileInfo{Mode: os.ModeDir}},
+		{"/home/alpha/notes.txt", FileInfo{hash: 123}},
+		{"/home/alpha/only-alpha", FileInfo{hash: 123}},
+		{"/home/alpha/changed", FileInfo{hash: 123}},
+		{"/home/alpha/docs", FileInfo{Mode: os.ModeDir}},
+		{"/home/beta", FileInfo{Mode: os.ModeDir}},
+		{"/home/beta/notes.txt", FileInfo{hash: 123}},
+		{"/home/beta/only-beta", FileInfo{hash: 123}},
+		{"/home/beta/changed", FileInfo{hash: 456}},
+		{"/home/beta/docs", FileInfo{Mode: os.ModeDir}},
+	}
+	for _, fixture := range fixtures {
+		fixture.info.Fqfp = fixture.fqfp
+		if _, _, err := tree.AddPath(fixture.fqfp, fixture.info); err != nil {
+			t.Fatalf("could not setup test: %v", err)
+		}
+	}
+
+	treeA := tree.Clone()
+	treeB := tree.Clone()
+	if err := treeA.SetPwd("/home/alpha"); err != nil {
+		t.Fatalf("could not setup test: %v", err)
+	}
+	if err := treeB.SetPwd("/home/beta"); err != nil {
+		t.Fatalf("could not setup test: %v", err)
+	}
+
+	// the result of the previous comparison must not leak into the next one
+	staleNode, _ := treeA.GetNode("/home/alpha/notes.txt")
+	staleNode.Data.DiffType = Removed
+
+	if err := CompareAndMark(treeA, treeB); err != nil {
+		t.Fatalf("unable to compare and mark: %+v", err)
+	}
+
+	expected := map[string]DiffType{
+		"/home/alpha/notes.txt":  Unmodified,
+		"/home/alpha/docs":       Unmodified,
+		"/home/alpha/only-alpha": Added,
+		"/home/alpha/changed":    Modified,
+		"/home/beta/notes.txt":   Unmodified,
+		"/home/beta/docs":        Unmodified,
+		"/home/beta/only-beta":   Added,
+		"/home/beta/changed":     Modified,
+	}
+	for fqfp, diffType := range expected {
+		fileTree := treeA
+		if strings.HasPrefix(fqfp, "/home/beta") {
+			fileTree = treeB
+		}
+		node, err := fileTree.GetNode(fqfp)
+		if err != nil {
+			t.Fatalf("missing node: %v", err)
+		}
+		if err = AssertDiffType(node, diffType); err != nil {
+			t.Error(err)
+		}
+	}
+
+	expectedCounts := map[DiffType]int{Unmodified: 2, Added: 1, Modified: 1}
+	if counts := treeA.DiffTypeCounts(); !reflect.DeepEqual(counts, expectedCounts) {
+		t.Errorf("Expected counts %v, got %v", expectedCounts, counts)
+	}
+}
+
 func TestRemoveOnIterate(t *testing.T) {
 	tree := NewFileTreeModel()
 	fixturePaths := []string{
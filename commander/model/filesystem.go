@@ -3,30 +3,86 @@ package model
 import (
 	"os"
 	"path/filepath"
+	"strings"
+
+	"github.com/mushkevych/9ofm/commander/fileops"
 )
 
+// ReadOptions limit the part of the file system read into the tree
+type ReadOptions struct {
+	// number of directory levels read below the root directory; 0 reads the whole tree
+	Depth int
+	// shell patterns of the names of the files and directories left out of the tree, such as ".git"
+	Excludes []string
+}
+
 // ReadFileTree reads directory specified by the fqfp (Fully Qualified File AbsPath)
 func ReadFileTree(fqfp string) (*FileTreeModel, error) {
+	return ReadFileTreeWithOptions(fqfp, ReadOptions{Depth: 1}, nil)
+}
+
+// ReadFileTreeWithOptions reads directory specified by the fqfp, descending into the nested directories
+// up to the options.Depth. The error returned by the monitor's Checkpoint stops the reading and is returned.
+func ReadFileTreeWithOptions(fqfp string, options ReadOptions, monitor fileops.Monitor) (*FileTreeModel, error) {
+	if monitor == nil {
+		monitor = fileops.NopMonitor{}
+	}
 	fileTree := NewFileTreeModel()
 
 	err := filepath.Walk(fqfp, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return nil
 		}
+		if err = monitor.Checkpoint(); err != nil {
+			return err
+		}
+
+		if path != fqfp && isExcluded(info.Name(), options.Excludes) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
 
 		fileInfo := NewFileInfo(path, info, err)
 		_, _, err = fileTree.AddPath(path, fileInfo)
 
-		if fileInfo.IsDir() && path != fqfp {
+		if fileInfo.IsDir() && path != fqfp && options.Depth > 0 && DepthBelow(fqfp, path) >= options.Depth {
 			return filepath.SkipDir // skip walking nested directories and its content.
 		}
 		return nil
 	})
+	if err != nil {
+		return nil, err
+	}
 
 	// Add parent directory reference ".."
 	if err := fileTree.SetPwd(fqfp); err != nil {
 		return nil, err
 	}
 
-	return fileTree, err
+	return fileTree, nil
+}
+
+// DepthBelow returns the number of directory levels between the root and the path: 0 for the root itself,
+// 1 for the children of the root, etc; -1 if the path is outside of the root
+func DepthBelow(root, path string) int {
+	relative, err := filepath.Rel(root, path)
+	if err != nil || relative == ".." || strings.HasPrefix(relative, ".."+string(os.PathSeparator)) {
+		return -1
+	}
+	if relative == "." {
+		return 0
+	}
+	return strings.Count(relative, string(os.PathSeparator)) + 1
+}
+
+// isExcluded returns true if the name matches any of the shell patterns
+func isExcluded(name string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if matched, _ := filepath.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
 }
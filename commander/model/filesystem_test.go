@@ -0,0 +1,111 @@
+package model
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func helperDeepDirs(t *testing.T) (dirA, dirB string) {
+	root := t.TempDir()
+
+	dirA, dirB = filepath.Join(root, "alpha"), filepath.Join(root, "beta")
+	files := map[string][2]string{
+		"top.txt":               {"same", "same"},
+		"sub/same.txt":          {"same", "same"},
+		"sub/deep/file.txt":     {"short", "much longer"},
+		".git/objects/abcdef01": {"short", "much longer"},
+	}
+	for name, contents := range files {
+		for idx, dir := range []string{dirA, dirB} {
+			fqfp := filepath.Join(dir, name)
+			if err := os.MkdirAll(filepath.Dir(fqfp), 0755); err != nil {
+				t.Fatalf("could not setup test: %v", err)
+			}
+			if err := ioutil.WriteFile(fqfp, []byte(contents[idx]), 0644); err != nil {
+				t.Fatalf("could not setup test: %v", err)
+			}
+		}
+	}
+	return dirA, dirB
+}
+
+func helperReadAndCompare(t *testing.T, dirA, dirB string, options ReadOptions) (treeA, treeB *FileTreeModel) {
+	var err error
+	if treeA, err = ReadFileTreeWithOptions(dirA, options, nil); err != nil {
+		t.Fatalf("unable to read tree: %v", err)
+	}
+	if treeB, err = ReadFileTreeWithOptions(dirB, options, nil); err != nil {
+		t.Fatalf("unable to read tree: %v", err)
+	}
+	if err = CompareAndMark(treeA, treeB); err != nil {
+		t.Fatalf("unable to compare and mark: %+v", err)
+	}
+	return treeA, treeB
+}
+
+func TestDeepCompare(t *testing.T) {
+	dirA, dirB := helperDeepDirs(t)
+	treeA, _ := helperReadAndCompare(t, dirA, dirB, ReadOptions{Excludes: []string{".git"}})
+
+	expected := map[string]DiffType{
+		"top.txt":           Unmodified,
+		"sub":               Modified,
+		"sub/same.txt":      Unmodified,
+		"sub/deep":          Modified,
+		"sub/deep/file.txt": Modified,
+	}
+	for name, diffType := range expected {
+		node, err := treeA.GetNode(filepath.Join(dirA, name))
+		if err != nil {
+			t.Fatalf("missing node: %v", err)
+		}
+		if err = AssertDiffType(node, diffType); err != nil {
+			t.Error(err)
+		}
+	}
+	if _, err := treeA.GetNode(filepath.Join(dirA, ".git")); err == nil {
+		t.Errorf("expected .git to be excluded")
+	}
+
+	// the marks are kept when entering the nested directory
+	if err := treeA.SetPwd(filepath.Join(dirA, "sub")); err != nil {
+		t.Fatalf("unable to set pwd: %v", err)
+	}
+	if diffType := treeA.GetNodeByName("deep").Data.DiffType; diffType != Modified {
+		t.Errorf("expected nested directory to be Modified, got %v", diffType)
+	}
+}
+
+func TestDeepCompareDepth(t *testing.T) {
+	dirA, dirB := helperDeepDirs(t)
+	treeA, _ := helperReadAndCompare(t, dirA, dirB, ReadOptions{Depth: 2, Excludes: []string{".git"}})
+
+	node, err := treeA.GetNode(filepath.Join(dirA, "sub", "deep"))
+	if err != nil {
+		t.Fatalf("missing node: %v", err)
+	}
+	if len(node.Children) != 0 || node.Data.DiffType != Unmodified {
+		t.Errorf("expected directory below the depth to be left unread and Unmodified, got %d children and %v",
+			len(node.Children), node.Data.DiffType)
+	}
+
+	if depth := DepthBelow(dirA, filepath.Join(dirA, "sub", "deep")); depth != 2 {
+		t.Errorf("expected depth 2, got %d", depth)
+	}
+	if depth := DepthBelow(filepath.Join(dirA, "sub"), dirA); depth != -1 {
+		t.Errorf("expected the parent to be outside of the root, got %d", depth)
+	}
+}
+
+func TestReadFileTreeStop(t *testing.T) {
+	dirA, _ := helperDeepDirs(t)
+	monitor := &countingMonitor{stopAfter: 1}
+	monitor.FileDone()
+
+	if _, err := ReadFileTreeWithOptions(dirA, ReadOptions{}, monitor); !errors.Is(err, errStopped) {
+		t.Errorf("expected the reading to stop, got %v", err)
+	}
+}
@@ -0,0 +1,104 @@
+package model
+
+import (
+	"sync"
+
+	"github.com/mushkevych/9ofm/commander/fileops"
+	"github.com/mushkevych/9ofm/commander/hashcache"
+)
+
+// size of the chunks the files are read in while hashing
+const hashBufferSize = 64 * 1024
+
+// hashCache remembers the hashes of the files across the comparisons; nil if the hashes are not cached
+var hashCache *hashcache.Cache
+
+// SetHashCache plugs in the cache consulted before the content of a file is hashed; nil disables the caching.
+// It must be called before any hashing starts.
+func SetHashCache(cache *hashcache.Cache) {
+	hashCache = cache
+}
+
+// HashCandidates returns the files whose content must be hashed to compare the PWD of treeA and treeB:
+// regular files present on both sides with the same size, whose hashes are not computed yet.
+// Files of different size differ anyway, and are never read.
+func HashCandidates(treeA, treeB *FileTreeModel) []*FileNode {
+	var candidates []*FileNode
+	_ = treeA.pwd.DepthFirstSearch(func(node *FileNode) error {
+		other := treeA.counterpart(node, treeB)
+		if other == nil {
+			return nil
+		}
+
+		info, otherInfo := &node.Data.FileInfo, &other.Data.FileInfo
+		if !info.Mode.IsRegular() || !otherInfo.Mode.IsRegular() || info.Size != otherInfo.Size {
+			return nil
+		}
+		for _, candidate := range []*FileNode{node, other} {
+			if !candidate.Data.FileInfo.Hashed() {
+				candidates = append(candidates, candidate)
+			}
+		}
+		return nil
+	}, treeA.isBelowPwd)
+	return candidates
+}
+
+// HashFiles computes the content hashes of the files on a pool of at most workers goroutines.
+// The monitor accounts for the bytes read and the files hashed; the error returned by its Checkpoint
+// stops the hashing. Returns nil or *fileops.OperationErrors with the read errors, which are recorded
+// in the FileInfo.Err of the files as well, and the error of the Checkpoint.
+func HashFiles(fileNodes []*FileNode, workers int, monitor fileops.Monitor) error {
+	if monitor == nil {
+		monitor = fileops.NopMonitor{}
+	}
+	if workers < 1 {
+		workers = 1
+	}
+
+	var mutex sync.Mutex
+	var stopErr error
+	isStopped := func() bool {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return stopErr != nil
+	}
+
+	queue := make(chan *FileNode)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for node := range queue {
+				if err := node.Data.FileInfo.ComputeHash(monitor); err != nil {
+					mutex.Lock()
+					if stopErr == nil {
+						stopErr = err
+					}
+					mutex.Unlock()
+					continue
+				}
+				monitor.FileDone()
+			}
+		}()
+	}
+
+	for _, node := range fileNodes {
+		if isStopped() {
+			break
+		}
+		queue <- node
+	}
+	close(queue)
+	wg.Wait()
+
+	errs := new(fileops.OperationErrors)
+	for _, node := range fileNodes {
+		if !node.Data.FileInfo.Hashed() {
+			errs.Add(node.Data.FileInfo.Err)
+		}
+	}
+	errs.Add(stopErr)
+	return errs.ErrorOrNil()
+}
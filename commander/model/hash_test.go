@@ -0,0 +1,194 @@
+package model
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mushkevych/9ofm/commander/hashcache"
+)
+
+// countingMonitor accounts for the hashed files and bytes, and stops the hashing once stopAfter files are hashed
+type countingMonitor struct {
+	mutex     sync.Mutex
+	files     int
+	bytes     int64
+	stopAfter int
+}
+
+var errStopped = errors.New("stopped")
+
+func (m *countingMonitor) Checkpoint() error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if m.stopAfter > 0 && m.files >= m.stopAfter {
+		return errStopped
+	}
+	return nil
+}
+
+func (m *countingMonitor) BytesDone(n int64) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.bytes += n
+}
+
+func (m *countingMonitor) FileDone() {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.files++
+}
+
+func helperWriteFiles(t *testing.T, dir string, files map[string]string) {
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("could not setup test: %v", err)
+		}
+	}
+}
+
+func helperCompareDirs(t *testing.T) (treeA, treeB *FileTreeModel, dirA, dirB string) {
+	root := t.TempDir()
+
+	dirA, dirB = filepath.Join(root, "alpha"), filepath.Join(root, "beta")
+	for _, dir := range []string{dirA, dirB} {
+		if err := os.Mkdir(dir, 0755); err != nil {
+			t.Fatalf("could not setup test: %v", err)
+		}
+	}
+	helperWriteFiles(t, dirA, map[string]string{"same": "content", "changed": "aaaa", "resized": "short"})
+	helperWriteFiles(t, dirB, map[string]string{"same": "content", "changed": "bbbb", "resized": "much longer"})
+
+	var err error
+	if treeA, err = ReadFileTree(dirA); err != nil {
+		t.Fatalf("could not setup test: %v", err)
+	}
+	if treeB, err = ReadFileTree(dirB); err != nil {
+		t.Fatalf("could not setup test: %v", err)
+	}
+	return treeA, treeB, dirA, dirB
+}
+
+func TestHashFiles(t *testing.T) {
+	treeA, treeB, _, _ := helperCompareDirs(t)
+
+	// without the hashes only the size tells the difference
+	if err := CompareAndMark(treeA, treeB); err != nil {
+		t.Fatalf("unable to compare and mark: %+v", err)
+	}
+	if diffType := treeA.GetNodeByName("changed").Data.DiffType; diffType != Unmodified {
+		t.Errorf("expected file to look Unmodified before hashing, got %v", diffType)
+	}
+	if diffType := treeA.GetNodeByName("resized").Data.DiffType; diffType != Modified {
+		t.Errorf("expected resized file to be Modified, got %v", diffType)
+	}
+
+	candidates := HashCandidates(treeA, treeB)
+	if len(candidates) != 4 {
+		t.Fatalf("expected 4 files of equal size to be hashed, got %d", len(candidates))
+	}
+
+	monitor := new(countingMonitor)
+	if err := HashFiles(candidates, 3, monitor); err != nil {
+		t.Fatalf("unable to hash files: %v", err)
+	}
+	if monitor.files != 4 || monitor.bytes != 2*int64(len("content")+len("aaaa")) {
+		t.Errorf("expected 4 files and 22 bytes to be accounted, got %d files and %d bytes", monitor.files, monitor.bytes)
+	}
+	if len(HashCandidates(treeA, treeB)) != 0 {
+		t.Errorf("expected hashed files not to be hashed again")
+	}
+
+	if err := CompareAndMark(treeA, treeB); err != nil {
+		t.Fatalf("unable to compare and mark: %+v", err)
+	}
+	expected := map[string]DiffType{"same": Unmodified, "changed": Modified, "resized": Modified}
+	for name, diffType := range expected {
+		if actual := treeA.GetNodeByName(name).Data.DiffType; actual != diffType {
+			t.Errorf("expected %s to be %v, got %v", name, diffType, actual)
+		}
+	}
+}
+
+func TestHashFilesStop(t *testing.T) {
+	treeA, treeB, _, _ := helperCompareDirs(t)
+	candidates := HashCandidates(treeA, treeB)
+
+	monitor := &countingMonitor{stopAfter: 1}
+	err := HashFiles(candidates, 1, monitor)
+	if !errors.Is(err, errStopped) {
+		t.Fatalf("expected the hashing to stop, got %v", err)
+	}
+	if monitor.files != 1 {
+		t.Errorf("expected 1 file to be hashed before the stop, got %d", monitor.files)
+	}
+}
+
+func TestHashFilesReadError(t *testing.T) {
+	treeA, treeB, dirA, _ := helperCompareDirs(t)
+	if err := os.Remove(filepath.Join(dirA, "changed")); err != nil {
+		t.Fatalf("could not setup test: %v", err)
+	}
+
+	err := HashFiles(HashCandidates(treeA, treeB), 2, nil)
+	if err == nil {
+		t.Fatalf("expected the read error to be reported")
+	}
+	info := treeA.GetNodeByName("changed").Data.FileInfo
+	if !os.IsNotExist(info.Err) || info.Hashed() {
+		t.Errorf("expected the read error to be recorded, got %v", info.Err)
+	}
+	if !treeA.GetNodeByName("same").Data.FileInfo.Hashed() {
+		t.Errorf("expected the rest of the files to be hashed")
+	}
+}
+
+func TestHashFilesCache(t *testing.T) {
+	_, _, dirA, _ := helperCompareDirs(t)
+	cache, err := hashcache.Open(filepath.Join(dirA, "..", "hashes"), 10)
+	if err != nil {
+		t.Fatalf("unable to open the cache: %v", err)
+	}
+	SetHashCache(cache)
+	defer SetHashCache(nil)
+
+	fqfp := filepath.Join(dirA, "same")
+	hashOf := func() uint64 {
+		tree, err := ReadFileTree(dirA)
+		if err != nil {
+			t.Fatalf("unable to read tree: %v", err)
+		}
+		node := tree.GetNodeByName("same")
+		if err = HashFiles([]*FileNode{node}, 1, nil); err != nil {
+			t.Fatalf("unable to hash files: %v", err)
+		}
+		return node.Data.FileInfo.hash
+	}
+
+	original := hashOf()
+	if cache.Len() != 1 {
+		t.Fatalf("expected the hash to be cached, got %d hashes", cache.Len())
+	}
+
+	// the content is not read again while the size and the modification time are the same
+	info, _ := os.Stat(fqfp)
+	helperWriteFiles(t, dirA, map[string]string{"same": "CONTENT"})
+	if err = os.Chtimes(fqfp, info.ModTime(), info.ModTime()); err != nil {
+		t.Fatalf("could not setup test: %v", err)
+	}
+	if hash := hashOf(); hash != original {
+		t.Errorf("expected the cached hash %d, got %d", original, hash)
+	}
+
+	modTime := info.ModTime().Add(time.Second)
+	if err = os.Chtimes(fqfp, modTime, modTime); err != nil {
+		t.Fatalf("could not setup test: %v", err)
+	}
+	if hash := hashOf(); hash == original {
+		t.Errorf("expected the hash of the modified file to be computed again")
+	}
+}
@@ -0,0 +1,77 @@
+package commander
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/mitchellh/go-homedir"
+	"github.com/mushkevych/9ofm/commander/session"
+)
+
+// DefaultRoot is the starting directory of the panels when none is given
+const DefaultRoot = "/"
+
+// Options configure the start of the Application.
+// Relative paths are resolved against the current directory, and the leading "~" stands for the home directory.
+type Options struct {
+	// starting directory of the panel A; defaults to the StartingPath
+	AlphaRoot string
+	// starting directory of the panel B; defaults to the StartingPath
+	BetaRoot string
+	// starting directory of both panels, unless given by AlphaRoot or BetaRoot; defaults to the directories
+	// of the previous session, or to the DefaultRoot
+	StartingPath string
+	// start afresh instead of restoring the previous session; the session is saved on exit nevertheless
+	NoRestore bool
+}
+
+// panelRoots returns the absolute starting directories of both panels, verifying that they are directories.
+// Panels without the starting directory in the options start in the directories of the previous session,
+// or in their closest ancestors if the directories have disappeared; state is nil if there is no session to restore.
+func (options Options) panelRoots(state *session.State) (alphaRoot, betaRoot string, err error) {
+	alphaDefault, betaDefault := DefaultRoot, DefaultRoot
+	if state != nil {
+		alphaDefault, betaDefault = session.ExistingDir(state.Alpha.Pwd), session.ExistingDir(state.Beta.Pwd)
+	}
+
+	for _, panel := range []struct {
+		name        string
+		path        string
+		defaultPath string
+		root        *string
+	}{{"A", options.AlphaRoot, alphaDefault, &alphaRoot}, {"B", options.BetaRoot, betaDefault, &betaRoot}} {
+		path := panel.path
+		if path == "" {
+			path = options.StartingPath
+		}
+		if path == "" {
+			path = panel.defaultPath
+		}
+		if *panel.root, err = resolveRoot(path); err != nil {
+			return "", "", fmt.Errorf("invalid starting path of panel %s: %w", panel.name, err)
+		}
+	}
+	return alphaRoot, betaRoot, nil
+}
+
+// resolveRoot expands the "~", makes the path absolute, and verifies that it is a directory
+func resolveRoot(path string) (string, error) {
+	expanded, err := homedir.Expand(path)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", path, err)
+	}
+	fqfp, err := filepath.Abs(expanded)
+	if err != nil {
+		return "", err
+	}
+
+	info, err := os.Stat(fqfp)
+	if err != nil {
+		return "", err
+	}
+	if !info.IsDir() {
+		return "", fmt.Errorf("%s is not a directory", fqfp)
+	}
+	return fqfp, nil
+}
@@ -0,0 +1,87 @@
+package pathcomplete
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/mitchellh/go-homedir"
+)
+
+// separator of the path elements as typed by the user
+const separator = string(filepath.Separator)
+
+// Expand resolves the path typed by the user into the clean absolute path: the leading "~" stands for the home
+// directory, and the relative paths are relative to the base directory
+func Expand(input, base string) (string, error) {
+	expanded, err := homedir.Expand(strings.TrimSpace(input))
+	if err != nil {
+		return "", err
+	}
+	if !filepath.IsAbs(expanded) {
+		expanded = filepath.Join(base, expanded)
+	}
+	return filepath.Clean(expanded), nil
+}
+
+// Complete extends the last element of the typed path the way the shells do: up to the longest prefix shared
+// by the names of the matching files. The completed input keeps the form typed by the user, such as "~/";
+// the directories get the trailing separator once the name is unique. The matching names are returned as well,
+// with the trailing separator for the directories; the hidden files match only if the typed name starts with ".".
+func Complete(input, base string) (completed string, names []string) {
+	if input == "~" {
+		return "~" + separator, nil
+	}
+
+	dirPart, prefix := "", input
+	if idx := strings.LastIndex(input, separator); idx >= 0 {
+		dirPart, prefix = input[:idx+1], input[idx+1:]
+	}
+	dir, err := Expand(dirPart, base)
+	if err != nil {
+		return input, nil
+	}
+	fileInfos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return input, nil
+	}
+
+	for _, fileInfo := range fileInfos {
+		name := fileInfo.Name()
+		if !strings.HasPrefix(name, prefix) || (strings.HasPrefix(name, ".") && !strings.HasPrefix(prefix, ".")) {
+			continue
+		}
+		// follow the symbolic links to the directories
+		if info, err := os.Stat(filepath.Join(dir, name)); err == nil && info.IsDir() {
+			name += separator
+		}
+		names = append(names, name)
+	}
+	if len(names) == 0 {
+		return input, nil
+	}
+	sort.Strings(names)
+
+	if len(names) == 1 {
+		return dirPart + names[0], names
+	}
+	return dirPart + commonPrefix(names), names
+}
+
+// commonPrefix returns the longest prefix shared by all the names
+func commonPrefix(names []string) string {
+	prefix := names[0]
+	for _, name := range names[1:] {
+		for !strings.HasPrefix(name, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+	// the names differing in the bytes of a single character share the bytes before that character only
+	for !utf8.ValidString(prefix) {
+		prefix = prefix[:len(prefix)-1]
+	}
+	return prefix
+}
@@ -0,0 +1,84 @@
+package pathcomplete
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/mitchellh/go-homedir"
+)
+
+// helperFixture creates the directories and files completed by the tests, and a symlink to a directory
+func helperFixture(t *testing.T) string {
+	dir := t.TempDir()
+	for _, name := range []string{"alpha", "alpha/nested", ".hidden"} {
+		if err := os.Mkdir(filepath.Join(dir, name), 0755); err != nil {
+			t.Fatalf("could not setup test: %v", err)
+		}
+	}
+	for _, name := range []string{"alpine", "beta", "alpha/file"} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatalf("could not setup test: %v", err)
+		}
+	}
+	if err := os.Symlink(filepath.Join(dir, "alpha"), filepath.Join(dir, "link")); err != nil {
+		t.Fatalf("could not setup test: %v", err)
+	}
+	return dir
+}
+
+func TestExpand(t *testing.T) {
+	home, err := homedir.Dir()
+	if err != nil {
+		t.Skipf("no home directory: %v", err)
+	}
+
+	for input, expected := range map[string]string{
+		"/etc/../var/":  "/var",
+		"src":           "/base/src",
+		"../other":      "/other",
+		"":              "/base",
+		"  ~ ":          home,
+		"~/Downloads/.": filepath.Join(home, "Downloads"),
+	} {
+		if path, err := Expand(input, "/base"); err != nil || path != expected {
+			t.Errorf("expected %q to expand to %s, got %s, %v", input, expected, path, err)
+		}
+	}
+}
+
+func TestComplete(t *testing.T) {
+	dir := helperFixture(t)
+
+	for _, tc := range []struct {
+		input     string
+		completed string
+		names     []string
+	}{
+		{"al", "alp", []string{"alpha/", "alpine"}},
+		{"alph", "alpha/", []string{"alpha/"}},
+		{"b", "beta", []string{"beta"}},
+		{"li", "link/", []string{"link/"}},
+		{"alpha/n", "alpha/nested/", []string{"nested/"}},
+		{dir + "/alpha/f", dir + "/alpha/file", []string{"file"}},
+		{"", "", []string{"alpha/", "alpine", "beta", "link/"}},
+		{".", ".hidden/", []string{".hidden/"}},
+		{"gamma", "gamma", nil},
+		{"missing/a", "missing/a", nil},
+		{"~", "~/", nil},
+	} {
+		completed, names := Complete(tc.input, dir)
+		if completed != tc.completed || !reflect.DeepEqual(names, tc.names) {
+			t.Errorf("expected %q to complete to %q %v, got %q %v", tc.input, tc.completed, tc.names, completed, names)
+		}
+	}
+}
+
+func TestCommonPrefix(t *testing.T) {
+	// "é" and "è" share the first byte of their encoding
+	if prefix := commonPrefix([]string{"café", "cafè"}); prefix != "caf" {
+		t.Errorf("expected the prefix to end before the differing character, got %q", prefix)
+	}
+}
@@ -0,0 +1,277 @@
+package report
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mushkevych/9ofm/commander/model"
+)
+
+// Format of the exported report
+type Format string
+
+const (
+	JSON Format = "json"
+	CSV  Format = "csv"
+	Text Format = "text" // one line per file, prefixed like the lines of the unified diff
+	// the paths that differ, grouped by their DiffType
+	Grouped Format = "grouped"
+)
+
+// ParseFormat converts the format name into the Format
+func ParseFormat(name string) (Format, error) {
+	for _, format := range []Format{JSON, CSV, Text, Grouped} {
+		if strings.ToLower(strings.TrimSpace(name)) == string(format) {
+			return format, nil
+		}
+	}
+	return "", fmt.Errorf("unknown report format: %s", name)
+}
+
+// Side describes the file on one side of the comparison
+type Side struct {
+	AbsPath string `json:"absPath"`
+	Size    int64  `json:"size"`
+	Mode    string `json:"mode"`
+	Owner   string `json:"owner"`          // uid:gid
+	Hash    string `json:"hash,omitempty"` // hex xxhash of the content; empty unless the content was hashed
+}
+
+// Entry is a single file or directory present on either side
+type Entry struct {
+	// slash-delimited path relative to the compared directories
+	Path string `json:"path"`
+	// relative to the left side: Added files are present on the right side only, Removed ones on the left side only
+	DiffType string `json:"diffType"`
+	Left     *Side  `json:"left,omitempty"`
+	Right    *Side  `json:"right,omitempty"`
+}
+
+// Report lists every file and directory below the PWDs of two compared trees
+type Report struct {
+	Left    string  `json:"left"`
+	Right   string  `json:"right"`
+	Entries []Entry `json:"entries"`
+}
+
+// Build creates the report from the PWDs of the two trees, which must have been compared by model.CompareAndMark.
+// Entries are ordered by their path, every directory followed by its content.
+func Build(left, right *model.FileTreeModel) (*Report, error) {
+	return BuildFrom(left, left.GetPwd(), right, right.GetPwd())
+}
+
+// BuildFrom creates the report from the directories of the two trees, which were the PWDs
+// when the trees were compared. See Build for details.
+func BuildFrom(left *model.FileTreeModel, leftRoot string, right *model.FileTreeModel, rightRoot string) (*Report, error) {
+	report := &Report{Left: leftRoot, Right: rightRoot}
+	entries := make(map[string]*Entry)
+
+	for _, tree := range []*model.FileTreeModel{left, right} {
+		tree, root := tree, leftRoot
+		if tree == right {
+			root = rightRoot
+		}
+		err := tree.DepthFirstSearch(func(node *model.FileNode) error {
+			relative, err := filepath.Rel(root, node.AbsPath())
+			if err != nil {
+				return err
+			}
+			relative = filepath.ToSlash(relative)
+
+			entry, ok := entries[relative]
+			if !ok {
+				entry = &Entry{Path: relative}
+				entries[relative] = entry
+			}
+			if tree == left {
+				entry.Left = newSide(node)
+				entry.DiffType = node.Data.DiffType.String()
+			} else {
+				entry.Right = newSide(node)
+			}
+			return nil
+		}, func(node *model.FileNode) bool {
+			// only the nodes below the root
+			return model.DepthBelow(root, node.AbsPath()) > 0
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, entry := range entries {
+		switch {
+		case entry.Left == nil:
+			entry.DiffType = model.Added.String()
+		case entry.Right == nil:
+			entry.DiffType = model.Removed.String()
+		}
+		report.Entries = append(report.Entries, *entry)
+	}
+	sort.Slice(report.Entries, func(i, j int) bool {
+		return lessPath(report.Entries[i].Path, report.Entries[j].Path)
+	})
+	return report, nil
+}
+
+// newSide describes the file of the node
+func newSide(node *model.FileNode) *Side {
+	info := &node.Data.FileInfo
+	side := &Side{
+		AbsPath: node.AbsPath(),
+		Size:    info.Size,
+		Mode:    info.Mode.String(),
+		Owner:   info.Uid + ":" + info.Gid,
+	}
+	if info.Hashed() {
+		side.Hash = fmt.Sprintf("%016x", info.Hash())
+	}
+	return side
+}
+
+// isDir returns true if the side is a directory
+func (s *Side) isDir() bool {
+	return strings.HasPrefix(s.Mode, "d")
+}
+
+// lessPath orders the slash-delimited paths name by name, so that the content of a directory follows it immediately
+func lessPath(a, b string) bool {
+	namesA, namesB := strings.Split(a, "/"), strings.Split(b, "/")
+	for idx := 0; idx < len(namesA) && idx < len(namesB); idx++ {
+		if namesA[idx] != namesB[idx] {
+			return namesA[idx] < namesB[idx]
+		}
+	}
+	return len(namesA) < len(namesB)
+}
+
+// Differences returns the number of entries that are not Unmodified
+func (r *Report) Differences() int {
+	count := 0
+	for _, entry := range r.Entries {
+		if entry.DiffType != model.Unmodified.String() {
+			count++
+		}
+	}
+	return count
+}
+
+// Write encodes the report in the format
+func (r *Report) Write(w io.Writer, format Format) error {
+	switch format {
+	case JSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(r)
+	case CSV:
+		return r.writeCSV(w)
+	case Text:
+		return r.writeText(w)
+	case Grouped:
+		return r.writeGrouped(w)
+	default:
+		return fmt.Errorf("unknown report format: %s", format)
+	}
+}
+
+// writeCSV writes the header and a single record per entry; the columns of the missing side are empty
+func (r *Report) writeCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	header := []string{"path", "diff_type"}
+	for _, side := range []string{"left", "right"} {
+		for _, column := range []string{"abs_path", "size", "mode", "owner", "hash"} {
+			header = append(header, side+"_"+column)
+		}
+	}
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+
+	for _, entry := range r.Entries {
+		record := []string{entry.Path, entry.DiffType}
+		for _, side := range []*Side{entry.Left, entry.Right} {
+			if side == nil {
+				record = append(record, "", "", "", "", "")
+				continue
+			}
+			record = append(record, side.AbsPath, strconv.FormatInt(side.Size, 10), side.Mode, side.Owner, side.Hash)
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// textPrefix marks the lines of the text report the way the unified diff does
+var textPrefix = map[string]string{
+	model.Unmodified.String(): " ",
+	model.Modified.String():   "~",
+	model.Added.String():      "+",
+	model.Removed.String():    "-",
+}
+
+// writeText writes the line per entry, with the properties that differ between the sides of the Modified entries
+func (r *Report) writeText(w io.Writer) error {
+	if _, err := fmt.Fprintf(w, "--- %s\n+++ %s\n", r.Left, r.Right); err != nil {
+		return err
+	}
+
+	for _, entry := range r.Entries {
+		line := textPrefix[entry.DiffType] + " " + entry.Path
+		if entry.Left != nil && entry.Right != nil {
+			var changes []string
+			for _, property := range []struct{ name, left, right string }{
+				{"size", strconv.FormatInt(entry.Left.Size, 10), strconv.FormatInt(entry.Right.Size, 10)},
+				{"mode", entry.Left.Mode, entry.Right.Mode},
+				{"owner", entry.Left.Owner, entry.Right.Owner},
+				{"hash", entry.Left.Hash, entry.Right.Hash},
+			} {
+				// the size of a directory tells nothing about its content, and unknown hashes are not compared
+				skipped := property.name == "size" && (entry.Left.isDir() || entry.Right.isDir()) ||
+					property.name == "hash" && (property.left == "" || property.right == "")
+				if !skipped && property.left != property.right {
+					changes = append(changes, fmt.Sprintf("%s %s -> %s", property.name, property.left, property.right))
+				}
+			}
+			if len(changes) > 0 {
+				line += "  (" + strings.Join(changes, ", ") + ")"
+			}
+		}
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeGrouped writes the paths of the entries that differ under the heading of their DiffType
+func (r *Report) writeGrouped(w io.Writer) error {
+	groups := make(map[string][]string)
+	for _, entry := range r.Entries {
+		groups[entry.DiffType] = append(groups[entry.DiffType], entry.Path)
+	}
+
+	for _, diffType := range []model.DiffType{model.Added, model.Removed, model.Modified} {
+		paths := groups[diffType.String()]
+		if len(paths) == 0 {
+			continue
+		}
+		if _, err := fmt.Fprintf(w, "%s (%d):\n", diffType, len(paths)); err != nil {
+			return err
+		}
+		for _, path := range paths {
+			if _, err := fmt.Fprintln(w, "  "+path); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
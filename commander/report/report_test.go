@@ -0,0 +1,148 @@
+package report
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mushkevych/9ofm/commander/model"
+)
+
+func helperCompareDirs(t *testing.T) (treeA, treeB *model.FileTreeModel) {
+	root := t.TempDir()
+
+	files := []struct{ dir, name, content string }{
+		{"alpha", "same.txt", "same"},
+		{"beta", "same.txt", "same"},
+		{"alpha", "sub/changed.txt", "short"},
+		{"beta", "sub/changed.txt", "much longer"},
+		{"alpha", "removed.txt", "left only"},
+		{"beta", "sub/added.txt", "right only"},
+	}
+	for _, file := range files {
+		fqfp := filepath.Join(root, file.dir, file.name)
+		if err := os.MkdirAll(filepath.Dir(fqfp), 0755); err != nil {
+			t.Fatalf("could not setup test: %v", err)
+		}
+		if err := ioutil.WriteFile(fqfp, []byte(file.content), 0644); err != nil {
+			t.Fatalf("could not setup test: %v", err)
+		}
+	}
+
+	read := func(dir string) *model.FileTreeModel {
+		tree, err := model.ReadFileTreeWithOptions(filepath.Join(root, dir), model.ReadOptions{}, nil)
+		if err != nil {
+			t.Fatalf("could not setup test: %v", err)
+		}
+		return tree
+	}
+	treeA, treeB = read("alpha"), read("beta")
+	if err := model.CompareAndMark(treeA, treeB); err != nil {
+		t.Fatalf("unable to compare and mark: %+v", err)
+	}
+	return treeA, treeB
+}
+
+func TestBuild(t *testing.T) {
+	report, err := Build(helperCompareDirs(t))
+	if err != nil {
+		t.Fatalf("unable to build report: %v", err)
+	}
+
+	var actual []string
+	for _, entry := range report.Entries {
+		actual = append(actual, entry.DiffType+" "+entry.Path)
+	}
+	expected := []string{
+		"Removed removed.txt",
+		"Unmodified same.txt",
+		"Modified sub",
+		"Added sub/added.txt",
+		"Modified sub/changed.txt",
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected entries %q, got %q", expected, actual)
+	}
+	if differences := report.Differences(); differences != 4 {
+		t.Errorf("expected 4 differences, got %d", differences)
+	}
+
+	changed := report.Entries[4]
+	if changed.Left.Size != 5 || changed.Right.Size != 11 || !strings.HasSuffix(changed.Right.AbsPath, "beta/sub/changed.txt") {
+		t.Errorf("expected both sides of the changed file, got %+v and %+v", changed.Left, changed.Right)
+	}
+}
+
+func TestWrite(t *testing.T) {
+	report, err := Build(helperCompareDirs(t))
+	if err != nil {
+		t.Fatalf("unable to build report: %v", err)
+	}
+
+	var buffer bytes.Buffer
+	if err = report.Write(&buffer, JSON); err != nil {
+		t.Fatalf("unable to write JSON: %v", err)
+	}
+	decoded := new(Report)
+	if err = json.Unmarshal(buffer.Bytes(), decoded); err != nil || !reflect.DeepEqual(decoded, report) {
+		t.Errorf("expected the JSON to decode into the report, got %v", err)
+	}
+
+	buffer.Reset()
+	if err = report.Write(&buffer, CSV); err != nil {
+		t.Fatalf("unable to write CSV: %v", err)
+	}
+	records, err := csv.NewReader(&buffer).ReadAll()
+	if err != nil || len(records) != len(report.Entries)+1 || len(records[0]) != 12 {
+		t.Errorf("expected the header and a record per entry, got %d records: %v", len(records), err)
+	}
+
+	buffer.Reset()
+	if err = report.Write(&buffer, Text); err != nil {
+		t.Fatalf("unable to write text: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(buffer.String()), "\n")
+	if len(lines) != len(report.Entries)+2 {
+		t.Fatalf("expected the header and a line per entry, got %q", lines)
+	}
+	for _, line := range []string{"- removed.txt", "  same.txt", "+ sub/added.txt", "~ sub/changed.txt  (size 5 -> 11)"} {
+		found := false
+		for _, actual := range lines {
+			found = found || actual == line
+		}
+		if !found {
+			t.Errorf("expected line %q in %q", line, lines)
+		}
+	}
+}
+
+func TestWriteGrouped(t *testing.T) {
+	report, err := Build(helperCompareDirs(t))
+	if err != nil {
+		t.Fatalf("unable to build report: %v", err)
+	}
+
+	var buffer bytes.Buffer
+	if err = report.Write(&buffer, Grouped); err != nil {
+		t.Fatalf("unable to write grouped report: %v", err)
+	}
+	expected := "Added (1):\n  sub/added.txt\nRemoved (1):\n  removed.txt\nModified (2):\n  sub\n  sub/changed.txt\n"
+	if actual := buffer.String(); actual != expected {
+		t.Errorf("expected grouped report %q, got %q", expected, actual)
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	if format, err := ParseFormat(" CSV "); err != nil || format != CSV {
+		t.Errorf("expected CSV, got %q, %v", format, err)
+	}
+	if _, err := ParseFormat("xml"); err == nil {
+		t.Errorf("expected unknown format to be rejected")
+	}
+}
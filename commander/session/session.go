@@ -0,0 +1,78 @@
+package session
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/mushkevych/9ofm/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+// Panel is the state of a single file panel
+type Panel struct {
+	Pwd string `json:"pwd"`
+	// name of the file under the cursor
+	Selected string `json:"selected,omitempty"`
+	// regular expression filtering the names of the files; empty if none
+	Filter string `json:"filter,omitempty"`
+	// names of the DiffTypes hidden from the compared panel, e.g. "Unmodified"
+	HiddenDiffTypes []string `json:"hiddenDiffTypes,omitempty"`
+}
+
+// State of the UI saved on exit and restored on the next start
+type State struct {
+	Alpha Panel `json:"alpha"`
+	Beta  Panel `json:"beta"`
+	// name of the panel that had the focus, e.g. "betaFilePanel"
+	Active string `json:"active"`
+}
+
+// Load reads the state saved by Save; a missing file is no state, hence nil is returned.
+// The malformed file is discarded the same way, since the session starts afresh without it.
+func Load(path string) (*State, error) {
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	state := new(State)
+	if err = json.Unmarshal(data, state); err != nil {
+		log.WithError(err).Warnf("discarding malformed session %s", path)
+		return nil, nil
+	}
+	return state, nil
+}
+
+// Save writes the state into the file, replacing the previous one at once
+func (s *State) Save(path string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return utils.WriteFileAtomic(path, 0600, func(w io.Writer) error {
+		_, err := w.Write(data)
+		return err
+	})
+}
+
+// ExistingDir returns the directory itself, or its closest ancestor that still exists if it has disappeared;
+// the root if the path is not absolute
+func ExistingDir(path string) string {
+	if !filepath.IsAbs(path) {
+		return string(filepath.Separator)
+	}
+	for dir := filepath.Clean(path); ; dir = filepath.Dir(dir) {
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			return dir
+		}
+		if dir == filepath.Dir(dir) {
+			return dir
+		}
+	}
+}
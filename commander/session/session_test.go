@@ -0,0 +1,69 @@
+package session
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestSaveAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config", "session.json")
+
+	state, err := Load(path)
+	if err != nil || state != nil {
+		t.Fatalf("expected no state before the first save, got %+v, %v", state, err)
+	}
+
+	expected := &State{
+		Alpha:  Panel{Pwd: "/tmp", Selected: "a.txt", Filter: `\.go$`, HiddenDiffTypes: []string{"Unmodified"}},
+		Beta:   Panel{Pwd: "/"},
+		Active: "betaFilePanel",
+	}
+	if err = expected.Save(path); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if state, err = Load(path); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(state, expected) {
+		t.Errorf("expected %+v, got %+v", expected, state)
+	}
+
+	content, _ := ioutil.ReadDir(filepath.Dir(path))
+	if len(content) != 1 {
+		t.Errorf("expected the temporary file to be renamed, found %d files", len(content))
+	}
+}
+
+func TestLoadMalformed(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.json")
+	if err := ioutil.WriteFile(path, []byte("{not json"), 0600); err != nil {
+		t.Fatalf("could not setup test: %v", err)
+	}
+
+	state, err := Load(path)
+	if err != nil || state != nil {
+		t.Errorf("expected the malformed session to be discarded, got %+v, %v", state, err)
+	}
+}
+
+func TestExistingDir(t *testing.T) {
+	root := t.TempDir()
+	nested := filepath.Join(root, "a", "b")
+	if err := os.MkdirAll(nested, 0755); err != nil {
+		t.Fatalf("could not setup test: %v", err)
+	}
+
+	for path, expected := range map[string]string{
+		nested:                               nested,
+		filepath.Join(nested, "gone", "too"): nested,
+		filepath.Join(root, "a", "c"):        filepath.Join(root, "a"),
+		"relative/path":                      "/",
+	} {
+		if actual := ExistingDir(path); actual != expected {
+			t.Errorf("%s: expected %s, got %s", path, expected, actual)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package syncplan
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/mushkevych/9ofm/commander/fileops"
+)
+
+// ErrChanged is reported for the items whose files have changed since the plan was built
+var ErrChanged = errors.New("changed since the plan was built")
+
+// Paths returns the fully qualified paths of the source and the target of the item
+func (p *Plan) Paths(item Item) (source, target string) {
+	left := filepath.Join(p.Left, filepath.FromSlash(item.Path))
+	right := filepath.Join(p.Right, filepath.FromSlash(item.Path))
+	if item.Direction == RightToLeft {
+		return right, left
+	}
+	return left, right
+}
+
+// Measure returns the number of files and the total size of the regular files the plan copies or deletes
+func (p *Plan) Measure() (files, bytes int64) {
+	for _, item := range p.Items {
+		source, target := p.Paths(item)
+		switch item.Action() {
+		case Copy, Replace:
+			f, b := fileops.Measure(source)
+			files, bytes = files+f, bytes+b
+		case Delete:
+			f, b := fileops.Measure(target)
+			files, bytes = files+f, bytes+b
+		}
+	}
+	return files, bytes
+}
+
+// Execute carries out the items of the plan, skipping the excluded ones and the ones without a direction.
+// The item whose files are not of the kinds, sizes or modification times recorded in the plan anymore
+// fails with ErrChanged.
+// The done function, if not nil, is called after every executed item with its error.
+// Returns nil or *fileops.OperationErrors with an error for every file that could not be synced;
+// the error returned by the monitor's Checkpoint stops the execution and is included in the errors.
+func (p *Plan) Execute(monitor fileops.Monitor, done func(item Item, err error)) error {
+	if monitor == nil {
+		monitor = fileops.NopMonitor{}
+	}
+	errs := new(fileops.OperationErrors)
+
+	options := fileops.DefaultCopyOptions()
+	options.Monitor = monitor
+	copier := fileops.NewCopier(options)
+
+	for _, item := range p.Items {
+		if item.Action() == Skip {
+			continue
+		}
+		if err := monitor.Checkpoint(); err != nil {
+			if !errors.Is(errs, err) {
+				errs.Add(err)
+			}
+			break
+		}
+
+		err := p.execute(copier, monitor, item)
+		if done != nil {
+			done(item, err)
+		}
+		errs.Add(err)
+	}
+	return errs.ErrorOrNil()
+}
+
+// execute carries out a single item
+func (p *Plan) execute(copier *fileops.Copier, monitor fileops.Monitor, item Item) error {
+	source, target := p.Paths(item)
+	sourceKind, targetKind := item.sides()
+	sourceState, targetState := item.states()
+	if err := checkSide(source, sourceKind, sourceState); err != nil {
+		return err
+	}
+	if err := checkSide(target, targetKind, targetState); err != nil {
+		return err
+	}
+
+	switch item.Action() {
+	case Delete:
+		return fileops.Delete(target, monitor)
+	case Replace:
+		if sourceKind != targetKind {
+			// a directory is never overwritten by a file, nor the other way round
+			if err := fileops.Delete(target, monitor); err != nil {
+				return err
+			}
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	return copier.Copy(source, target)
+}
+
+// checkSide returns ErrChanged if the file system entry at the path is not of the expected kind, or its size or
+// modification time differ from the expected state. The state is not checked if the plan does not record it.
+func checkSide(fqfp string, expected Kind, state *State) error {
+	actual := Missing
+	info, err := os.Lstat(fqfp)
+	switch {
+	case err == nil && info.IsDir():
+		actual = Dir
+	case err == nil:
+		actual = File
+	case !os.IsNotExist(err):
+		return err
+	}
+
+	if actual != expected {
+		return fmt.Errorf("%s: %w", fqfp, ErrChanged)
+	}
+	if actual == Missing || state == nil {
+		return nil
+	}
+	if !info.ModTime().Equal(state.ModTime) || (actual == File && info.Size() != state.Size) {
+		return fmt.Errorf("%s: %w", fqfp, ErrChanged)
+	}
+	return nil
+}
@@ -0,0 +1,316 @@
+package syncplan
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mushkevych/9ofm/commander/model"
+)
+
+// Mode defines how the plan brings the two directories in sync
+type Mode string
+
+const (
+	Mirror Mode = "mirror"  // make the right side look like the left one, including deletes
+	Update Mode = "update"  // copy the files missing on the right side, and replace the older ones
+	TwoWay Mode = "two-way" // copy the missing files both ways; the newer file replaces the older one
+)
+
+// ParseMode converts the mode name into the Mode
+func ParseMode(name string) (Mode, error) {
+	for _, mode := range []Mode{Mirror, Update, TwoWay} {
+		if strings.ToLower(strings.TrimSpace(name)) == string(mode) {
+			return mode, nil
+		}
+	}
+	return "", fmt.Errorf("unknown sync mode: %s", name)
+}
+
+// Direction of a single item of the plan
+type Direction string
+
+const (
+	None        Direction = ""   // the item is left as it is
+	LeftToRight Direction = "->" // the left side is carried over to the right one
+	RightToLeft Direction = "<-" // the right side is carried over to the left one
+)
+
+// Kind of the file system entry on one side of an item
+type Kind string
+
+const (
+	Missing Kind = ""
+	File    Kind = "file" // anything but a directory
+	Dir     Kind = "dir"
+)
+
+// Action performed on the target side of an item
+type Action string
+
+const (
+	Skip    Action = "skip"
+	Copy    Action = "copy"    // the target is missing
+	Replace Action = "replace" // the target is overwritten
+	Delete  Action = "delete"  // the source is missing, hence the target is removed
+)
+
+// State of the file system entry on one side of an item when the plan was built
+type State struct {
+	Size    int64     `json:"size,omitempty"` // of anything but directories
+	ModTime time.Time `json:"mtime"`
+}
+
+// Item is a single path where the two sides differ
+type Item struct {
+	// slash-delimited path relative to the roots of the plan
+	Path      string    `json:"path"`
+	Left      Kind      `json:"left,omitempty"`
+	Right     Kind      `json:"right,omitempty"`
+	Direction Direction `json:"direction,omitempty"`
+	// the sides are checked against their states before the item is executed; nil for the missing sides
+	LeftState  *State `json:"leftState,omitempty"`
+	RightState *State `json:"rightState,omitempty"`
+	// the reason the direction can not be chosen automatically; the item is skipped unless the direction is set
+	Conflict string `json:"conflict,omitempty"`
+	Excluded bool   `json:"excluded,omitempty"`
+}
+
+// sides returns the kind of the side carried over, and the kind of the side changed by the item
+func (item Item) sides() (source, target Kind) {
+	if item.Direction == RightToLeft {
+		return item.Right, item.Left
+	}
+	return item.Left, item.Right
+}
+
+// states returns the states of the side carried over and of the side changed by the item
+func (item Item) states() (source, target *State) {
+	if item.Direction == RightToLeft {
+		return item.RightState, item.LeftState
+	}
+	return item.LeftState, item.RightState
+}
+
+// Action returns what happens to the target side once the plan is executed
+func (item Item) Action() Action {
+	if item.Excluded || item.Direction == None {
+		return Skip
+	}
+
+	source, target := item.sides()
+	switch {
+	case source == Missing:
+		return Delete
+	case target == Missing:
+		return Copy
+	default:
+		return Replace
+	}
+}
+
+// Flip reverses the direction of the item; the item without a direction goes from left to right
+func (item *Item) Flip() {
+	if item.Direction == LeftToRight {
+		item.Direction = RightToLeft
+	} else {
+		item.Direction = LeftToRight
+	}
+}
+
+// String of an Item
+func (item Item) String() string {
+	direction := item.Direction
+	if direction == None {
+		direction = "=="
+	}
+	return fmt.Sprintf("%s %s %s", direction, item.Action(), item.Path)
+}
+
+// Plan lists the changes that bring two directory trees in sync.
+// It is serialized to JSON, so that it can be reviewed, edited and executed later.
+type Plan struct {
+	Left  string `json:"left"`
+	Right string `json:"right"`
+	Mode  Mode   `json:"mode"`
+	Items []Item `json:"items"`
+}
+
+// Build creates the plan from the PWDs of the two trees, which must have been compared by model.CompareAndMark.
+// Directories present on both sides are not items themselves: the plan descends into them.
+func Build(left, right *model.FileTreeModel, mode Mode) (*Plan, error) {
+	if _, err := ParseMode(string(mode)); err != nil {
+		return nil, err
+	}
+	leftPwd, err := left.GetNode(left.GetPwd())
+	if err != nil {
+		return nil, err
+	}
+	rightPwd, err := right.GetNode(right.GetPwd())
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &Plan{Left: left.GetPwd(), Right: right.GetPwd(), Mode: mode}
+	plan.addItems("", leftPwd, rightPwd)
+	return plan, nil
+}
+
+// addItems adds the differences between the content of the two directories; either of them may be nil
+func (p *Plan) addItems(dir string, left, right *model.FileNode) {
+	names := make(map[string]bool)
+	for _, node := range []*model.FileNode{left, right} {
+		if node != nil {
+			for name := range node.Children {
+				names[name] = true
+			}
+		}
+	}
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+
+	for _, name := range sorted {
+		var leftNode, rightNode *model.FileNode
+		if left != nil {
+			leftNode = left.Children[name]
+		}
+		if right != nil {
+			rightNode = right.Children[name]
+		}
+
+		item := Item{Path: path.Join(dir, name), Left: kindOf(leftNode), Right: kindOf(rightNode),
+			LeftState: stateOf(leftNode), RightState: stateOf(rightNode)}
+		if item.Left == Dir && item.Right == Dir {
+			p.addItems(item.Path, leftNode, rightNode)
+			continue
+		}
+		if item.Left != Missing && item.Right != Missing && leftNode.Data.DiffType == model.Unmodified &&
+			rightNode.Data.DiffType == model.Unmodified {
+			continue
+		}
+
+		if p.decide(&item, leftNode, rightNode) {
+			p.Items = append(p.Items, item)
+		}
+	}
+}
+
+// decide sets the direction of the item according to the plan mode; returns false if the item is not a part of the plan
+func (p *Plan) decide(item *Item, left, right *model.FileNode) bool {
+	switch {
+	case item.Right == Missing:
+		item.Direction = LeftToRight
+		return true
+
+	case item.Left == Missing:
+		switch p.Mode {
+		case Mirror:
+			item.Direction = LeftToRight
+		case TwoWay:
+			item.Direction = RightToLeft
+		default:
+			return false
+		}
+		return true
+
+	case p.Mode == Mirror:
+		item.Direction = LeftToRight
+		return true
+
+	case item.Left != item.Right:
+		item.Conflict = "a file on one side, a directory on the other"
+		return true
+	}
+
+	leftTime, rightTime := left.Data.FileInfo.ModTime, right.Data.FileInfo.ModTime
+	switch {
+	case leftTime.After(rightTime):
+		item.Direction = LeftToRight
+	case p.Mode == Update:
+		// the right side is either newer, or modified at the same time
+		return false
+	case rightTime.After(leftTime):
+		item.Direction = RightToLeft
+	default:
+		item.Conflict = "both sides modified at the same time"
+	}
+	return true
+}
+
+// kindOf returns the kind of the file system entry represented by the node
+func kindOf(node *model.FileNode) Kind {
+	switch {
+	case node == nil:
+		return Missing
+	case node.IsDir():
+		return Dir
+	default:
+		return File
+	}
+}
+
+// stateOf returns the state of the file system entry represented by the node; nil for the missing node.
+// The time is kept in UTC, so that the state is the same once the plan is saved and loaded.
+func stateOf(node *model.FileNode) *State {
+	if node == nil {
+		return nil
+	}
+	state := &State{ModTime: node.Data.FileInfo.ModTime.UTC()}
+	if !node.IsDir() {
+		state.Size = node.Data.FileInfo.Size
+	}
+	return state
+}
+
+// Counts returns the number of items for every Action
+func (p *Plan) Counts() map[Action]int {
+	counts := make(map[Action]int)
+	for _, item := range p.Items {
+		counts[item.Action()]++
+	}
+	return counts
+}
+
+// Save writes the plan as JSON into the file
+func (p *Plan) Save(fqfp string) error {
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(fqfp, append(data, '\n'), 0644)
+}
+
+// Load reads the plan from the JSON file
+func Load(fqfp string) (*Plan, error) {
+	data, err := ioutil.ReadFile(fqfp)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := new(Plan)
+	if err = json.Unmarshal(data, plan); err != nil {
+		return nil, fmt.Errorf("%s: %w", fqfp, err)
+	}
+	if _, err = ParseMode(string(plan.Mode)); err != nil {
+		return nil, fmt.Errorf("%s: %w", fqfp, err)
+	}
+	if plan.Left == "" || plan.Right == "" {
+		return nil, fmt.Errorf("%s: both left and right directories are required", fqfp)
+	}
+	for _, item := range plan.Items {
+		if item.Direction != None && item.Direction != LeftToRight && item.Direction != RightToLeft {
+			return nil, fmt.Errorf("%s: %s: unknown direction %q", fqfp, item.Path, item.Direction)
+		}
+		if item.Path == "" || path.IsAbs(item.Path) || strings.HasPrefix(path.Clean(item.Path), "..") {
+			return nil, fmt.Errorf("%s: %q: the path must be relative to the plan directories", fqfp, item.Path)
+		}
+	}
+	return plan, nil
+}
@@ -0,0 +1,260 @@
+package syncplan
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/mushkevych/9ofm/commander/model"
+)
+
+// modification times of the fixtures are relative to the same moment, so that both sides can be modified at the same time
+var now = time.Now().Truncate(time.Second)
+
+type fixture struct {
+	name    string
+	content string
+	age     time.Duration
+}
+
+func helperWriteFixtures(t *testing.T, dir string, fixtures []fixture) {
+	for _, f := range fixtures {
+		fqfp := filepath.Join(dir, f.name)
+		if err := os.MkdirAll(filepath.Dir(fqfp), 0755); err != nil {
+			t.Fatalf("could not setup test: %v", err)
+		}
+		if err := ioutil.WriteFile(fqfp, []byte(f.content), 0644); err != nil {
+			t.Fatalf("could not setup test: %v", err)
+		}
+		modTime := now.Add(-f.age)
+		if err := os.Chtimes(fqfp, modTime, modTime); err != nil {
+			t.Fatalf("could not setup test: %v", err)
+		}
+	}
+}
+
+// helperSyncDirs creates two directories that differ in every way the plan knows of
+func helperSyncDirs(t *testing.T) (dirA, dirB string) {
+	root := t.TempDir()
+
+	dirA, dirB = filepath.Join(root, "alpha"), filepath.Join(root, "beta")
+	helperWriteFixtures(t, dirA, []fixture{
+		{"same.txt", "same", time.Hour},
+		{"left-only.txt", "left", time.Hour},
+		{"left-newer.txt", "new content", time.Minute},
+		{"right-newer.txt", "old", time.Hour},
+		{"tie.txt", "left tie", time.Hour},
+		{"nested/deep/file.txt", "left", time.Hour},
+		{"kind", "a file on the left", time.Hour},
+	})
+	helperWriteFixtures(t, dirB, []fixture{
+		{"same.txt", "same", time.Hour},
+		{"right-only.txt", "right", time.Hour},
+		{"left-newer.txt", "old", time.Hour},
+		{"right-newer.txt", "new content", time.Minute},
+		{"tie.txt", "right side tie", time.Hour},
+		{"kind/file.txt", "a directory on the right", time.Hour},
+	})
+	return dirA, dirB
+}
+
+func helperBuild(t *testing.T, dirA, dirB string, mode Mode) *Plan {
+	var trees [2]*model.FileTreeModel
+	for idx, dir := range []string{dirA, dirB} {
+		tree, err := model.ReadFileTreeWithOptions(dir, model.ReadOptions{}, nil)
+		if err != nil {
+			t.Fatalf("unable to read tree: %v", err)
+		}
+		trees[idx] = tree
+	}
+	if err := model.CompareAndMark(trees[0], trees[1]); err != nil {
+		t.Fatalf("unable to compare and mark: %+v", err)
+	}
+
+	plan, err := Build(trees[0], trees[1], mode)
+	if err != nil {
+		t.Fatalf("unable to build plan: %v", err)
+	}
+	return plan
+}
+
+// describe lists the items of the plan as strings
+func describe(plan *Plan) []string {
+	var items []string
+	for _, item := range plan.Items {
+		items = append(items, item.String())
+	}
+	return items
+}
+
+func TestBuild(t *testing.T) {
+	dirA, dirB := helperSyncDirs(t)
+	cases := map[Mode][]string{
+		Mirror: {
+			"-> replace kind",
+			"-> replace left-newer.txt",
+			"-> copy left-only.txt",
+			"-> copy nested",
+			"-> replace right-newer.txt",
+			"-> delete right-only.txt",
+			"-> replace tie.txt",
+		},
+		Update: {
+			"== skip kind",
+			"-> replace left-newer.txt",
+			"-> copy left-only.txt",
+			"-> copy nested",
+		},
+		TwoWay: {
+			"== skip kind",
+			"-> replace left-newer.txt",
+			"-> copy left-only.txt",
+			"-> copy nested",
+			"<- replace right-newer.txt",
+			"<- copy right-only.txt",
+			"== skip tie.txt",
+		},
+	}
+
+	for mode, expected := range cases {
+		plan := helperBuild(t, dirA, dirB, mode)
+		if actual := describe(plan); !reflect.DeepEqual(actual, expected) {
+			t.Errorf("%s: expected items %q, got %q", mode, expected, actual)
+		}
+	}
+
+	plan := helperBuild(t, dirA, dirB, TwoWay)
+	if plan.Items[len(plan.Items)-1].Conflict == "" {
+		t.Errorf("expected the files modified at the same time to be a conflict")
+	}
+}
+
+func TestItemFlipAndExclude(t *testing.T) {
+	item := Item{Path: "file.txt", Left: File}
+	if action := item.Action(); action != Skip {
+		t.Errorf("expected the item without a direction to be skipped, got %s", action)
+	}
+
+	item.Flip()
+	if item.Direction != LeftToRight || item.Action() != Copy {
+		t.Errorf("expected the item to be copied to the right, got %s", item)
+	}
+	item.Flip()
+	if item.Direction != RightToLeft || item.Action() != Delete {
+		t.Errorf("expected the item to be deleted from the left, got %s", item)
+	}
+
+	item.Excluded = true
+	if action := item.Action(); action != Skip {
+		t.Errorf("expected the excluded item to be skipped, got %s", action)
+	}
+}
+
+func TestExecuteMirror(t *testing.T) {
+	dirA, dirB := helperSyncDirs(t)
+	plan := helperBuild(t, dirA, dirB, Mirror)
+
+	var executed int
+	err := plan.Execute(nil, func(item Item, err error) {
+		executed++
+		if err != nil {
+			t.Errorf("%s: %v", item, err)
+		}
+	})
+	if err != nil {
+		t.Fatalf("unable to execute plan: %v", err)
+	}
+	if executed != len(plan.Items) {
+		t.Errorf("expected %d items to be executed, got %d", len(plan.Items), executed)
+	}
+
+	if items := describe(helperBuild(t, dirA, dirB, Mirror)); len(items) != 0 {
+		t.Errorf("expected the directories to be in sync, got %q", items)
+	}
+	content, err := ioutil.ReadFile(filepath.Join(dirB, "kind"))
+	if err != nil || string(content) != "a file on the left" {
+		t.Errorf("expected the directory to be replaced by the file, got %q, %v", content, err)
+	}
+}
+
+func TestExecuteChanged(t *testing.T) {
+	dirA, dirB := helperSyncDirs(t)
+	plan := helperBuild(t, dirA, dirB, TwoWay)
+	if err := os.Remove(filepath.Join(dirB, "right-only.txt")); err != nil {
+		t.Fatalf("could not setup test: %v", err)
+	}
+
+	err := plan.Execute(nil, nil)
+	if !errors.Is(err, ErrChanged) {
+		t.Fatalf("expected the changed item to fail, got %v", err)
+	}
+	if _, err = os.Stat(filepath.Join(dirA, "right-only.txt")); !os.IsNotExist(err) {
+		t.Errorf("expected the changed item to be left alone, got %v", err)
+	}
+	if _, err = os.Stat(filepath.Join(dirB, "left-only.txt")); err != nil {
+		t.Errorf("expected the rest of the items to be executed, got %v", err)
+	}
+}
+
+func TestExecuteModified(t *testing.T) {
+	dirA, dirB := helperSyncDirs(t)
+	plan := helperBuild(t, dirA, dirB, Mirror)
+
+	// the target edited after the plan was built keeps its kind, but not its size nor its modification time
+	helperWriteFixtures(t, dirB, []fixture{{"left-newer.txt", "edited since", 0}})
+	// the target touched after the plan was built keeps its size
+	touched := filepath.Join(dirB, "right-only.txt")
+	if err := os.Chtimes(touched, now, now); err != nil {
+		t.Fatalf("could not setup test: %v", err)
+	}
+
+	failed := make(map[string]bool)
+	err := plan.Execute(nil, func(item Item, err error) {
+		if errors.Is(err, ErrChanged) {
+			failed[item.Path] = true
+		}
+	})
+	if !errors.Is(err, ErrChanged) {
+		t.Fatalf("expected the modified items to fail, got %v", err)
+	}
+	if expected := map[string]bool{"left-newer.txt": true, "right-only.txt": true}; !reflect.DeepEqual(failed, expected) {
+		t.Errorf("expected %v to fail, got %v", expected, failed)
+	}
+	if content, err := ioutil.ReadFile(filepath.Join(dirB, "left-newer.txt")); err != nil || string(content) != "edited since" {
+		t.Errorf("expected the edited target to be kept, got %q, %v", content, err)
+	}
+	if _, err = os.Stat(touched); err != nil {
+		t.Errorf("expected the touched target not to be deleted, got %v", err)
+	}
+}
+
+func TestSaveAndLoad(t *testing.T) {
+	dirA, dirB := helperSyncDirs(t)
+	plan := helperBuild(t, dirA, dirB, TwoWay)
+	plan.Items[0].Flip()
+	plan.Items[1].Excluded = true
+
+	fqfp := filepath.Join(filepath.Dir(dirA), "plan.json")
+	if err := plan.Save(fqfp); err != nil {
+		t.Fatalf("unable to save plan: %v", err)
+	}
+	loaded, err := Load(fqfp)
+	if err != nil {
+		t.Fatalf("unable to load plan: %v", err)
+	}
+	if !reflect.DeepEqual(plan, loaded) {
+		t.Errorf("expected the loaded plan to equal the saved one:\n%+v\n%+v", plan, loaded)
+	}
+
+	if err = ioutil.WriteFile(fqfp, []byte(`{"left": "/a", "right": "/b", "mode": "mirror",
+		"items": [{"path": "../escape", "left": "file", "direction": "->"}]}`), 0644); err != nil {
+		t.Fatalf("could not setup test: %v", err)
+	}
+	if _, err = Load(fqfp); err == nil {
+		t.Errorf("expected the path outside of the plan directories to be rejected")
+	}
+}
@@ -11,6 +11,22 @@ import (
 
 var Config go_up.GoUp
 
+// directory for the files maintained by 9ofm itself, such as the undo journal: $HOME/.config/9ofm
+var configDir string
+
+// ConfigDir returns the directory for the files maintained by 9ofm, creating it if needed
+func ConfigDir() (string, error) {
+	return configDir, os.MkdirAll(configDir, 0700)
+}
+
+// directory for the data that can be recomputed, such as the hashes of the files: $HOME/.cache/9ofm
+var cacheDir string
+
+// CacheDir returns the directory for the cached data, creating it if needed
+func CacheDir() (string, error) {
+	return cacheDir, os.MkdirAll(cacheDir, 0700)
+}
+
 func init() {
 	var err error
 
@@ -21,6 +37,8 @@ func init() {
 	}
 	// $HOME/.config/.9ofm.yaml
 	configFilePath := path.Join(home, ".config", ".9ofm.yaml")
+	configDir = path.Join(home, ".config", "9ofm")
+	cacheDir = path.Join(home, ".cache", "9ofm")
 
 	ignoreFileNotFound := true
 	Config, err = go_up.NewGoUp().
@@ -30,8 +48,20 @@ func init() {
 		Add("log.path", "./9ofm.log").
 		Add("debug", "false").
 		Add("log.enabled", "true").
-
-		Add("diff.hide", "Modified,Added,Removed").
+		Add("editor", "").                    // external editor for F4; falls back to $EDITOR
+		Add("shell", "").                     // subshell for F9; falls back to $SHELL
+		Add("conflict.policy", "ask").        // ask, overwrite, skip, rename, newer, size or abort
+		Add("jobs.workers", "1").             // number of file operations running in parallel
+		Add("delete.mode", "delete").         // F8 either deletes files, or moves them to the trash: delete or trash
+		Add("journal.size", "1000").          // number of operations kept in the undo journal
+		Add("diff.hide", "").                 // DiffTypes hidden in the compared panels, e.g. Unmodified; all are shown by default
+		Add("compare.criteria", "").          // properties compared: size, mtime, perms, owner, link and hash; empty for model.DefaultCriteria
+		Add("compare.mtime.tolerance", "2s"). // largest difference of the modification times still equal
+		Add("compare.workers", "4").          // number of files hashed in parallel
+		Add("compare.depth", "0").            // directory levels read by the deep comparison; 0 reads the whole tree
+		Add("compare.exclude", "").           // names left out of the deep comparison, e.g. .git,node_modules
+		Add("compare.cache", "100000").       // number of file hashes kept in the cache; 0 disables the cache
+		Add("history.size", "50").            // number of directories kept in the back/forward history of each panel
 		Build()
 
 	if err != nil {
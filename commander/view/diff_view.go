@@ -0,0 +1,362 @@
+package view
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/mushkevych/9ofm/utils"
+	"github.com/sergi/go-diff/diffmatchpatch"
+)
+
+// maxDiffFileSize limits the size of the files loaded into the DiffView
+const maxDiffFileSize = 16 * 1024 * 1024
+
+// ErrBinaryFile is returned for the files that can not be presented as lines of text
+var ErrBinaryFile = errors.New("binary file")
+
+// ErrFileChanged is returned when the file to save has been modified by someone else since it was loaded
+var ErrFileChanged = errors.New("file has changed since it was loaded")
+
+// DiffRowType tells how a single row of the DiffView differs between the sides
+type DiffRowType int
+
+const (
+	DiffEqual    DiffRowType = iota
+	DiffDeleted              // the line is present on the left side only
+	DiffInserted             // the line is present on the right side only
+	DiffChanged              // the lines of both sides differ
+)
+
+// DiffRow is a single row of the DiffView; Left and Right are the line indexes, -1 if the side has no line in the row
+type DiffRow struct {
+	Type  DiffRowType
+	Left  int
+	Right int
+}
+
+// Hunk is a group of the adjacent rows that differ; ranges are half-open
+type Hunk struct {
+	FirstRow, EndRow     int
+	LeftStart, LeftEnd   int
+	RightStart, RightEnd int
+}
+
+// DiffSide is the content of one of the compared files
+type DiffSide struct {
+	Path     string
+	Lines    []string
+	Modified bool // the lines were changed since the file was loaded or saved
+
+	trailingNewline bool
+	// modification time of the file when it was loaded or saved
+	modTime time.Time
+}
+
+// DiffView presents two text files side by side, aligning the equal lines and grouping the differences into hunks
+type DiffView struct {
+	Left  *DiffSide
+	Right *DiffSide
+
+	Rows  []DiffRow
+	Hunks []Hunk
+
+	// index of the current hunk; -1 if the files are equal
+	Hunk int
+
+	// the top-most row presented
+	Top int
+}
+
+// NewDiffView loads both text files and compares them line by line
+func NewDiffView(leftPath, rightPath string) (*DiffView, error) {
+	left, err := loadDiffSide(leftPath)
+	if err != nil {
+		return nil, err
+	}
+	right, err := loadDiffSide(rightPath)
+	if err != nil {
+		return nil, err
+	}
+
+	v := &DiffView{Left: left, Right: right, Hunk: -1}
+	v.compare()
+	return v, nil
+}
+
+// loadDiffSide reads the lines of the text file
+func loadDiffSide(fqfp string) (*DiffSide, error) {
+	info, err := os.Stat(fqfp)
+	if err != nil {
+		return nil, err
+	}
+	if info.Size() > maxDiffFileSize {
+		return nil, fmt.Errorf("%s: the file is larger than %d bytes", fqfp, maxDiffFileSize)
+	}
+
+	data, err := ioutil.ReadFile(fqfp)
+	if err != nil {
+		return nil, err
+	}
+	if bytes.IndexByte(data, 0) >= 0 || !utf8.Valid(data) {
+		return nil, fmt.Errorf("%s: %w", fqfp, ErrBinaryFile)
+	}
+
+	side := &DiffSide{Path: fqfp, modTime: info.ModTime()}
+	if len(data) == 0 {
+		return side, nil
+	}
+	text := string(data)
+	side.trailingNewline = strings.HasSuffix(text, "\n")
+	side.Lines = strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+	return side, nil
+}
+
+// text joins the lines back into the file content
+func (s *DiffSide) text() string {
+	if len(s.Lines) == 0 {
+		return ""
+	}
+	text := strings.Join(s.Lines, "\n")
+	if s.trailingNewline {
+		text += "\n"
+	}
+	return text
+}
+
+// Line returns the line at the index with the tabs expanded into spaces
+func (s *DiffSide) Line(idx int) string {
+	line := s.Lines[idx]
+	if !strings.Contains(line, "\t") {
+		return line
+	}
+
+	var builder strings.Builder
+	column := 0
+	for _, r := range line {
+		if r == '\t' {
+			spaces := tabWidth - column%tabWidth
+			builder.WriteString(strings.Repeat(" ", spaces))
+			column += spaces
+			continue
+		}
+		builder.WriteRune(r)
+		column++
+	}
+	return builder.String()
+}
+
+// save writes the lines into the file, keeping its permissions, unless the file has changed since it was loaded.
+// The lines are written into a temporary file, which replaces the file once complete; the symbolic link
+// to the file is kept, and the file it points at is replaced.
+func (s *DiffSide) save() error {
+	path, err := filepath.EvalSymlinks(s.Path)
+	if err != nil {
+		return err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if !info.ModTime().Equal(s.modTime) {
+		return fmt.Errorf("%s: %w", s.Path, ErrFileChanged)
+	}
+
+	err = utils.WriteFileAtomic(path, info.Mode().Perm(), func(w io.Writer) error {
+		_, err := io.WriteString(w, s.text())
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	if info, err = os.Stat(path); err != nil {
+		return err
+	}
+	s.modTime = info.ModTime()
+	s.Modified = false
+	return nil
+}
+
+// compare aligns the lines of both sides into the rows and the hunks
+func (v *DiffView) compare() {
+	// every line is terminated, so that the last line matches regardless of the trailing newline
+	terminated := func(lines []string) string {
+		if len(lines) == 0 {
+			return ""
+		}
+		return strings.Join(lines, "\n") + "\n"
+	}
+
+	dmp := diffmatchpatch.New()
+	leftRunes, rightRunes, _ := dmp.DiffLinesToRunes(terminated(v.Left.Lines), terminated(v.Right.Lines))
+	diffs := dmp.DiffMainRunes(leftRunes, rightRunes, false)
+
+	v.Rows, v.Hunks = nil, nil
+	left, right := 0, 0
+	for idx := 0; idx < len(diffs); {
+		// every rune of the diff stands for a single line
+		if diffs[idx].Type == diffmatchpatch.DiffEqual {
+			for count := utf8.RuneCountInString(diffs[idx].Text); count > 0; count-- {
+				v.Rows = append(v.Rows, DiffRow{Type: DiffEqual, Left: left, Right: right})
+				left, right = left+1, right+1
+			}
+			idx++
+			continue
+		}
+
+		// adjacent deletions and insertions make up a single hunk
+		deleted, inserted := 0, 0
+		for ; idx < len(diffs) && diffs[idx].Type != diffmatchpatch.DiffEqual; idx++ {
+			if diffs[idx].Type == diffmatchpatch.DiffDelete {
+				deleted += utf8.RuneCountInString(diffs[idx].Text)
+			} else {
+				inserted += utf8.RuneCountInString(diffs[idx].Text)
+			}
+		}
+
+		hunk := Hunk{FirstRow: len(v.Rows), LeftStart: left, LeftEnd: left + deleted, RightStart: right, RightEnd: right + inserted}
+		for row := 0; row < deleted || row < inserted; row++ {
+			switch {
+			case row < deleted && row < inserted:
+				v.Rows = append(v.Rows, DiffRow{Type: DiffChanged, Left: left + row, Right: right + row})
+			case row < deleted:
+				v.Rows = append(v.Rows, DiffRow{Type: DiffDeleted, Left: left + row, Right: -1})
+			default:
+				v.Rows = append(v.Rows, DiffRow{Type: DiffInserted, Left: -1, Right: right + row})
+			}
+		}
+		hunk.EndRow = len(v.Rows)
+		v.Hunks = append(v.Hunks, hunk)
+		left, right = hunk.LeftEnd, hunk.RightEnd
+	}
+
+	if v.Hunk >= len(v.Hunks) {
+		v.Hunk = len(v.Hunks) - 1
+	}
+	if v.Hunk < 0 && len(v.Hunks) > 0 {
+		v.Hunk = 0
+	}
+}
+
+// NextHunk makes the following hunk the current one and scrolls to it; returns false if there is none
+func (v *DiffView) NextHunk(height int) bool {
+	if v.Hunk+1 >= len(v.Hunks) {
+		return false
+	}
+	v.Hunk++
+	v.scrollToHunk(height)
+	return true
+}
+
+// PreviousHunk makes the preceding hunk the current one and scrolls to it; returns false if there is none
+func (v *DiffView) PreviousHunk(height int) bool {
+	if v.Hunk <= 0 {
+		return false
+	}
+	v.Hunk--
+	v.scrollToHunk(height)
+	return true
+}
+
+// scrollToHunk shows the current hunk, preceded by a few rows of the context, unless it is visible already
+func (v *DiffView) scrollToHunk(height int) {
+	if v.Hunk < 0 {
+		return
+	}
+	hunk := v.Hunks[v.Hunk]
+	if hunk.FirstRow >= v.Top && hunk.EndRow <= v.Top+height {
+		return
+	}
+
+	context := 3
+	if height <= 2*context {
+		context = 0
+	}
+	v.Top = hunk.FirstRow - context
+	v.clampTop(height)
+}
+
+// CopyHunk replaces the lines of the current hunk on the target side with the lines of the other side,
+// and compares the files again
+func (v *DiffView) CopyHunk(leftToRight bool, height int) error {
+	if v.Hunk < 0 {
+		return errors.New("the files are equal")
+	}
+
+	hunk := v.Hunks[v.Hunk]
+	source, target := v.Left, v.Right
+	sourceStart, sourceEnd, targetStart, targetEnd := hunk.LeftStart, hunk.LeftEnd, hunk.RightStart, hunk.RightEnd
+	if !leftToRight {
+		source, target = v.Right, v.Left
+		sourceStart, sourceEnd, targetStart, targetEnd = hunk.RightStart, hunk.RightEnd, hunk.LeftStart, hunk.LeftEnd
+	}
+
+	if sourceEnd == len(source.Lines) && targetEnd == len(target.Lines) {
+		// the end of the file is copied as well
+		target.trailingNewline = source.trailingNewline
+	}
+	lines := make([]string, 0, len(target.Lines)-(targetEnd-targetStart)+(sourceEnd-sourceStart))
+	lines = append(lines, target.Lines[:targetStart]...)
+	lines = append(lines, source.Lines[sourceStart:sourceEnd]...)
+	lines = append(lines, target.Lines[targetEnd:]...)
+	target.Lines = lines
+	target.Modified = true
+
+	v.compare()
+	v.scrollToHunk(height)
+	return nil
+}
+
+// Save writes the modified sides into their files
+func (v *DiffView) Save() error {
+	for _, side := range []*DiffSide{v.Left, v.Right} {
+		if !side.Modified {
+			continue
+		}
+		if err := side.save(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// IsModified returns true if any of the sides has unsaved changes
+func (v *DiffView) IsModified() bool {
+	return v.Left.Modified || v.Right.Modified
+}
+
+// ScrollDown moves the view n rows down, without scrolling past the last row
+func (v *DiffView) ScrollDown(n, height int) {
+	v.Top += n
+	v.clampTop(height)
+}
+
+// ScrollUp moves the view n rows up
+func (v *DiffView) ScrollUp(n, height int) {
+	v.Top -= n
+	v.clampTop(height)
+}
+
+// ScrollToEnd shows the last rows
+func (v *DiffView) ScrollToEnd(height int) {
+	v.Top = len(v.Rows)
+	v.clampTop(height)
+}
+
+// clampTop keeps the top-most row within the rows, so that the last page is filled
+func (v *DiffView) clampTop(height int) {
+	if v.Top > len(v.Rows)-height {
+		v.Top = len(v.Rows) - height
+	}
+	if v.Top < 0 {
+		v.Top = 0
+	}
+}
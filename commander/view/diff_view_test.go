@@ -0,0 +1,144 @@
+package view
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func helperCreateDiffView(t *testing.T, left, right string) (*DiffView, string, string) {
+	dir := t.TempDir()
+
+	leftPath, rightPath := filepath.Join(dir, "left"), filepath.Join(dir, "right")
+	for path, content := range map[string]string{leftPath: left, rightPath: right} {
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("unable to write temp file: %v", err)
+		}
+	}
+
+	dv, err := NewDiffView(leftPath, rightPath)
+	if err != nil {
+		t.Fatalf("unable to open diff view: %v", err)
+	}
+	return dv, leftPath, rightPath
+}
+
+func TestDiffViewRows(t *testing.T) {
+	dv, _, _ := helperCreateDiffView(t, "a\nb\nc\nd\ne\n", "a\nB\nc\ne\nf\n")
+
+	expected := []DiffRow{
+		{DiffEqual, 0, 0},
+		{DiffChanged, 1, 1},
+		{DiffEqual, 2, 2},
+		{DiffDeleted, 3, -1},
+		{DiffEqual, 4, 3},
+		{DiffInserted, -1, 4},
+	}
+	if !reflect.DeepEqual(dv.Rows, expected) {
+		t.Errorf("expected rows %v, got %v", expected, dv.Rows)
+	}
+	if len(dv.Hunks) != 3 || dv.Hunk != 0 {
+		t.Fatalf("expected 3 hunks with the first one current, got %d hunks and %d", len(dv.Hunks), dv.Hunk)
+	}
+
+	if !dv.NextHunk(10) || !dv.NextHunk(10) || dv.NextHunk(10) {
+		t.Errorf("expected to move through the hunks up to the last one")
+	}
+	if !dv.PreviousHunk(10) || dv.Hunk != 1 {
+		t.Errorf("expected to move back to the second hunk, got %d", dv.Hunk)
+	}
+}
+
+func TestDiffViewCopyHunk(t *testing.T) {
+	dv, leftPath, rightPath := helperCreateDiffView(t, "a\nb\nc\nd\n", "a\nB\nc\n")
+
+	// the changed line goes to the right side
+	if err := dv.CopyHunk(true, 10); err != nil {
+		t.Fatalf("unable to copy hunk: %v", err)
+	}
+	if len(dv.Hunks) != 1 || !dv.Right.Modified || dv.Left.Modified {
+		t.Fatalf("expected only the deleted line to differ, got %d hunks", len(dv.Hunks))
+	}
+
+	// the line missing on the right side is removed from the left one
+	if err := dv.CopyHunk(false, 10); err != nil {
+		t.Fatalf("unable to copy hunk: %v", err)
+	}
+	if len(dv.Hunks) != 0 || dv.Hunk != -1 {
+		t.Fatalf("expected the files to be equal, got %d hunks", len(dv.Hunks))
+	}
+	if err := dv.CopyHunk(true, 10); err == nil {
+		t.Errorf("expected no hunk to copy")
+	}
+
+	if err := dv.Save(); err != nil {
+		t.Fatalf("unable to save: %v", err)
+	}
+	if dv.IsModified() {
+		t.Errorf("expected the saved sides not to be modified")
+	}
+	for _, path := range []string{leftPath, rightPath} {
+		content, err := ioutil.ReadFile(path)
+		if err != nil || string(content) != "a\nb\nc\n" {
+			t.Errorf("expected %s to be saved, got %q, %v", path, content, err)
+		}
+		if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0644 {
+			t.Errorf("expected %s to keep its permissions, got %v, %v", path, info.Mode(), err)
+		}
+	}
+}
+
+func TestDiffViewSaveChangedFile(t *testing.T) {
+	dv, leftPath, rightPath := helperCreateDiffView(t, "a\nb\n", "a\nB\n")
+	if err := dv.CopyHunk(true, 10); err != nil {
+		t.Fatalf("unable to copy hunk: %v", err)
+	}
+
+	// someone else writes the file after it was loaded
+	if err := ioutil.WriteFile(rightPath, []byte("a\nC\n"), 0644); err != nil {
+		t.Fatalf("unable to write temp file: %v", err)
+	}
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(rightPath, later, later); err != nil {
+		t.Fatalf("unable to touch temp file: %v", err)
+	}
+
+	if err := dv.Save(); !errors.Is(err, ErrFileChanged) {
+		t.Fatalf("expected the changed file not to be overwritten, got %v", err)
+	}
+	if content, err := ioutil.ReadFile(rightPath); err != nil || string(content) != "a\nC\n" {
+		t.Errorf("expected the changed file to be kept, got %q, %v", content, err)
+	}
+	if !dv.Right.Modified {
+		t.Errorf("expected the unsaved side to stay modified")
+	}
+	if entries, err := ioutil.ReadDir(filepath.Dir(leftPath)); err != nil || len(entries) != 2 {
+		t.Errorf("expected no temporary files left, got %d entries, %v", len(entries), err)
+	}
+}
+
+func TestDiffViewBinaryFile(t *testing.T) {
+	dir := t.TempDir()
+
+	path := filepath.Join(dir, "binary")
+	if err := ioutil.WriteFile(path, []byte{'a', 0, 'b'}, 0644); err != nil {
+		t.Fatalf("unable to write temp file: %v", err)
+	}
+	if _, err := NewDiffView(path, path); err == nil {
+		t.Errorf("expected the binary file to be refused")
+	}
+}
+
+func TestDiffSideLine(t *testing.T) {
+	side := &DiffSide{Lines: []string{"\tx", "ab\tc"}}
+	if line := side.Line(0); line != "        x" {
+		t.Errorf("expected the tab to be expanded, got %q", line)
+	}
+	if line := side.Line(1); line != "ab      c" {
+		t.Errorf("expected the tab to be expanded to the tab stop, got %q", line)
+	}
+}
@@ -3,6 +3,7 @@ package view
 import (
 	"fmt"
 	"github.com/mushkevych/9ofm/commander/system"
+	"path/filepath"
 	"strings"
 
 	"github.com/mushkevych/9ofm/commander/model"
@@ -15,6 +16,15 @@ type FileTreeView struct {
 	ModelTree *model.FileTreeModel
 
 	HiddenDiffTypes []bool
+
+	// true once the tree is compared with another one; DiffTypes are hidden only in the compared tree
+	Compared bool
+	// PWD at the time of the comparison, and the number of directory levels compared below it; 0 is unlimited
+	compareRoot  string
+	compareDepth int
+
+	// names of the marked files in the PWD
+	marked map[string]bool
 }
 
 // NewFileTreeView creates a new view object attached the the global [tview] screen object.
@@ -24,27 +34,194 @@ func NewFileTreeView(tree *model.FileTreeModel) (treeViewModel *FileTreeView, er
 	// populate main fields
 	treeViewModel.ModelTree = tree
 	treeViewModel.HiddenDiffTypes = make([]bool, 4)
+	treeViewModel.marked = make(map[string]bool)
 
-	hiddenTypes := system.Config.GetStringSlice("diff.hide", ",")
-	for _, hType := range hiddenTypes {
-		switch t := strings.ToLower(hType); t {
-		case "added":
-			treeViewModel.HiddenDiffTypes[model.Added] = true
-		case "removed":
-			treeViewModel.HiddenDiffTypes[model.Removed] = true
-		case "modified":
-			treeViewModel.HiddenDiffTypes[model.Modified] = true
-		case "unmodified":
-			treeViewModel.HiddenDiffTypes[model.Unmodified] = true
-		default:
-			return nil, fmt.Errorf("unknown diff.hide value: %s", t)
-		}
+	if err = treeViewModel.HideDiffTypes(system.Config.GetStringSlice("diff.hide", ",")); err != nil {
+		return nil, fmt.Errorf("invalid diff.hide setting: %w", err)
 	}
 
 	return treeViewModel, nil
 }
 
+// diffTypes are the DiffTypes by their lower-case names
+var diffTypes = map[string]model.DiffType{
+	"added":      model.Added,
+	"removed":    model.Removed,
+	"modified":   model.Modified,
+	"unmodified": model.Unmodified,
+}
+
+// HideDiffTypes hides the DiffTypes of the given names, such as "Unmodified", and shows the rest; blank names are skipped
+func (v *FileTreeView) HideDiffTypes(names []string) error {
+	hidden := make([]bool, len(v.HiddenDiffTypes))
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		diffType, ok := diffTypes[name]
+		if !ok {
+			return fmt.Errorf("unknown DiffType: %s", name)
+		}
+		hidden[diffType] = true
+	}
+	v.HiddenDiffTypes = hidden
+	return nil
+}
+
+// HiddenDiffTypeNames returns the names of the hidden DiffTypes
+func (v *FileTreeView) HiddenDiffTypeNames() []string {
+	var names []string
+	for _, diffType := range []model.DiffType{model.Added, model.Removed, model.Modified, model.Unmodified} {
+		if v.HiddenDiffTypes[diffType] {
+			names = append(names, diffType.String())
+		}
+	}
+	return names
+}
+
+// CopyHiddenDiffTypes carries over the DiffTypes hidden in the other view, such as the one before the refresh
+func (v *FileTreeView) CopyHiddenDiffTypes(other *FileTreeView) {
+	if other != nil {
+		copy(v.HiddenDiffTypes, other.HiddenDiffTypes)
+	}
+}
+
 // ToggleShowDiffType will show/hide the selected DiffType in the FileTree pane.
 func (v *FileTreeView) ToggleShowDiffType(diffType model.DiffType) {
 	v.HiddenDiffTypes[diffType] = !v.HiddenDiffTypes[diffType]
 }
+
+// SetCompared records that the tree has been compared with another one, down to depth levels below the PWD;
+// 0 depth stands for the whole tree
+func (v *FileTreeView) SetCompared(depth int) {
+	v.Compared = true
+	v.compareRoot = v.ModelTree.GetPwd()
+	v.compareDepth = depth
+}
+
+// CompareRoot returns the PWD at the time of the comparison; empty unless the tree has been compared
+func (v *FileTreeView) CompareRoot() string {
+	return v.compareRoot
+}
+
+// IsComparedDir returns true if the content of the directory has been read and compared,
+// hence the directory can be entered without losing the result of the comparison
+func (v *FileTreeView) IsComparedDir(fqfp string) bool {
+	if !v.Compared {
+		return false
+	}
+	depth := model.DepthBelow(v.compareRoot, fqfp)
+	return depth >= 0 && (v.compareDepth == 0 || depth < v.compareDepth)
+}
+
+// ChangePwd enters the directory of the tree; the marks are cleared
+func (v *FileTreeView) ChangePwd(fqfp string) error {
+	if err := v.ModelTree.SetPwd(fqfp); err != nil {
+		return err
+	}
+	v.ClearMarks()
+	return nil
+}
+
+// IsHidden returns true if the file in the PWD is not listed, since its DiffType is hidden.
+// Nothing is hidden until the tree is compared; the ".." parent reference is never hidden.
+func (v *FileTreeView) IsHidden(fileNode *model.FileNode) bool {
+	if !v.Compared || fileNode == v.ModelTree.GetNodeByName("..") {
+		return false
+	}
+	return v.HiddenDiffTypes[fileNode.Data.DiffType]
+}
+
+// IsMarked returns true if the file in the PWD is marked
+func (v *FileTreeView) IsMarked(fileNode *model.FileNode) bool {
+	return fileNode != nil && v.marked[fileNode.Name]
+}
+
+// ToggleMark marks or unmarks the file in the PWD; the ".." parent reference can not be marked
+func (v *FileTreeView) ToggleMark(fileNode *model.FileNode) {
+	if fileNode == nil || v.ModelTree.GetNodeByName(fileNode.Name) != fileNode {
+		return
+	}
+
+	if v.marked[fileNode.Name] {
+		delete(v.marked, fileNode.Name)
+	} else {
+		v.marked[fileNode.Name] = true
+	}
+}
+
+// MarkByGlob marks (or unmarks, if mark is false) files in the PWD whose names match the shell pattern;
+// hidden files are left as they are.
+// Returns the number of files whose state has changed.
+func (v *FileTreeView) MarkByGlob(pattern string, mark bool) (int, error) {
+	if _, err := filepath.Match(pattern, ""); err != nil {
+		return 0, err
+	}
+
+	changed := 0
+	for _, fileNode := range v.ModelTree.PwdChildren() {
+		if matched, _ := filepath.Match(pattern, fileNode.Name); !matched || v.IsHidden(fileNode) || v.marked[fileNode.Name] == mark {
+			continue
+		}
+
+		if mark {
+			v.marked[fileNode.Name] = true
+		} else {
+			delete(v.marked, fileNode.Name)
+		}
+		changed++
+	}
+	return changed, nil
+}
+
+// InvertMarks marks all unmarked files in the PWD, and unmarks the marked ones; hidden files are left as they are
+func (v *FileTreeView) InvertMarks() {
+	for _, fileNode := range v.ModelTree.PwdChildren() {
+		if !v.IsHidden(fileNode) {
+			v.ToggleMark(fileNode)
+		}
+	}
+}
+
+// ClearMarks unmarks all files
+func (v *FileTreeView) ClearMarks() {
+	v.marked = make(map[string]bool)
+}
+
+// MarkedNodes returns marked files sorted by name; the marked files hidden since are left out,
+// so that the operations never touch the files the user can not see
+func (v *FileTreeView) MarkedNodes() []*model.FileNode {
+	var result []*model.FileNode
+	for _, fileNode := range v.ModelTree.PwdChildren() {
+		if v.marked[fileNode.Name] && !v.IsHidden(fileNode) {
+			result = append(result, fileNode)
+		}
+	}
+	return result
+}
+
+// MarkedSize returns the number of marked files and their total size; sizes of the directories are not counted
+func (v *FileTreeView) MarkedSize() (count int, size int64) {
+	for _, fileNode := range v.MarkedNodes() {
+		count++
+		if !fileNode.Data.FileInfo.IsDir() {
+			size += fileNode.Data.FileInfo.Size
+		}
+	}
+	return count, size
+}
+
+// CopyMarks carries over the marks from the other view of the same PWD, such as the one before the refresh.
+// Marks of the files that no longer exist are dropped.
+func (v *FileTreeView) CopyMarks(other *FileTreeView) {
+	if other == nil || other.ModelTree.GetPwd() != v.ModelTree.GetPwd() {
+		return
+	}
+
+	for name := range other.marked {
+		if v.ModelTree.GetNodeByName(name) != nil && name != ".." {
+			v.marked[name] = true
+		}
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"reflect"
 	"regexp"
 	"testing"
 
@@ -199,3 +200,140 @@ func TestFileTreeHideTypeWithFilter(t *testing.T) {
 
 	runTestCase(t, vm, width, height, regex)
 }
+
+func helperMarkedNames(vm *FileTreeView) []string {
+	var names []string
+	for _, fileNode := range vm.MarkedNodes() {
+		names = append(names, fileNode.Name)
+	}
+	return names
+}
+
+func TestFileTreeMarks(t *testing.T) {
+	vm := initializeTestViewModel(t)
+	checkError(t, vm.ModelTree.SetPwd("/bin"), "unable to set pwd")
+
+	changed, err := vm.MarkByGlob("c*", true)
+	checkError(t, err, "unable to mark by glob")
+	if changed != 4 {
+		t.Errorf("expected 4 marked files, got %d", changed)
+	}
+
+	// ".." can not be marked
+	vm.ToggleMark(vm.ModelTree.GetNodeByName(".."))
+	vm.ToggleMark(vm.ModelTree.GetNodeByName("echo"))
+	changed, err = vm.MarkByGlob("ch*", false)
+	checkError(t, err, "unable to unmark by glob")
+	if changed != 2 {
+		t.Errorf("expected 2 unmarked files, got %d", changed)
+	}
+
+	expected := []string{"cat", "cp", "echo"}
+	if actual := helperMarkedNames(vm); !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected %v, got %v", expected, actual)
+	}
+
+	vm.InvertMarks()
+	expected = []string{"chmod", "chown", "date", "dd", "df", "dmesg"}
+	if actual := helperMarkedNames(vm); !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected %v after invert, got %v", expected, actual)
+	}
+	if count, _ := vm.MarkedSize(); count != len(expected) {
+		t.Errorf("expected %d marked files, got %d", len(expected), count)
+	}
+
+	if _, err = vm.MarkByGlob("[", true); err == nil {
+		t.Errorf("expected error for malformed pattern")
+	}
+
+	refreshed, err := NewFileTreeView(vm.ModelTree)
+	checkError(t, err, "unable to create view")
+	refreshed.CopyMarks(vm)
+	if actual := helperMarkedNames(refreshed); !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected marks %v to be carried over, got %v", expected, actual)
+	}
+
+	vm.ClearMarks()
+	if count, size := vm.MarkedSize(); count != 0 || size != 0 {
+		t.Errorf("expected no marks, got %d (%d bytes)", count, size)
+	}
+}
+
+func TestFileTreeHidden(t *testing.T) {
+	vm := initializeTestViewModel(t)
+	checkError(t, vm.ModelTree.SetPwd("/bin"), "unable to set pwd")
+	vm.HiddenDiffTypes = make([]bool, 4)
+	vm.ToggleShowDiffType(model.Unmodified)
+
+	modified := vm.ModelTree.GetNodeByName("cp")
+	modified.Data.DiffType = model.Modified
+	unmodified := vm.ModelTree.GetNodeByName("cat")
+	parent := vm.ModelTree.GetNodeByName("..")
+
+	if vm.IsHidden(unmodified) {
+		t.Errorf("expected nothing to be hidden before the tree is compared")
+	}
+
+	vm.Compared = true
+	if !vm.IsHidden(unmodified) || vm.IsHidden(modified) || vm.IsHidden(parent) {
+		t.Errorf("expected only unmodified files to be hidden")
+	}
+
+	vm.InvertMarks()
+	expected := []string{"cp"}
+	if actual := helperMarkedNames(vm); !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected hidden files to be left unmarked, got %v", actual)
+	}
+
+	// the files marked before they got hidden are not returned
+	vm.ToggleShowDiffType(model.Modified)
+	if actual := helperMarkedNames(vm); len(actual) != 0 {
+		t.Errorf("expected hidden marked files to be left out, got %v", actual)
+	}
+	vm.ToggleShowDiffType(model.Modified)
+	if actual := helperMarkedNames(vm); !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected the marks to be back once shown, got %v", actual)
+	}
+}
+
+func TestFileTreeComparedDir(t *testing.T) {
+	vm := initializeTestViewModel(t)
+	checkError(t, vm.ModelTree.SetPwd("/var"), "unable to set pwd")
+	if vm.IsComparedDir("/var/lib") {
+		t.Errorf("expected no directory to be compared before the comparison")
+	}
+
+	vm.SetCompared(2)
+	for fqfp, expected := range map[string]bool{"/var": true, "/var/lib": true, "/var/lib/apt": false, "/": false, "/bin": false} {
+		if actual := vm.IsComparedDir(fqfp); actual != expected {
+			t.Errorf("%s: expected compared=%v, got %v", fqfp, expected, actual)
+		}
+	}
+
+	vm.ToggleMark(vm.ModelTree.GetNodeByName("lib"))
+	checkError(t, vm.ChangePwd("/var/lib"), "unable to change pwd")
+	if vm.ModelTree.GetPwd() != "/var/lib" || len(vm.MarkedNodes()) != 0 {
+		t.Errorf("expected to enter /var/lib with no marks, got %s and %d marks", vm.ModelTree.GetPwd(), len(vm.MarkedNodes()))
+	}
+}
+
+func TestFileTreeHiddenDiffTypeNames(t *testing.T) {
+	vm := initializeTestViewModel(t)
+
+	checkError(t, vm.HideDiffTypes([]string{" unmodified", "", "Added"}), "unable to hide DiffTypes")
+	expected := []string{"Added", "Unmodified"}
+	if actual := vm.HiddenDiffTypeNames(); !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected %v, got %v", expected, actual)
+	}
+
+	refreshed, err := NewFileTreeView(vm.ModelTree)
+	checkError(t, err, "unable to create view")
+	refreshed.CopyHiddenDiffTypes(vm)
+	if actual := refreshed.HiddenDiffTypeNames(); !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected hidden DiffTypes %v to be carried over, got %v", expected, actual)
+	}
+
+	if err = vm.HideDiffTypes([]string{"renamed"}); err == nil {
+		t.Errorf("expected error for unknown DiffType")
+	}
+}
@@ -0,0 +1,352 @@
+package view
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/mushkevych/9ofm/commander/model"
+)
+
+// ViewMode defines how the file content is presented by the FileView
+type ViewMode int
+
+const (
+	TextMode ViewMode = iota
+	HexMode
+)
+
+const (
+	// number of bytes presented in a single row of the hex dump
+	hexBytesPerRow = 16
+
+	// number of screen cells used by the tab character
+	tabWidth = 8
+)
+
+// FileViewRow is a single screen row of the FileView.
+// Offsets holds the file offset of every rune in the Text; decoration runes have offset -1.
+type FileViewRow struct {
+	Offset  int64 // file offset of the first byte presented in this row
+	Next    int64 // file offset of the first byte presented in the following row
+	Text    []rune
+	Offsets []int64
+}
+
+// FileView is a scrollable window over the file content, presented either as text or as hex dump
+type FileView struct {
+	Buffer *model.FileBuffer
+
+	Mode ViewMode
+	Wrap bool
+
+	// file offset of the first byte presented in the top-most row
+	Offset int64
+
+	// position of the last search match; MatchOffset is -1 if there is no match
+	MatchOffset int64
+	MatchLength int
+}
+
+// NewFileView creates a new view over the file at the given fully qualified file path
+func NewFileView(fqfp string) (*FileView, error) {
+	buffer, err := model.NewFileBuffer(fqfp)
+	if err != nil {
+		return nil, err
+	}
+
+	return &FileView{
+		Buffer:      buffer,
+		Mode:        TextMode,
+		Wrap:        true,
+		Offset:      0,
+		MatchOffset: -1,
+	}, nil
+}
+
+// Close releases the underlying FileBuffer
+func (v *FileView) Close() error {
+	return v.Buffer.Close()
+}
+
+// ToggleMode switches between the text and the hex modes, keeping the current position in the file
+func (v *FileView) ToggleMode() error {
+	if v.Mode == TextMode {
+		v.Mode = HexMode
+	} else {
+		v.Mode = TextMode
+	}
+	return v.GoTo(v.Offset)
+}
+
+// ToggleWrap switches long line wrapping on and off in the text mode
+func (v *FileView) ToggleWrap() {
+	v.Wrap = !v.Wrap
+}
+
+// Rows returns at most height rows of the given width, starting at the current offset
+func (v *FileView) Rows(width, height int) ([]FileViewRow, error) {
+	rows := make([]FileViewRow, 0, height)
+	offset := v.Offset
+	for i := 0; i < height && offset < v.Buffer.Size(); i++ {
+		row, err := v.row(offset, width)
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, row)
+		offset = row.Next
+	}
+	return rows, nil
+}
+
+func (v *FileView) row(offset int64, width int) (FileViewRow, error) {
+	if v.Mode == HexMode {
+		return v.hexRow(offset)
+	}
+	return v.textRow(offset, width)
+}
+
+// textRow builds a single row of the text starting at the given offset
+func (v *FileView) textRow(offset int64, width int) (FileViewRow, error) {
+	row := FileViewRow{Offset: offset}
+	data, err := v.Buffer.Slice(offset, width*utf8.UTFMax+1)
+	if err != nil {
+		return row, err
+	}
+
+	position := 0
+	cells := 0
+	for position < len(data) && cells < width {
+		r, size := utf8.DecodeRune(data[position:])
+		if r == '\n' {
+			break
+		}
+
+		switch {
+		case r == '\t':
+			for spaces := tabWidth - cells%tabWidth; spaces > 0 && cells < width; spaces-- {
+				row.Text = append(row.Text, ' ')
+				row.Offsets = append(row.Offsets, offset+int64(position))
+				cells++
+			}
+		case r == utf8.RuneError || !unicode.IsPrint(r):
+			row.Text = append(row.Text, '.')
+			row.Offsets = append(row.Offsets, offset+int64(position))
+			cells++
+		default:
+			row.Text = append(row.Text, r)
+			row.Offsets = append(row.Offsets, offset+int64(position))
+			cells++
+		}
+		position += size
+	}
+
+	if !v.Wrap {
+		row.Next, err = v.Buffer.NextLineStart(offset)
+		return row, err
+	}
+
+	if position < len(data) && data[position] == '\n' {
+		// the line ends within the row: consume the line break
+		position++
+	}
+	row.Next = offset + int64(position)
+	return row, nil
+}
+
+// hexRow builds a single row of the hex dump starting at the given offset, which is aligned to hexBytesPerRow
+func (v *FileView) hexRow(offset int64) (FileViewRow, error) {
+	row := FileViewRow{Offset: offset, Next: offset + hexBytesPerRow}
+	data, err := v.Buffer.Slice(offset, hexBytesPerRow)
+	if err != nil {
+		return row, err
+	}
+
+	appendDecoration := func(text string) {
+		for _, r := range text {
+			row.Text = append(row.Text, r)
+			row.Offsets = append(row.Offsets, -1)
+		}
+	}
+
+	appendDecoration(fmt.Sprintf("%08x  ", offset))
+	for i := 0; i < hexBytesPerRow; i++ {
+		if i < len(data) {
+			for _, r := range fmt.Sprintf("%02x", data[i]) {
+				row.Text = append(row.Text, r)
+				row.Offsets = append(row.Offsets, offset+int64(i))
+			}
+		} else {
+			appendDecoration("  ")
+		}
+
+		if i == hexBytesPerRow/2-1 {
+			appendDecoration("  ")
+		} else {
+			appendDecoration(" ")
+		}
+	}
+
+	appendDecoration(" |")
+	for i, b := range data {
+		r := '.'
+		if b >= 0x20 && b < 0x7f {
+			r = rune(b)
+		}
+		row.Text = append(row.Text, r)
+		row.Offsets = append(row.Offsets, offset+int64(i))
+	}
+	appendDecoration("|")
+	return row, nil
+}
+
+// ScrollDown moves the view n rows towards the end of the file
+func (v *FileView) ScrollDown(width, n int) error {
+	for i := 0; i < n; i++ {
+		row, err := v.row(v.Offset, width)
+		if err != nil {
+			return err
+		}
+		if row.Next >= v.Buffer.Size() {
+			break
+		}
+		v.Offset = row.Next
+	}
+	return nil
+}
+
+// ScrollUp moves the view n rows towards the beginning of the file
+func (v *FileView) ScrollUp(width, n int) error {
+	for i := 0; i < n && v.Offset > 0; i++ {
+		if v.Mode == HexMode {
+			v.Offset -= hexBytesPerRow
+			continue
+		}
+
+		offset, err := v.Buffer.LineStart(v.Offset - 1)
+		if err != nil {
+			return err
+		}
+
+		if v.Wrap {
+			// previous line may span several rows: find the last one
+			for {
+				row, err := v.textRow(offset, width)
+				if err != nil {
+					return err
+				}
+				if row.Next >= v.Offset || row.Next == offset {
+					break
+				}
+				offset = row.Next
+			}
+		}
+		v.Offset = offset
+	}
+
+	if v.Offset < 0 {
+		v.Offset = 0
+	}
+	return nil
+}
+
+// ScrollToBeginning moves the view to the beginning of the file
+func (v *FileView) ScrollToBeginning() {
+	v.Offset = 0
+}
+
+// ScrollToEnd moves the view so that the last row of the file is at the bottom of the given height
+func (v *FileView) ScrollToEnd(width, height int) error {
+	size := v.Buffer.Size()
+	if v.Mode == HexMode {
+		v.Offset = 0
+		if size > 0 {
+			v.Offset = (size - 1) / hexBytesPerRow * hexBytesPerRow
+		}
+		return v.ScrollUp(width, height-1)
+	}
+
+	v.Offset = size
+	return v.ScrollUp(width, height)
+}
+
+// GoTo moves the view to the row containing the given offset
+func (v *FileView) GoTo(offset int64) error {
+	if offset >= v.Buffer.Size() {
+		offset = v.Buffer.Size() - 1
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	if v.Mode == HexMode {
+		v.Offset = offset / hexBytesPerRow * hexBytesPerRow
+		return nil
+	}
+
+	lineStart, err := v.Buffer.LineStart(offset)
+	if err != nil {
+		return err
+	}
+	v.Offset = lineStart
+	return nil
+}
+
+// Search looks for the next (forward is true) or the previous occurrence of the pattern
+// relative to the last match or, if there is none, to the current offset.
+// The view is moved to the match, if one is found.
+func (v *FileView) Search(pattern string, forward bool) error {
+	from := v.Offset
+	if v.MatchOffset >= 0 {
+		from = v.MatchOffset
+		if forward {
+			from++
+		}
+	}
+
+	offset, err := v.Buffer.Search([]byte(pattern), from, forward)
+	if err != nil {
+		return err
+	}
+
+	v.MatchOffset = offset
+	v.MatchLength = len(pattern)
+	return v.GoTo(offset)
+}
+
+// IsMatch returns true if the byte at the given offset belongs to the last search match
+func (v *FileView) IsMatch(offset int64) bool {
+	return v.MatchOffset >= 0 && offset >= v.MatchOffset && offset < v.MatchOffset+int64(v.MatchLength)
+}
+
+// ParseOffset converts user input into the file offset. Supported formats are:
+// decimal "1024", hexadecimal "0x400" and percentage of the file size "50%"
+func ParseOffset(text string, size int64) (int64, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return 0, errors.New("empty offset")
+	}
+
+	if strings.HasSuffix(text, "%") {
+		percent, err := strconv.ParseFloat(strings.TrimSuffix(text, "%"), 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid percentage: %s", text)
+		}
+		if percent < 0 || percent > 100 {
+			return 0, fmt.Errorf("percentage out of range: %s", text)
+		}
+		return int64(float64(size) * percent / 100), nil
+	}
+
+	offset, err := strconv.ParseInt(text, 0, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid offset: %s", text)
+	}
+	if offset < 0 {
+		return 0, fmt.Errorf("negative offset: %s", text)
+	}
+	return offset, nil
+}
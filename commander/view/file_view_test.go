@@ -0,0 +1,134 @@
+package view
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func helperCreateFileView(t *testing.T, content string) *FileView {
+	file, err := ioutil.TempFile("", "9ofm-file-view")
+	if err != nil {
+		t.Fatalf("unable to create temp file: %v", err)
+	}
+	t.Cleanup(func() { _ = os.Remove(file.Name()) })
+
+	if _, err = file.WriteString(content); err != nil {
+		t.Fatalf("unable to write temp file: %v", err)
+	}
+	_ = file.Close()
+
+	fv, err := NewFileView(file.Name())
+	if err != nil {
+		t.Fatalf("unable to open file view: %v", err)
+	}
+	t.Cleanup(func() { _ = fv.Close() })
+	return fv
+}
+
+func helperRowsAsStrings(t *testing.T, fv *FileView, width, height int) []string {
+	rows, err := fv.Rows(width, height)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var result []string
+	for _, row := range rows {
+		result = append(result, string(row.Text))
+	}
+	return result
+}
+
+func helperAssertRows(t *testing.T, expected, actual []string) {
+	if len(expected) != len(actual) {
+		t.Fatalf("expected rows %q, got %q", expected, actual)
+	}
+	for idx := range expected {
+		if expected[idx] != actual[idx] {
+			t.Errorf("row %d: expected %q, got %q", idx, expected[idx], actual[idx])
+		}
+	}
+}
+
+func TestFileViewTextRows(t *testing.T) {
+	fv := helperCreateFileView(t, "abcdefgh\n\tx\n\nlast")
+
+	helperAssertRows(t, []string{"abcde", "fgh", "     ", "x", "", "last"}, helperRowsAsStrings(t, fv, 5, 10))
+
+	fv.ToggleWrap()
+	helperAssertRows(t, []string{"abcde", "     ", "", "last"}, helperRowsAsStrings(t, fv, 5, 10))
+}
+
+func TestFileViewScroll(t *testing.T) {
+	fv := helperCreateFileView(t, "abcdefgh\nij\nkl\n")
+
+	if err := fv.ScrollDown(5, 2); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	helperAssertRows(t, []string{"ij", "kl"}, helperRowsAsStrings(t, fv, 5, 10))
+
+	if err := fv.ScrollUp(5, 1); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	helperAssertRows(t, []string{"fgh", "ij", "kl"}, helperRowsAsStrings(t, fv, 5, 10))
+
+	if err := fv.ScrollToEnd(5, 2); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	helperAssertRows(t, []string{"ij", "kl"}, helperRowsAsStrings(t, fv, 5, 10))
+
+	fv.ScrollToBeginning()
+	helperAssertRows(t, []string{"abcde"}, helperRowsAsStrings(t, fv, 5, 1))
+}
+
+func TestFileViewHexRows(t *testing.T) {
+	fv := helperCreateFileView(t, "0123456789abcdef\x00z")
+	if err := fv.ToggleMode(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	helperAssertRows(t, []string{
+		"00000000  30 31 32 33 34 35 36 37  38 39 61 62 63 64 65 66  |0123456789abcdef|",
+		"00000010  00 7a                                             |.z|",
+	}, helperRowsAsStrings(t, fv, 80, 10))
+}
+
+func TestFileViewSearch(t *testing.T) {
+	fv := helperCreateFileView(t, "one\ntwo\none\n")
+
+	if err := fv.Search("one", true); err != nil || fv.MatchOffset != 0 {
+		t.Errorf("expected match at 0, got %d (%v)", fv.MatchOffset, err)
+	}
+	if err := fv.Search("one", true); err != nil || fv.MatchOffset != 8 || fv.Offset != 8 {
+		t.Errorf("expected match at 8, got %d (%v)", fv.MatchOffset, err)
+	}
+	if err := fv.Search("one", false); err != nil || fv.MatchOffset != 0 || fv.Offset != 0 {
+		t.Errorf("expected match at 0, got %d (%v)", fv.MatchOffset, err)
+	}
+	if !fv.IsMatch(2) || fv.IsMatch(3) {
+		t.Errorf("unexpected match boundaries")
+	}
+}
+
+func TestParseOffset(t *testing.T) {
+	// format: <input: expected offset>
+	fixture := map[string]int64{
+		"1024":  1024,
+		"0x400": 1024,
+		"50%":   500,
+		" 7 ":   7,
+	}
+
+	for input, expected := range fixture {
+		actual, err := ParseOffset(input, 1000)
+		if err != nil || actual != expected {
+			t.Errorf("ParseOffset(%q): expected %d, got %d (%v)", input, expected, actual, err)
+		}
+	}
+
+	for _, input := range []string{"", "abc", "-1", "101%"} {
+		if _, err := ParseOffset(input, 1000); err == nil {
+			t.Errorf("ParseOffset(%q): expected error", input)
+		}
+	}
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mushkevych/9ofm/commander/controller"
+	"github.com/mushkevych/9ofm/commander/model"
+	"github.com/mushkevych/9ofm/commander/report"
+	"github.com/mushkevych/9ofm/commander/syncplan"
+	"github.com/mushkevych/9ofm/commander/system"
+)
+
+// commands run without the UI: 9ofm <command> [flags] [arguments]; each returns the exit code of the process
+var commands = map[string]func(args []string) int{
+	"sync":    syncCommand,
+	"compare": compareCommand,
+}
+
+// usageExitCode returns the exit code for the error of parsing the flags of a command; asking for help is no error
+func usageExitCode(err error) int {
+	if err == flag.ErrHelp {
+		return 0
+	}
+	return 2
+}
+
+// syncCommand executes the sync plan exported from the UI
+func syncCommand(args []string) int {
+	flags := flag.NewFlagSet("sync", flag.ContinueOnError)
+	dryRun := flags.Bool("dry-run", false, "List the items of the plan without executing them")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: 9ofm sync [-dry-run] <plan.json>")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return usageExitCode(err)
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return 2
+	}
+
+	plan, err := syncplan.Load(flags.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	if *dryRun {
+		for _, item := range plan.Items {
+			if item.Action() != syncplan.Skip {
+				fmt.Println(item)
+			}
+		}
+		return 0
+	}
+
+	err = plan.Execute(nil, func(item syncplan.Item, err error) {
+		if err == nil {
+			fmt.Println(item)
+		}
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
+
+// patterns is the flag.Value of the repeatable flag holding shell patterns; every value may list several patterns
+type patterns []string
+
+func (p *patterns) String() string {
+	return strings.Join(*p, ",")
+}
+
+func (p *patterns) Set(value string) error {
+	for _, pattern := range strings.Split(value, ",") {
+		if pattern = strings.TrimSpace(pattern); pattern != "" {
+			*p = append(*p, pattern)
+		}
+	}
+	return nil
+}
+
+// compareCommand compares two directories the way the deep comparison of the panels does, and writes the report.
+// The exit code is 0 if the trees are equal, 1 if they differ, and 2 if they could not be compared.
+func compareCommand(args []string) int {
+	defaults := controller.DeepCompareOptions()
+	ignores := patterns(defaults.Excludes)
+	criteria, err := controller.CompareCriteria()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	flags := flag.NewFlagSet("compare", flag.ContinueOnError)
+	formatName := flags.String("format", string(report.Grouped), "Format of the report: grouped, json, csv or text")
+	output := flags.String("o", "", "Write the report into the file instead of the standard output")
+	depth := flags.Int("depth", defaults.Depth, "Number of directory levels compared; 0 compares the whole trees")
+	hashing := flags.Bool("hash", criteria.Content,
+		"Compare the content of the files of equal size as well; adds hash to the criteria, -hash=false removes it")
+	criteriaNames := flags.String("criteria", strings.Join(criteria.Names(), ","),
+		"Properties of the files compared: size, mtime, perms, owner, link and hash")
+	tolerance := flags.Duration("mtime-tolerance", criteria.ModTimeTolerance,
+		"Largest difference of the modification times still considered equal")
+	flags.Var(&ignores, "ignore", "Shell pattern of the names left out of the comparison, such as .git; repeatable, adds to compare.exclude")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: 9ofm compare [flags] <dirA> <dirB>")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return usageExitCode(err)
+	}
+	format, err := report.ParseFormat(*formatName)
+	if err == nil {
+		criteria, err = model.ParseCriteria(strings.Split(*criteriaNames, ","), *tolerance)
+	}
+	if err != nil || flags.NArg() != 2 {
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+		}
+		flags.Usage()
+		return 2
+	}
+
+	// -hash given explicitly overrides the hash of the criteria either way
+	flags.Visit(func(f *flag.Flag) {
+		if f.Name == "hash" {
+			criteria.Content = *hashing
+		}
+	})
+
+	options := model.ReadOptions{Depth: *depth, Excludes: ignores}
+	left, right, err := compareDirs(flags.Arg(0), flags.Arg(1), options, criteria)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	comparison, err := report.Build(left, right)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	var w io.Writer = os.Stdout
+	var file *os.File
+	if *output != "" {
+		if file, err = os.Create(*output); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+		w = file
+	}
+	err = comparison.Write(w, format)
+	if file != nil {
+		// the report is complete only once the file is closed
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	if comparison.Differences() > 0 {
+		return 1
+	}
+	return 0
+}
+
+// compareDirs reads the trees of both directories with the options, and compares them by the criteria
+func compareDirs(dirA, dirB string, options model.ReadOptions, criteria model.Criteria) (left, right *model.FileTreeModel, err error) {
+	var trees [2]*model.FileTreeModel
+	for idx, dir := range []string{dirA, dirB} {
+		if dir, err = filepath.Abs(dir); err != nil {
+			return nil, nil, err
+		}
+		if trees[idx], err = model.ReadFileTreeWithOptions(dir, options, nil); err != nil {
+			return nil, nil, err
+		}
+	}
+	left, right = trees[0], trees[1]
+
+	if criteria.Content {
+		candidates := model.HashCandidates(left, right)
+		if err = model.HashFiles(candidates, system.Config.GetInt("compare.workers"), nil); err != nil {
+			// files that could not be hashed are compared as modified
+			fmt.Fprintln(os.Stderr, err)
+		}
+	}
+	return left, right, model.CompareAndMarkWith(left, right, criteria)
+}
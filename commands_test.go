@@ -0,0 +1,79 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// helperCompareDirs writes the files into the directories alpha and beta of a temporary directory
+func helperCompareDirs(t *testing.T, alpha, beta map[string]string) (root, dirA, dirB string) {
+	root = t.TempDir()
+	dirA, dirB = filepath.Join(root, "alpha"), filepath.Join(root, "beta")
+	for dir, files := range map[string]map[string]string{dirA: alpha, dirB: beta} {
+		if err := os.Mkdir(dir, 0755); err != nil {
+			t.Fatalf("could not setup test: %v", err)
+		}
+		for name, content := range files {
+			if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+				t.Fatalf("could not setup test: %v", err)
+			}
+		}
+	}
+	return root, dirA, dirB
+}
+
+func TestCompareCommandExitCode(t *testing.T) {
+	files := map[string]string{"same.txt": "same", "changed.txt": "short"}
+	changed := map[string]string{"same.txt": "same", "changed.txt": "much longer"}
+
+	for _, tc := range []struct {
+		name     string
+		beta     map[string]string
+		args     func(root, dirA, dirB string) []string
+		expected int
+	}{
+		{"equal", files, func(root, dirA, dirB string) []string {
+			return []string{"-o", filepath.Join(root, "report"), dirA, dirB}
+		}, 0},
+		{"different", changed, func(root, dirA, dirB string) []string {
+			return []string{"-o", filepath.Join(root, "report"), dirA, dirB}
+		}, 1},
+		{"missing directory", files, func(root, dirA, dirB string) []string {
+			return []string{"-o", filepath.Join(root, "report"), dirA, filepath.Join(root, "missing")}
+		}, 2},
+		{"unwritable report", files, func(root, dirA, dirB string) []string {
+			return []string{"-o", filepath.Join(root, "missing", "report"), dirA, dirB}
+		}, 2},
+		{"invalid format", files, func(root, dirA, dirB string) []string {
+			return []string{"-format", "xml", dirA, dirB}
+		}, 2},
+		{"one directory", files, func(root, dirA, dirB string) []string {
+			return []string{dirA}
+		}, 2},
+	} {
+		root, dirA, dirB := helperCompareDirs(t, files, tc.beta)
+		if actual := compareCommand(tc.args(root, dirA, dirB)); actual != tc.expected {
+			t.Errorf("%s: expected exit code %d, got %d", tc.name, tc.expected, actual)
+		}
+	}
+}
+
+func TestCompareCommandReport(t *testing.T) {
+	root, dirA, dirB := helperCompareDirs(t,
+		map[string]string{"changed.txt": "short"}, map[string]string{"changed.txt": "much longer"})
+	output := filepath.Join(root, "report.csv")
+
+	if actual := compareCommand([]string{"-format", "csv", "-o", output, dirA, dirB}); actual != 1 {
+		t.Fatalf("expected exit code 1, got %d", actual)
+	}
+	content, err := ioutil.ReadFile(output)
+	if err != nil {
+		t.Fatalf("expected the report to be written: %v", err)
+	}
+	if !strings.Contains(string(content), "changed.txt") {
+		t.Errorf("expected the report to list the changed file, got %q", content)
+	}
+}
@@ -11,5 +11,5 @@ require (
 	github.com/sirupsen/logrus v1.7.0
 	github.com/ufoscout/go-up v0.6.1
 	gitlab.com/tslocum/cview v1.5.2
-	golang.org/x/sys v0.0.0-20201204225414-ed752295db88 // indirect
+	golang.org/x/sys v0.0.0-20201204225414-ed752295db88
 )
@@ -35,9 +35,10 @@ import (
 )
 
 var (
-	flgVersion bool
-	alphaRoot  string
-	betaRoot   string
+	flgVersion   bool
+	flgNoRestore bool
+	alphaRoot    string
+	betaRoot     string
 
 	sha1ver   string // sha1 revision used to build the program
 	buildTime string // when the executable was built
@@ -75,7 +76,7 @@ func initLogging() {
 }
 
 // Run is the UI entrypoint.
-func Run() error {
+func Run(options commander.Options) error {
 	var err error
 
 	tviewApp := tview.NewApplication()
@@ -85,7 +86,7 @@ func Run() error {
 	defer tviewApp.Stop()
 
 	once.Do(func() {
-		application, err = commander.NewApplication(tviewApp)
+		application, err = commander.NewApplication(tviewApp, options)
 		if err != nil {
 			return
 		}
@@ -96,19 +97,27 @@ func Run() error {
 			return
 		}
 	})
+	if err != nil {
+		return err
+	}
 
 	if err := tviewApp.Run(); err != nil {
 		log.Error("main loop error: ", err)
 		return err
 	}
+
+	if err := application.SaveSession(); err != nil {
+		log.WithError(err).Error("unable to save the session")
+		system.MessageBus.Error(fmt.Sprintf("unable to save the session: %s", err))
+	}
 	return nil
 }
 
-func start(events system.EventChannel) {
+func start(events system.EventChannel, options commander.Options) {
 	var err error
 	defer close(events)
 
-	err = Run()
+	err = Run(options)
 	if err != nil {
 		events.ExitWithError(err)
 		return
@@ -116,10 +125,28 @@ func start(events system.EventChannel) {
 }
 
 func main() {
+	if len(os.Args) > 1 {
+		if command, ok := commands[os.Args[1]]; ok {
+			os.Exit(command(os.Args[2:]))
+		}
+	}
+
 	flag.BoolVar(&flgVersion, "version", false, "Version of the 9ofm")
-	flag.StringVar(&alphaRoot, "a", "/", "Starting path for panel A")
-	flag.StringVar(&betaRoot, "b", "/", "Starting path for panel B")
+	flag.StringVar(&alphaRoot, "a", "", "Starting path for panel A (default starting_path)")
+	flag.StringVar(&betaRoot, "b", "", "Starting path for panel B (default starting_path)")
+	flag.BoolVar(&flgNoRestore, "no-restore", false, "Start afresh instead of restoring the panels of the previous session")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "Usage: 9ofm [flags] [starting_path]")
+		fmt.Fprintf(flag.CommandLine.Output(),
+			"starting_path is the starting path for both panels (default: the paths of the previous session, or %s)\n",
+			commander.DefaultRoot)
+		flag.PrintDefaults()
+	}
 	flag.Parse()
+	if flag.NArg() > 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
 
 	if flgVersion {
 		fmt.Printf("Build on %s from sha1 %s\n", buildTime, sha1ver)
@@ -127,8 +154,14 @@ func main() {
 	}
 	initLogging()
 
+	options := commander.Options{
+		AlphaRoot:    alphaRoot,
+		BetaRoot:     betaRoot,
+		StartingPath: flag.Arg(0),
+		NoRestore:    flgNoRestore,
+	}
 	messageBus := system.NewEventChannel()
-	go start(messageBus)
+	go start(messageBus, options)
 
 	exitCode := system.MainEventLoop(messageBus)
 	os.Exit(exitCode)
@@ -1,8 +1,12 @@
 package utils
 
 import (
+	"fmt"
+	"io"
+	"io/ioutil"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
 )
 
@@ -17,8 +21,8 @@ func CleanArgs(s []string) []string {
 	return r
 }
 
-// runCmd runs a given shell command in the current tty
-func runCmd(cmdStr string, args ...string) error {
+// RunCmd runs a given shell command in the current tty
+func RunCmd(cmdStr string, args ...string) error {
 	allArgs := CleanArgs(args)
 
 	cmd := exec.Command(cmdStr, allArgs...)
@@ -30,3 +34,86 @@ func runCmd(cmdStr string, args ...string) error {
 
 	return cmd.Run()
 }
+
+// EditorCommand resolves the editor command line: the configured one, then $EDITOR, then the platform default.
+// Returns the command and its arguments.
+func EditorCommand(configured string) (string, []string) {
+	editor := strings.TrimSpace(configured)
+	if editor == "" {
+		editor = strings.TrimSpace(os.Getenv("EDITOR"))
+	}
+	if editor == "" {
+		editor = DefaultEditor
+	}
+
+	tokens := strings.Fields(editor)
+	return tokens[0], tokens[1:]
+}
+
+// ShellCommand resolves the subshell command line: the configured one, then $SHELL, then the platform default.
+// Returns the command and its arguments.
+func ShellCommand(configured string) (string, []string) {
+	shell := strings.TrimSpace(configured)
+	if shell == "" {
+		shell = strings.TrimSpace(os.Getenv("SHELL"))
+	}
+	if shell == "" {
+		shell = DefaultShell
+	}
+
+	tokens := strings.Fields(shell)
+	return tokens[0], tokens[1:]
+}
+
+// RunShell runs an interactive shell in the current tty, starting in the given directory.
+// Returns the directory the shell was in when it exited; if that can not be determined - the starting directory.
+func RunShell(dir string, shell string, args ...string) (string, error) {
+	// the shell writes its working directory into the file on exit, see shellHook
+	hookDir, err := ioutil.TempDir("", "9ofm-shell")
+	if err != nil {
+		return dir, err
+	}
+	defer os.RemoveAll(hookDir)
+	dirFile := filepath.Join(hookDir, "pwd")
+
+	args, env, err := shellHook(shell, CleanArgs(args), hookDir, dirFile)
+	if err != nil {
+		return dir, err
+	}
+	cmd := exec.Command(shell, args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), env...)
+
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Stdin = os.Stdin
+
+	fmt.Printf("9ofm: type 'exit' to return to the panels\n")
+	err = cmd.Run()
+	if _, ok := err.(*exec.ExitError); ok {
+		// non-zero exit status of the last command typed into the shell is not an error of the shell itself
+		err = nil
+	}
+
+	finalDir := dir
+	if content, readErr := ioutil.ReadFile(dirFile); readErr == nil {
+		if shellDir := strings.TrimSuffix(string(content), "\n"); shellDir != "" {
+			finalDir = shellDir
+		}
+	}
+	return finalDir, err
+}
+
+// readKey reads the input until the key
+func readKey(input io.Reader, key byte) error {
+	buffer := make([]byte, 1)
+	for {
+		if _, err := io.ReadFull(input, buffer); err != nil {
+			return err
+		}
+		if buffer[0] == key {
+			fmt.Println()
+			return nil
+		}
+	}
+}
@@ -0,0 +1,12 @@
+// +build linux
+
+package utils
+
+// DefaultEditor is used when neither 9ofm configuration nor $EDITOR define the editor
+const DefaultEditor = "vi"
+
+// DefaultShell is used when neither 9ofm configuration nor $SHELL define the shell
+const DefaultShell = "/bin/sh"
+
+// stdinFile is the name of the standard input in the file system
+const stdinFile = "/dev/fd/0"
@@ -0,0 +1,12 @@
+// +build plan9
+
+package utils
+
+// DefaultEditor is used when neither 9ofm configuration nor $EDITOR define the editor
+const DefaultEditor = "acme"
+
+// DefaultShell is used when neither 9ofm configuration nor $SHELL define the shell
+const DefaultShell = "rc"
+
+// stdinFile is the name of the standard input in the file system
+const stdinFile = "/fd/0"
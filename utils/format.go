@@ -0,0 +1,39 @@
+package utils
+
+import (
+	"fmt"
+	"time"
+)
+
+// HumanBytes formats the number of bytes with a binary unit suffix, e.g. 1536 as "1.5 KiB"
+func HumanBytes(bytes int64) string {
+	const unit = 1024
+	if bytes < unit && bytes > -unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+
+	value := float64(bytes)
+	suffixes := []string{"KiB", "MiB", "GiB", "TiB", "PiB", "EiB"}
+	idx := -1
+	for (value >= unit || value <= -unit) && idx < len(suffixes)-1 {
+		value /= unit
+		idx++
+	}
+	return fmt.Sprintf("%.1f %s", value, suffixes[idx])
+}
+
+// HumanDuration formats the duration as "hh:mm:ss", or "mm:ss" if it is shorter than an hour
+func HumanDuration(duration time.Duration) string {
+	seconds := int64(duration.Round(time.Second) / time.Second)
+	if seconds < 0 {
+		seconds = 0
+	}
+
+	hours := seconds / 3600
+	minutes := seconds % 3600 / 60
+	seconds = seconds % 60
+	if hours > 0 {
+		return fmt.Sprintf("%02d:%02d:%02d", hours, minutes, seconds)
+	}
+	return fmt.Sprintf("%02d:%02d", minutes, seconds)
+}
@@ -0,0 +1,84 @@
+package utils
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// startup files of zsh, in the order zsh reads them; each of them is read from $ZDOTDIR
+var zshStartupFiles = []string{".zshenv", ".zprofile", ".zshrc", ".zlogin"}
+
+// shellHook makes the shell write its working directory into the dirFile when it exits, by adding the exit trap
+// to its startup files, which are created in the hookDir, or to the command it starts with. Returns the arguments to start the shell with and
+// the variables to add to its environment; the shells without the known startup files are started unchanged.
+func shellHook(shell string, args []string, hookDir, dirFile string) ([]string, []string, error) {
+	trap := "trap " + shellQuote("pwd > "+shellQuote(dirFile)) + " EXIT\n"
+
+	switch filepath.Base(shell) {
+	case "bash":
+		// interactive bash ignores $ENV, but reads the file given with --rcfile instead of ~/.bashrc
+		rcFile := filepath.Join(hookDir, "bashrc")
+		content := "[ -f ~/.bashrc ] && . ~/.bashrc\n" + trap
+		if err := ioutil.WriteFile(rcFile, []byte(content), 0600); err != nil {
+			return nil, nil, err
+		}
+		return append([]string{"--rcfile", rcFile}, args...), nil, nil
+
+	case "zsh":
+		// zsh reads its startup files from $ZDOTDIR; each of them reads the user's own file from the original location
+		userDir := os.Getenv("ZDOTDIR")
+		if userDir == "" {
+			userDir = os.Getenv("HOME")
+		}
+		for _, name := range zshStartupFiles {
+			userFile := shellQuote(filepath.Join(userDir, name))
+			content := "ZDOTDIR=" + shellQuote(userDir) + "\n" +
+				"[ -f " + userFile + " ] && . " + userFile + "\n"
+			// the shell is left with the user's $ZDOTDIR once the last of its startup files is read
+			switch name {
+			case ".zshrc":
+				content += trap + "[[ -o login ]] && ZDOTDIR=" + shellQuote(hookDir) + "\n"
+			case ".zlogin":
+			default:
+				content += "ZDOTDIR=" + shellQuote(hookDir) + "\n"
+			}
+			if err := ioutil.WriteFile(filepath.Join(hookDir, name), []byte(content), 0600); err != nil {
+				return nil, nil, err
+			}
+		}
+		return args, []string{"ZDOTDIR=" + hookDir}, nil
+
+	case "sh", "ash", "dash", "ksh", "mksh", "yash":
+		// the interactive POSIX shells read the file named by $ENV
+		rcFile := filepath.Join(hookDir, "shrc")
+		content := trap
+		if userFile := os.Getenv("ENV"); userFile != "" {
+			content = "[ -f " + shellQuote(userFile) + " ] && . " + shellQuote(userFile) + "\n" + content
+		}
+		if err := ioutil.WriteFile(rcFile, []byte(content), 0600); err != nil {
+			return nil, nil, err
+		}
+		return args, []string{"ENV=" + rcFile}, nil
+
+	case "rc":
+		// rc reads no startup file unless it is the login shell: instead, the session is read from the standard input
+		// by "." started with -c, and the sigexit function is run once the session ends
+		script := "fn sigexit { pwd > " + rcQuote(dirFile) + " }\n. -i " + stdinFile
+		return append(args, "-c", script), nil, nil
+
+	default:
+		return args, nil, nil
+	}
+}
+
+// shellQuote quotes the string for the POSIX shells
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// rcQuote quotes the string for rc
+func rcQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
@@ -0,0 +1,32 @@
+// +build linux
+
+package utils
+
+import (
+	"fmt"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// WaitForKey prints the prompt and blocks until the user presses the key read from the terminal as the given byte,
+// such as 0x0f for Ctrl+O. The terminal is in the raw mode meanwhile, so that the key is read as soon as it is typed.
+func WaitForKey(prompt string, key byte) error {
+	fd := int(os.Stdin.Fd())
+	saved, err := unix.IoctlGetTermios(fd, unix.TCGETS)
+	if err != nil {
+		return err
+	}
+	raw := *saved
+	// Ctrl+O is the "discard" character of the extended input processing, hence IEXTEN is off as well
+	raw.Lflag &^= unix.ICANON | unix.ECHO | unix.IEXTEN
+	raw.Cc[unix.VMIN] = 1
+	raw.Cc[unix.VTIME] = 0
+	if err = unix.IoctlSetTermios(fd, unix.TCSETS, &raw); err != nil {
+		return err
+	}
+	defer unix.IoctlSetTermios(fd, unix.TCSETS, saved)
+
+	fmt.Print(prompt)
+	return readKey(os.Stdin, key)
+}
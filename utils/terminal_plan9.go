@@ -0,0 +1,25 @@
+// +build plan9
+
+package utils
+
+import (
+	"fmt"
+	"os"
+)
+
+// WaitForKey prints the prompt and blocks until the user presses the key read from the console as the given byte,
+// such as 0x0f for Ctrl+O. The console is in the raw mode meanwhile, so that the key is read as soon as it is typed.
+func WaitForKey(prompt string, key byte) error {
+	// the console is back in the cooked mode once the control file is closed
+	consctl, err := os.OpenFile("/dev/consctl", os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+	defer consctl.Close()
+	if _, err = consctl.WriteString("rawon"); err != nil {
+		return err
+	}
+
+	fmt.Print(prompt)
+	return readKey(os.Stdin, key)
+}